@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"pvz/internal/config"
+	"pvz/internal/storage/migrations"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+const migrateUsage = "usage: pvz migrate [flags] up|down|status|redo"
+
+// runMigrate выполняет команду migrate над встроенными миграциями.
+// Для неё нужна только база, поэтому остальная конфигурация не проверяется.
+func runMigrate(cfg config.DatabaseConfig, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+	if cfg.DSN == "" {
+		return errors.New("database.dsn is required")
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	provider, err := migrations.NewProvider(db)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch args[0] {
+	case "up":
+		results, err := provider.Up(ctx)
+		printResults(results...)
+		if err == nil && len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		result, err := provider.Down(ctx)
+		printResults(result)
+		return err
+	case "redo":
+		result, err := provider.Down(ctx)
+		printResults(result)
+		if err != nil {
+			return err
+		}
+		result, err = provider.UpByOne(ctx)
+		printResults(result)
+		return err
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATE\tAPPLIED AT\tMIGRATION")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if status.State == goose.StateApplied {
+				appliedAt = status.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", status.State, appliedAt, status.Source.Path)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+}
+
+func printResults(results ...*goose.MigrationResult) {
+	for _, result := range results {
+		if result != nil {
+			fmt.Println(result)
+		}
+	}
+}
+
+// migrateUp применяет недостающие миграции перед запуском сервиса.
+func migrateUp(ctx context.Context, provider *goose.Provider) error {
+	results, err := provider.Up(ctx)
+	for _, result := range results {
+		log.Println("applied migration", result.Source.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
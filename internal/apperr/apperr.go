@@ -0,0 +1,102 @@
+// Package apperr описывает ошибки предметной области. Каждая ошибка относится
+// к одному из видов (ErrNotFound, ErrConflict, ...) и несёт стабильный
+// машиночитаемый код, по которому клиент может отличать ошибки одного вида.
+package apperr
+
+import "errors"
+
+// Виды ошибок. Проверяются через errors.Is:
+//
+//	errors.Is(err, apperr.ErrNotFound)
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrForbidden       = errors.New("forbidden")
+	ErrValidation      = errors.New("validation failed")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrUnavailable     = errors.New("service unavailable")
+	ErrInternal        = errors.New("internal error")
+)
+
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Err     error
+}
+
+func New(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return New(ErrNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(ErrConflict, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(ErrForbidden, code, message)
+}
+
+func Validation(code, message string) *Error {
+	return New(ErrValidation, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(ErrUnauthorized, code, message)
+}
+
+func TooManyRequests(code, message string) *Error {
+	return New(ErrTooManyRequests, code, message)
+}
+
+func Unavailable(code, message string) *Error {
+	return New(ErrUnavailable, code, message)
+}
+
+// Internal скрывает причину от клиента: в ответ попадает только код,
+// а err остаётся доступен через errors.Unwrap для логов.
+func Internal(err error) *Error {
+	return &Error{Kind: ErrInternal, Code: "internal", Message: "internal server error", Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is сопоставляет ошибку с её видом, поэтому errors.Is(err, ErrConflict)
+// истинно для любой ошибки, созданной через Conflict.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// Kind возвращает вид ошибки или ErrInternal, если err не относится
+// ни к одному из видов.
+func Kind(err error) error {
+	for _, kind := range []error{ErrNotFound, ErrConflict, ErrForbidden, ErrValidation, ErrUnauthorized, ErrTooManyRequests, ErrUnavailable} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return ErrInternal
+}
+
+// Code возвращает машиночитаемый код ошибки или "internal" для ошибок без кода.
+func Code(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Kind != ErrInternal {
+		return appErr.Code
+	}
+	return "internal"
+}
@@ -0,0 +1,63 @@
+package apperr_test
+
+import (
+	"errors"
+	"fmt"
+	"pvz/internal/apperr"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindAndCode(t *testing.T) {
+	notFound := apperr.NotFound("pvz_not_found", "pvz not found")
+
+	tests := []struct {
+		name         string
+		err          error
+		expectedKind error
+		expectedCode string
+	}{
+		{
+			name:         "sentinel",
+			err:          notFound,
+			expectedKind: apperr.ErrNotFound,
+			expectedCode: "pvz_not_found",
+		},
+		{
+			name:         "wrapped sentinel",
+			err:          fmt.Errorf("failed to get pvz: %w", notFound),
+			expectedKind: apperr.ErrNotFound,
+			expectedCode: "pvz_not_found",
+		},
+		{
+			name:         "plain error",
+			err:          errors.New("pq: connection refused"),
+			expectedKind: apperr.ErrInternal,
+			expectedCode: "internal",
+		},
+		{
+			name:         "internal",
+			err:          apperr.Internal(errors.New("pq: connection refused")),
+			expectedKind: apperr.ErrInternal,
+			expectedCode: "internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedKind, apperr.Kind(tt.err))
+			assert.Equal(t, tt.expectedCode, apperr.Code(tt.err))
+		})
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", apperr.Conflict("pvz_exists", "pvz exists"))
+
+	assert.True(t, errors.Is(err, apperr.ErrConflict))
+	assert.False(t, errors.Is(err, apperr.ErrNotFound))
+
+	cause := errors.New("pq: connection refused")
+	assert.True(t, errors.Is(apperr.Internal(cause), cause))
+}
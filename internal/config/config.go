@@ -0,0 +1,489 @@
+// Package config собирает настройки сервиса. Значения применяются по
+// возрастанию приоритета: значения по умолчанию, YAML файл, переменные
+// окружения, флаги командной строки.
+package config
+
+import (
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+type Config struct {
+	HTTP       HTTPConfig       `yaml:"http"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Auth       AuthConfig       `yaml:"auth"`
+	CORS       CORSConfig       `yaml:"cors"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	Log        LogConfig        `yaml:"log"`
+	Notifier   NotifierConfig   `yaml:"notifier"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Pagination PaginationConfig `yaml:"pagination"`
+	// Password задаёт требования к новым паролям.
+	Password PasswordPolicyConfig `yaml:"password_policy"`
+}
+
+type HTTPConfig struct {
+	Addr         string        `yaml:"addr" env:"HTTP_ADDR"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	// RequestTimeout ограничивает обработку запроса вместе со всеми
+	// обращениями к базе.
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"REQUEST_TIMEOUT"`
+	// ShutdownDelay — сколько /readyz отвечает ошибкой до закрытия
+	// слушающего сокета, чтобы балансировщик успел убрать экземпляр.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"HTTP_SHUTDOWN_DELAY"`
+	// ShutdownTimeout ограничивает ожидание завершения начатых запросов.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+	// TrustedProxies — адреса и подсети прокси, чьему X-Forwarded-For
+	// можно верить. Пустой список: адрес клиента берётся из соединения.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES"`
+}
+
+type DatabaseConfig struct {
+	DSN             string        `yaml:"dsn" env:"DATABASE_URL"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DATABASE_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DATABASE_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DATABASE_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DATABASE_CONN_MAX_IDLE_TIME"`
+	// AutoMigrate применяет миграции при старте сервиса.
+	AutoMigrate bool `yaml:"auto_migrate" env:"DATABASE_AUTO_MIGRATE"`
+}
+
+// AuthConfig: без KeyringPath токены подписываются HMAC-ключом JWTSecret.
+type AuthConfig struct {
+	JWTSecret   string `yaml:"jwt_secret" env:"JWT_SECRET"`
+	KeyringPath string `yaml:"keyring_path" env:"JWT_KEYRING"`
+	PolicyPath  string `yaml:"policy_path" env:"AUTH_POLICY"`
+	// TwoFactorRoles дополняет политику ролями, которым нужен второй фактор.
+	TwoFactorRoles        []string      `yaml:"two_factor_roles" env:"AUTH_TWO_FACTOR_ROLES"`
+	BreachedPasswordsPath string        `yaml:"breached_passwords_path" env:"PASSWORD_BREACHED_LIST"`
+	AccessTokenTTL        time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL       time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+	ChallengeTokenTTL     time.Duration `yaml:"challenge_token_ttl" env:"CHALLENGE_TOKEN_TTL"`
+}
+
+// CORSConfig: пустой AllowedOrigins отключает CORS заголовки.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+type RateLimitConfig struct {
+	Login LoginRateLimitConfig `yaml:"login"`
+}
+
+type LoginRateLimitConfig struct {
+	MaxAccountFailures int           `yaml:"max_account_failures" env:"LOGIN_MAX_ACCOUNT_FAILURES"`
+	MaxIPFailures      int           `yaml:"max_ip_failures" env:"LOGIN_MAX_IP_FAILURES"`
+	Window             time.Duration `yaml:"window" env:"LOGIN_FAILURE_WINDOW"`
+	BaseLockout        time.Duration `yaml:"base_lockout" env:"LOGIN_BASE_LOCKOUT"`
+	MaxLockout         time.Duration `yaml:"max_lockout" env:"LOGIN_MAX_LOCKOUT"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+type NotifierConfig struct {
+	OutboxPath string `yaml:"outbox_path" env:"NOTIFIER_OUTBOX"`
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED"`
+	Path    string `yaml:"path" env:"METRICS_PATH"`
+}
+
+// TracingConfig: Exporter "none" отключает трассировку, "stdout" печатает
+// спаны в stdout, "otlp" отправляет их коллектору по OTLP/HTTP на Endpoint.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_OTLP_INSECURE"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// PaginationConfig: CursorSecret подписывает курсоры списков. Без него ключ
+// выводится из auth.jwt_secret.
+type PaginationConfig struct {
+	CursorSecret string `yaml:"cursor_secret" env:"PAGINATION_CURSOR_SECRET"`
+}
+
+// PasswordPolicyConfig: MinLength считается в символах, MaxLength — в байтах,
+// потому что bcrypt ограничивает длину пароля 72 байтами.
+type PasswordPolicyConfig struct {
+	MinLength     int  `yaml:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	MaxLength     int  `yaml:"max_length" env:"PASSWORD_MAX_LENGTH"`
+	RequireLower  bool `yaml:"require_lower" env:"PASSWORD_REQUIRE_LOWER"`
+	RequireUpper  bool `yaml:"require_upper" env:"PASSWORD_REQUIRE_UPPER"`
+	RequireDigit  bool `yaml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol bool `yaml:"require_symbol" env:"PASSWORD_REQUIRE_SYMBOL"`
+}
+
+// Options — флаги, которые управляют запуском, а не настройками сервиса.
+type Options struct {
+	Path        string
+	PrintConfig bool
+	// Args — аргументы после флагов, например команда migrate.
+	Args []string
+}
+
+// Default повторяет значения по умолчанию из usecase: config не зависит
+// от usecase, а типы usecase собираются из настроек в cmd.
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			Addr:            ":8080",
+			ReadTimeout:     5 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			RequestTimeout:  3 * time.Second,
+			ShutdownDelay:   5 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:    15 * time.Minute,
+			RefreshTokenTTL:   30 * 24 * time.Hour,
+			ChallengeTokenTTL: 5 * time.Minute,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-API-Key", "X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Login: LoginRateLimitConfig{
+				MaxAccountFailures: 5,
+				MaxIPFailures:      20,
+				Window:             15 * time.Minute,
+				BaseLockout:        30 * time.Second,
+				MaxLockout:         15 * time.Minute,
+			},
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "pvz",
+			SampleRatio: 1,
+		},
+		Password: PasswordPolicyConfig{
+			MinLength:    8,
+			MaxLength:    72,
+			RequireLower: true,
+			RequireUpper: true,
+			RequireDigit: true,
+		},
+	}
+}
+
+// Load разбирает флаги из args и собирает конфигурацию. Путь к файлу
+// берётся из флага -config или переменной PVZ_CONFIG. Проверку значений
+// выполняет Validate, чтобы -print-config работал и для ошибочной конфигурации.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, Options, error) {
+	var opts Options
+	defaultPath, _ := lookupEnv("PVZ_CONFIG")
+
+	fs := flag.NewFlagSet("pvz", flag.ContinueOnError)
+	fs.StringVar(&opts.Path, "config", defaultPath, "path to YAML config file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print effective config with secrets redacted and exit")
+	addr := fs.String("http-addr", "", "HTTP listen address")
+	dsn := fs.String("database-dsn", "", "Postgres connection string")
+	logLevel := fs.String("log-level", "", "log level: debug, info, warn, error")
+	logFormat := fs.String("log-format", "", "log format: text, json")
+	autoMigrate := fs.Bool("auto-migrate", false, "apply pending migrations on start")
+	if err := fs.Parse(args); err != nil {
+		return nil, opts, err
+	}
+	opts.Args = fs.Args()
+
+	cfg := Default()
+	if opts.Path != "" {
+		if err := cfg.readFile(opts.Path); err != nil {
+			return nil, opts, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), lookupEnv); err != nil {
+		return nil, opts, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "http-addr":
+			cfg.HTTP.Addr = *addr
+		case "database-dsn":
+			cfg.Database.DSN = *dsn
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		case "auto-migrate":
+			cfg.Database.AutoMigrate = *autoMigrate
+		}
+	})
+
+	return cfg, opts, nil
+}
+
+func (c *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv заполняет поля с тегом env из переменных окружения.
+// Списки задаются через запятую.
+func applyEnv(v reflect.Value, lookupEnv func(string) (string, bool)) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := v.Type().Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setValue(field, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setValue(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case []string:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Validate сообщает обо всех ошибках конфигурации сразу.
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, field, message string) {
+		if !ok {
+			problems = append(problems, field+": "+message)
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr", "is required")
+	check(c.HTTP.ReadTimeout > 0, "http.read_timeout", "must be positive")
+	check(c.HTTP.WriteTimeout > 0, "http.write_timeout", "must be positive")
+	check(c.HTTP.IdleTimeout > 0, "http.idle_timeout", "must be positive")
+	check(c.HTTP.RequestTimeout > 0, "http.request_timeout", "must be positive")
+	check(c.HTTP.RequestTimeout < c.HTTP.WriteTimeout, "http.request_timeout", "must be less than http.write_timeout")
+	check(c.HTTP.ShutdownDelay >= 0, "http.shutdown_delay", "must not be negative")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout", "must be positive")
+	for _, proxy := range c.HTTP.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "http.trusted_proxies", "must contain IP addresses or CIDR ranges")
+	}
+
+	check(c.Database.DSN != "", "database.dsn", "is required")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns", "must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns", "must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns", "must not exceed database.max_open_conns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime", "must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time", "must not be negative")
+
+	check(c.Auth.JWTSecret != "" || c.Auth.KeyringPath != "", "auth.jwt_secret", "is required when auth.keyring_path is not set")
+	check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= 32, "auth.jwt_secret", "must be at least 32 bytes")
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl", "must be positive")
+	check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "auth.refresh_token_ttl", "must be greater than auth.access_token_ttl")
+	check(c.Auth.ChallengeTokenTTL > 0, "auth.challenge_token_ttl", "must be positive")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(origin != "*" || !c.CORS.AllowCredentials, "cors.allowed_origins", "must not contain * when cors.allow_credentials is set")
+	}
+	check(len(c.CORS.AllowedOrigins) == 0 || len(c.CORS.AllowedMethods) > 0, "cors.allowed_methods", "is required when cors.allowed_origins is set")
+	check(c.CORS.MaxAge >= 0, "cors.max_age", "must not be negative")
+
+	login := c.RateLimit.Login
+	check(login.MaxAccountFailures > 0, "rate_limit.login.max_account_failures", "must be positive")
+	check(login.MaxIPFailures > 0, "rate_limit.login.max_ip_failures", "must be positive")
+	check(login.Window > 0, "rate_limit.login.window", "must be positive")
+	check(login.BaseLockout > 0, "rate_limit.login.base_lockout", "must be positive")
+	check(login.MaxLockout >= login.BaseLockout, "rate_limit.login.max_lockout", "must not be less than rate_limit.login.base_lockout")
+
+	password := c.Password
+	check(password.MinLength > 0, "password_policy.min_length", "must be positive")
+	check(password.MaxLength > 0 && password.MaxLength <= 72, "password_policy.max_length", "must be between 1 and 72")
+	check(password.MaxLength >= password.MinLength, "password_policy.max_length", "must not be less than password_policy.min_length")
+
+	check(c.Pagination.CursorSecret != "" || c.Auth.JWTSecret != "", "pagination.cursor_secret", "is required when auth.jwt_secret is not set")
+	check(c.Pagination.CursorSecret == "" || len(c.Pagination.CursorSecret) >= 32, "pagination.cursor_secret", "must be at least 32 bytes")
+
+	check(!c.Metrics.Enabled || strings.HasPrefix(c.Metrics.Path, "/"), "metrics.path", "must start with /")
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		check(c.Tracing.Endpoint != "", "tracing.endpoint", "is required when tracing.exporter is otlp")
+	default:
+		check(false, "tracing.exporter", "must be one of none, stdout, otlp")
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log.level", "must be one of debug, info, warn, error")
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		check(false, "log.format", "must be one of text, json")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Redacted возвращает копию конфигурации, которую можно выводить в лог.
+func (c *Config) Redacted() *Config {
+	out := *c
+	out.Database.DSN = redactDSN(c.Database.DSN)
+	if out.Auth.JWTSecret != "" {
+		out.Auth.JWTSecret = redacted
+	}
+	if out.Pagination.CursorSecret != "" {
+		out.Pagination.CursorSecret = redacted
+	}
+	return &out
+}
+
+// Print выводит конфигурацию в YAML с убранными секретами.
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// CursorKey возвращает ключ подписи курсоров. Ключ, выведенный из
+// auth.jwt_secret, не совпадает с ключом подписи токенов.
+func (c *Config) CursorKey() []byte {
+	if c.Pagination.CursorSecret != "" {
+		return []byte(c.Pagination.CursorSecret)
+	}
+	key := sha256.Sum256([]byte("pvz cursor\x00" + c.Auth.JWTSecret))
+	return key[:]
+}
+
+// redactDSN скрывает пароль и в URL, и в формате key=value.
+func redactDSN(dsn string) string {
+	if dsn == "" {
+		return ""
+	}
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", redacted)
+			u.RawQuery = query.Encode()
+		}
+		return u.String()
+	}
+
+	parts := strings.Fields(dsn)
+	for i, part := range parts {
+		if strings.HasPrefix(part, "password=") {
+			parts[i] = "password=" + redacted
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,91 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/usecase"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+)
+
+type APIKeysHandler struct {
+	apiKeyUsecase usecase.APIKeyUsecase
+}
+
+func NewAPIKeysHandler(apiKeyUsecase usecase.APIKeyUsecase) *APIKeysHandler {
+	return &APIKeysHandler{apiKeyUsecase: apiKeyUsecase}
+}
+
+// CreateKey выпускает ключ. Без userId ключ действует от имени создателя.
+func (h *APIKeysHandler) CreateKey(c *gin.Context) {
+	var input struct {
+		Name        string               `json:"name"`
+		UserID      *uuid.UUID           `json:"userId"`
+		Permissions []usecase.Permission `json:"permissions"`
+		PVZIDs      []uuid.UUID          `json:"pvzIds"`
+		ExpiresAt   *time.Time           `json:"expiresAt"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	user_id := actor.UserID
+	if input.UserID != nil {
+		user_id = *input.UserID
+	}
+
+	key, err := h.apiKeyUsecase.CreateKey(c.Request.Context(), actor, usecase.APIKeyInput{
+		Name:        input.Name,
+		UserID:      user_id,
+		Permissions: input.Permissions,
+		PVZIDs:      input.PVZIDs,
+		ExpiresAt:   input.ExpiresAt,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *APIKeysHandler) ListKeys(c *gin.Context) {
+	keys, err := h.apiKeyUsecase.ListKeys(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeysHandler) RevokeKey(c *gin.Context) {
+	key_id, err := uuid.FromString(c.Param("keyId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.apiKeyUsecase.RevokeKey(c.Request.Context(), actor, key_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,69 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+)
+
+type AssignmentHandler struct {
+	assignmentUsecase usecase.AssignmentUsecase
+}
+
+func NewAssignmentHandler(assignmentUsecase usecase.AssignmentUsecase) *AssignmentHandler {
+	return &AssignmentHandler{assignmentUsecase: assignmentUsecase}
+}
+
+func (h *AssignmentHandler) AssignEmployee(c *gin.Context) {
+	pvz_id, err := uuid.FromString(c.Param("pvzId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	var input struct {
+		UserID uuid.UUID `json:"userId"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if input.UserID.IsNil() {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	err = h.assignmentUsecase.AssignEmployee(c.Request.Context(), pvz_id, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"pvzId": pvz_id, "userId": input.UserID})
+}
+
+func (h *AssignmentHandler) UnassignEmployee(c *gin.Context) {
+	pvz_id, err := uuid.FromString(c.Param("pvzId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	user_id, err := uuid.FromString(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	err = h.assignmentUsecase.UnassignEmployee(c.Request.Context(), pvz_id, user_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
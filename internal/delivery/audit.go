@@ -0,0 +1,83 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+)
+
+type AuditHandler struct {
+	auditUsecase usecase.AuditUsecase
+}
+
+func NewAuditHandler(auditUsecase usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{auditUsecase: auditUsecase}
+}
+
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	var filter entity.AuditFilter
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(invalidQuery("page"))
+		return
+	}
+	filter.Page = page
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.Error(invalidQuery("limit"))
+		return
+	}
+	filter.Limit = limit
+
+	if actor := c.Query("actorId"); actor != "" {
+		actor_id, err := uuid.FromString(actor)
+		if err != nil {
+			c.Error(invalidQuery("actorId"))
+			return
+		}
+		filter.ActorID = &actor_id
+	}
+
+	if entityID := c.Query("entityId"); entityID != "" {
+		entity_id, err := uuid.FromString(entityID)
+		if err != nil {
+			c.Error(invalidQuery("entityId"))
+			return
+		}
+		filter.EntityID = &entity_id
+	}
+	filter.EntityType = c.Query("entity")
+
+	if from := c.Query("from"); from != "" {
+		date, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.Error(invalidQuery("from"))
+			return
+		}
+		filter.From = &date
+	}
+
+	if to := c.Query("to"); to != "" {
+		date, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.Error(invalidQuery("to"))
+			return
+		}
+		filter.To = &date
+	}
+
+	events, err := h.auditUsecase.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,105 @@
+package delivery_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAuditUsecase) ListEvents(ctx context.Context, filter entity.AuditFilter) (*usecase.AuditListResponse, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(*usecase.AuditListResponse), args.Error(1)
+}
+
+func TestListAuditEventsHandler(t *testing.T) {
+	actor_id := uuid.Must(uuid.NewV4())
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		role         string
+		queryParams  string
+		mock         func(*MockAuditUsecase)
+		expectedCode int
+	}{
+		{
+			name:        "succesful list",
+			role:        "moderator",
+			queryParams: "actorId=" + actor_id.String() + "&entity=reception&from=2025-05-01T00:00:00Z&to=2025-05-02T00:00:00Z",
+			mock: func(m *MockAuditUsecase) {
+				m.On("ListEvents", context.Background(), entity.AuditFilter{ActorID: &actor_id, EntityType: "reception", From: &from, To: &to, Page: 1, Limit: 50}).
+					Return(&usecase.AuditListResponse{Events: []entity.AuditEvent{}, Page: 1, Limit: 50}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "employee cannot read audit",
+			role:         "employee",
+			mock:         func(m *MockAuditUsecase) {},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "invalid actor",
+			role:         "moderator",
+			queryParams:  "actorId=123",
+			mock:         func(m *MockAuditUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid from",
+			role:         "moderator",
+			queryParams:  "from=yesterday",
+			mock:         func(m *MockAuditUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "inverted range",
+			role:        "moderator",
+			queryParams: "from=2025-05-02T00:00:00Z&to=2025-05-01T00:00:00Z",
+			mock: func(m *MockAuditUsecase) {
+				m.On("ListEvents", context.Background(), entity.AuditFilter{From: &to, To: &from, Page: 1, Limit: 50}).
+					Return((*usecase.AuditListResponse)(nil), usecase.ErrInvalidAuditRange)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockAuditUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewAuditHandler(mockUsecase)
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.GET("/audit", func(ctx *gin.Context) {
+				ctx.Set("role", tt.role)
+				ctx.Set("mfa", true)
+			}, middlewares.RequirePermission(usecase.DefaultPolicy(), usecase.PermAuditRead), handler.ListEvents)
+
+			req, _ := http.NewRequest(http.MethodGet, "/audit?"+tt.queryParams, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
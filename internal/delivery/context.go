@@ -0,0 +1,43 @@
+package delivery
+
+import (
+	"pvz/internal/storage/migrations/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+)
+
+func userIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	user_id, err := uuid.FromString(c.GetString("userID"))
+	if err != nil {
+		return uuid.UUID{}, errInvalidUserID
+	}
+	return user_id, nil
+}
+
+// requestActor описывает текущий запрос для журнала аудита. Для анонимных
+// запросов UserID остаётся пустым. RequestID берётся из RequestLogger,
+// чтобы аудит и журнал запросов ссылались на один идентификатор. Для
+// запросов по API ключу в аудит попадают ключ и тот, кто его выпустил.
+func requestActor(c *gin.Context) entity.Actor {
+	requestID := c.GetString("requestID")
+	if requestID == "" {
+		requestID = c.GetHeader("X-Request-ID")
+	}
+	actor := entity.Actor{RequestID: requestID, IP: c.ClientIP()}
+	actor.UserID, _ = userIDFromContext(c)
+	actor.APIKeyID, _ = uuid.FromString(c.GetString("apiKeyID"))
+	actor.APIKeyCreatedBy, _ = uuid.FromString(c.GetString("apiKeyCreatedBy"))
+	return actor
+}
+
+func actorFromContext(c *gin.Context) (entity.Actor, error) {
+	user_id, err := userIDFromContext(c)
+	if err != nil {
+		return entity.Actor{}, err
+	}
+	actor := requestActor(c)
+	actor.UserID = user_id
+	actor.Role = c.GetString("role")
+	return actor, nil
+}
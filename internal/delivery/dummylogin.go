@@ -22,28 +22,28 @@ func (h *DummyLoginHandler) DummyLogin(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
 	switch input.Role {
 	case "moderator":
-		token, err := h.dummyLoginUsecase.Login("dummy_moderator@test.com", "supersecretpassword")
+		tokens, err := h.dummyLoginUsecase.Login(c.Request.Context(), "dummy_moderator@test.com", "supersecretpassword")
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"token": token})
+		c.JSON(http.StatusOK, tokens)
 
 	case "employee":
-		token, err := h.dummyLoginUsecase.Login("dummy_employee@test.com", "supersecretpassword")
+		tokens, err := h.dummyLoginUsecase.Login(c.Request.Context(), "dummy_employee@test.com", "supersecretpassword")
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"token": token})
+		c.JSON(http.StatusOK, tokens)
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
@@ -0,0 +1,16 @@
+package delivery
+
+import (
+	"pvz/internal/apperr"
+)
+
+// Ошибки разбора запроса. Ответ по ним формирует middlewares.ErrorHandler.
+var (
+	errInvalidRequest = apperr.Validation("invalid_request", "invalid request")
+	errInvalidPath    = apperr.Validation("invalid_path", "wrong query")
+	errInvalidUserID  = apperr.Validation("invalid_user_id", "invalid user id")
+)
+
+func invalidQuery(param string) error {
+	return apperr.Validation("invalid_query", "invalid "+param)
+}
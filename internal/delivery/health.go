@@ -0,0 +1,30 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthUsecase usecase.HealthUsecase
+}
+
+func NewHealthHandler(healthUsecase usecase.HealthUsecase) *HealthHandler {
+	return &HealthHandler{healthUsecase: healthUsecase}
+}
+
+// Liveness отвечает, пока процесс жив, и не обращается к базе, чтобы
+// недоступность базы не приводила к перезапуску сервиса.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	if err := h.healthUsecase.Ready(c.Request.Context()); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
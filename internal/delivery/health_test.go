@@ -0,0 +1,91 @@
+package delivery_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockHealthUsecase struct {
+	mock.Mock
+}
+
+func (m *MockHealthUsecase) Ready(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockHealthUsecase) Drain() {
+	m.Called()
+}
+
+func TestHealthHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		path         string
+		mock         func(*MockHealthUsecase)
+		expectedCode int
+	}{
+		{
+			name:         "alive",
+			path:         "/healthz",
+			mock:         func(m *MockHealthUsecase) {},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "ready",
+			path: "/readyz",
+			mock: func(m *MockHealthUsecase) {
+				m.On("Ready", context.Background()).Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "database unavailable",
+			path: "/readyz",
+			mock: func(m *MockHealthUsecase) {
+				m.On("Ready", context.Background()).Return(usecase.ErrDatabaseUnavailable)
+			},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			name: "shutting down",
+			path: "/readyz",
+			mock: func(m *MockHealthUsecase) {
+				m.On("Ready", context.Background()).Return(usecase.ErrShuttingDown)
+			},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockHealthUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewHealthHandler(mockUsecase)
+
+			router := gin.New()
+			router.Use(middlewares.ErrorHandler())
+			router.GET("/healthz", handler.Liveness)
+			router.GET("/readyz", handler.Readiness)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	jwksProvider usecase.JWKSProvider
+}
+
+func NewJWKSHandler(jwksProvider usecase.JWKSProvider) *JWKSHandler {
+	return &JWKSHandler{jwksProvider: jwksProvider}
+}
+
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwksProvider.JWKS())
+}
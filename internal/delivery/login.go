@@ -1,18 +1,22 @@
 package delivery
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"pvz/internal/usecase"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type LoginHandler struct {
-	loginUsecase usecase.UserUsecase
+	loginUsecase    usecase.UserUsecase
+	attemptsUsecase usecase.LoginAttemptsUsecase
 }
 
-func NewLoginHandler(loginUsecase usecase.UserUsecase) *LoginHandler {
-	return &LoginHandler{loginUsecase: loginUsecase}
+func NewLoginHandler(loginUsecase usecase.UserUsecase, attemptsUsecase usecase.LoginAttemptsUsecase) *LoginHandler {
+	return &LoginHandler{loginUsecase: loginUsecase, attemptsUsecase: attemptsUsecase}
 }
 
 func (h *LoginHandler) Login(c *gin.Context) {
@@ -22,20 +26,67 @@ func (h *LoginHandler) Login(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
 	if input.Email == "" || input.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
-	token, err := h.loginUsecase.Login(input.Email, input.Password)
+	ip := c.ClientIP()
+	retryAfter, err := h.attemptsUsecase.CheckLogin(c.Request.Context(), input.Email, ip)
+	if errors.Is(err, usecase.ErrTooManyAttempts) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokens, err := h.loginUsecase.Login(c.Request.Context(), input.Email, input.Password)
+	if errors.Is(err, usecase.ErrInvalidCredentials) {
+		if err := h.attemptsUsecase.RecordFailure(c.Request.Context(), input.Email, ip); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.attemptsUsecase.RecordSuccess(c.Request.Context(), input.Email, ip); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// VerifyTwoFactor завершает вход по challenge токену и коду второго фактора.
+func (h *LoginHandler) VerifyTwoFactor(c *gin.Context) {
+	var input struct {
+		ChallengeToken string `json:"challengeToken"`
+		Code           string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if input.ChallengeToken == "" || input.Code == "" {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	tokens, err := h.loginUsecase.VerifyLogin(c.Request.Context(), input.ChallengeToken, input.Code)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, tokens)
 }
@@ -2,14 +2,19 @@ package delivery_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -18,16 +23,55 @@ type MockUserUsecase struct {
 	mock.Mock
 }
 
-func (m *MockUserUsecase) Login(email, password string) (string, error) {
-	args := m.Called(email, password)
-	return args.String(0), args.Error(1)
+func (m *MockUserUsecase) Login(ctx context.Context, email, password string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, email, password)
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockUserUsecase) VerifyLogin(ctx context.Context, challengeToken, code string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, challengeToken, code)
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
 }
 
-func (m *MockUserUsecase) Register(email, password, role string) (string, error) {
-	args := m.Called(email, password, role)
+func (m *MockUserUsecase) Register(ctx context.Context, actor entity.Actor, email, password, role string) (string, error) {
+	args := m.Called(ctx, actor, email, password, role)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockUserUsecase) Refresh(ctx context.Context, refreshToken string) (*usecase.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.Get(0).(*usecase.TokenPair), args.Error(1)
+}
+
+func (m *MockUserUsecase) Logout(ctx context.Context, userID, jti uuid.UUID, expiresAt time.Time, refreshToken string) error {
+	args := m.Called(ctx, userID, jti, expiresAt, refreshToken)
+	return args.Error(0)
+}
+
+type MockLoginAttemptsUsecase struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptsUsecase) CheckLogin(ctx context.Context, email, ip string) (time.Duration, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginAttemptsUsecase) RecordFailure(ctx context.Context, email, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptsUsecase) RecordSuccess(ctx context.Context, email, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptsUsecase) ListAttempts(ctx context.Context, filter entity.LoginAttemptFilter) ([]entity.LoginAttempt, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entity.LoginAttempt), args.Error(1)
+}
+
 func TestDummyLoginHandler_DummyLogin(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	tests := []struct {
@@ -42,7 +86,7 @@ func TestDummyLoginHandler_DummyLogin(t *testing.T) {
 				"role": "moderator",
 			},
 			mock: func(muu *MockUserUsecase) {
-				muu.On("Login", "dummy_moderator@test.com", "supersecretpassword").Return("moderator_token", nil)
+				muu.On("Login", context.Background(), "dummy_moderator@test.com", "supersecretpassword").Return(&usecase.TokenPair{AccessToken: "moderator_token", RefreshToken: "refresh_token"}, nil)
 			},
 			expectedCode: http.StatusOK,
 		},
@@ -52,7 +96,7 @@ func TestDummyLoginHandler_DummyLogin(t *testing.T) {
 				"role": "employee",
 			},
 			mock: func(muu *MockUserUsecase) {
-				muu.On("Login", "dummy_employee@test.com", "supersecretpassword").Return("employee_token", nil)
+				muu.On("Login", context.Background(), "dummy_employee@test.com", "supersecretpassword").Return(&usecase.TokenPair{AccessToken: "employee_token", RefreshToken: "refresh_token"}, nil)
 			},
 			expectedCode: http.StatusOK,
 		},
@@ -76,8 +120,8 @@ func TestDummyLoginHandler_DummyLogin(t *testing.T) {
 				"role": "moderator",
 			},
 			mock: func(m *MockUserUsecase) {
-				m.On("Login", "dummy_moderator@test.com", "supersecretpassword").
-					Return("", errors.New("login failed"))
+				m.On("Login", context.Background(), "dummy_moderator@test.com", "supersecretpassword").
+					Return((*usecase.TokenPair)(nil), usecase.ErrInvalidCredentials)
 			},
 			expectedCode: http.StatusUnauthorized,
 		},
@@ -87,8 +131,8 @@ func TestDummyLoginHandler_DummyLogin(t *testing.T) {
 				"role": "employee",
 			},
 			mock: func(m *MockUserUsecase) {
-				m.On("Login", "dummy_employee@test.com", "supersecretpassword").
-					Return("", errors.New("login failed"))
+				m.On("Login", context.Background(), "dummy_employee@test.com", "supersecretpassword").
+					Return((*usecase.TokenPair)(nil), usecase.ErrInvalidCredentials)
 			},
 			expectedCode: http.StatusUnauthorized,
 		},
@@ -114,6 +158,7 @@ func TestDummyLoginHandler_DummyLogin(t *testing.T) {
 			handler := delivery.NewDummyLoginHandler(mockUsecase)
 
 			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
 			router.POST("/dummyLogin", handler.DummyLogin)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -129,6 +174,7 @@ func TestDummyLoginHandler_DummyLogin(t *testing.T) {
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
 				assert.Contains(t, response, "token")
+				assert.Contains(t, response, "refreshToken")
 			}
 
 			mockUsecase.AssertExpectations(t)
@@ -139,10 +185,11 @@ func TestDummyLoginHandler_DummyLogin(t *testing.T) {
 func TestRegister(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	tests := []struct {
-		name         string
-		requestBody  any
-		mock         func(*MockUserUsecase)
-		expectedCode int
+		name           string
+		requestBody    any
+		mock           func(*MockUserUsecase)
+		expectedCode   int
+		expectedFields []usecase.FieldError
 	}{
 		{
 			name: "successful registration",
@@ -152,7 +199,7 @@ func TestRegister(t *testing.T) {
 				"role":     "moderator",
 			},
 			mock: func(muu *MockUserUsecase) {
-				muu.On("Register", "kanzartem11@mail.ru", "q1w2e3", "moderator").Return("user_id", nil)
+				muu.On("Register", context.Background(), mock.AnythingOfType("entity.Actor"), "kanzartem11@mail.ru", "q1w2e3", "moderator").Return("user_id", nil)
 			},
 			expectedCode: http.StatusCreated,
 		},
@@ -164,15 +211,26 @@ func TestRegister(t *testing.T) {
 				"role":     "moderator",
 			},
 			mock: func(muu *MockUserUsecase) {
-				muu.On("Register", "kanzartem11@mail.ru", "q1w2e3", "moderator").Return("", errors.New("user already exists"))
+				muu.On("Register", context.Background(), mock.AnythingOfType("entity.Actor"), "kanzartem11@mail.ru", "q1w2e3", "moderator").Return("", usecase.ErrUserExists)
 			},
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusConflict,
 		},
 		{
-			name:         "missing body",
-			requestBody:  nil,
-			mock:         func(muu *MockUserUsecase) {},
+			name:        "missing body",
+			requestBody: nil,
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Register", context.Background(), mock.AnythingOfType("entity.Actor"), "", "", "").Return("", &usecase.ValidationError{Fields: []usecase.FieldError{
+					{Field: "email", Message: "is required"},
+					{Field: "password", Message: "is required"},
+					{Field: "role", Message: "is required"},
+				}})
+			},
 			expectedCode: http.StatusBadRequest,
+			expectedFields: []usecase.FieldError{
+				{Field: "email", Message: "is required"},
+				{Field: "password", Message: "is required"},
+				{Field: "role", Message: "is required"},
+			},
 		},
 		{
 			name: "empty email",
@@ -181,8 +239,13 @@ func TestRegister(t *testing.T) {
 				"password": "q1w2e3",
 				"role":     "moderator",
 			},
-			mock:         func(muu *MockUserUsecase) {},
-			expectedCode: http.StatusBadRequest,
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Register", context.Background(), mock.AnythingOfType("entity.Actor"), "", "q1w2e3", "moderator").Return("", &usecase.ValidationError{
+					Fields: []usecase.FieldError{{Field: "email", Message: "is required"}},
+				})
+			},
+			expectedCode:   http.StatusBadRequest,
+			expectedFields: []usecase.FieldError{{Field: "email", Message: "is required"}},
 		},
 		{
 			name: "empty password",
@@ -191,8 +254,13 @@ func TestRegister(t *testing.T) {
 				"password": "",
 				"role":     "moderator",
 			},
-			mock:         func(muu *MockUserUsecase) {},
-			expectedCode: http.StatusBadRequest,
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Register", context.Background(), mock.AnythingOfType("entity.Actor"), "kanzartem11@mail.ru", "", "moderator").Return("", &usecase.ValidationError{
+					Fields: []usecase.FieldError{{Field: "password", Message: "is required"}},
+				})
+			},
+			expectedCode:   http.StatusBadRequest,
+			expectedFields: []usecase.FieldError{{Field: "password", Message: "is required"}},
 		},
 		{
 			name: "empty role",
@@ -201,8 +269,13 @@ func TestRegister(t *testing.T) {
 				"password": "q1w2e3",
 				"role":     "",
 			},
-			mock:         func(muu *MockUserUsecase) {},
-			expectedCode: http.StatusBadRequest,
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Register", context.Background(), mock.AnythingOfType("entity.Actor"), "kanzartem11@mail.ru", "q1w2e3", "").Return("", &usecase.ValidationError{
+					Fields: []usecase.FieldError{{Field: "role", Message: "is required"}},
+				})
+			},
+			expectedCode:   http.StatusBadRequest,
+			expectedFields: []usecase.FieldError{{Field: "role", Message: "is required"}},
 		},
 		{
 			name: "invalid role",
@@ -212,7 +285,9 @@ func TestRegister(t *testing.T) {
 				"role":     "user",
 			},
 			mock: func(muu *MockUserUsecase) {
-				muu.On("Register", "kanzartem11@mail.ru", "q1w2e3", "user").Return("", errors.New("invalid role"))
+				muu.On("Register", context.Background(), mock.AnythingOfType("entity.Actor"), "kanzartem11@mail.ru", "q1w2e3", "user").Return("", &usecase.ValidationError{
+					Fields: []usecase.FieldError{{Field: "role", Message: "is not allowed"}},
+				})
 			},
 			expectedCode: http.StatusBadRequest,
 		},
@@ -232,6 +307,7 @@ func TestRegister(t *testing.T) {
 			handler := delivery.NewRegisterHandler(mockUsecase)
 
 			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
 			router.POST("/register", handler.Register)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -249,6 +325,15 @@ func TestRegister(t *testing.T) {
 				assert.Contains(t, response, "id")
 			}
 
+			if tt.expectedFields != nil {
+				var response struct {
+					Fields []usecase.FieldError `json:"errors"`
+				}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedFields, response.Fields)
+			}
+
 			mockUsecase.AssertExpectations(t)
 		})
 	}
@@ -260,7 +345,9 @@ func TestLogin(t *testing.T) {
 		name         string
 		requestBody  any
 		mock         func(*MockUserUsecase)
+		attempts     func(*MockLoginAttemptsUsecase)
 		expectedCode int
+		retryAfter   string
 	}{
 		{
 			name: "successful  login",
@@ -269,7 +356,11 @@ func TestLogin(t *testing.T) {
 				"password": "q1w2e3",
 			},
 			mock: func(muu *MockUserUsecase) {
-				muu.On("Login", "kanzartem11@mail.ru", "q1w2e3").Return("token", nil)
+				muu.On("Login", context.Background(), "kanzartem11@mail.ru", "q1w2e3").Return(&usecase.TokenPair{AccessToken: "token", RefreshToken: "refresh_token"}, nil)
+			},
+			attempts: func(mla *MockLoginAttemptsUsecase) {
+				mla.On("CheckLogin", context.Background(), "kanzartem11@mail.ru", mock.Anything).Return(time.Duration(0), nil)
+				mla.On("RecordSuccess", context.Background(), "kanzartem11@mail.ru", mock.Anything).Return(nil)
 			},
 			expectedCode: http.StatusOK,
 		},
@@ -280,7 +371,11 @@ func TestLogin(t *testing.T) {
 				"password": "q1w2e3",
 			},
 			mock: func(muu *MockUserUsecase) {
-				muu.On("Login", "kanzartem1@mail.ru", "q1w2e3").Return("", errors.New("incorrect login"))
+				muu.On("Login", context.Background(), "kanzartem1@mail.ru", "q1w2e3").Return((*usecase.TokenPair)(nil), usecase.ErrInvalidCredentials)
+			},
+			attempts: func(mla *MockLoginAttemptsUsecase) {
+				mla.On("CheckLogin", context.Background(), "kanzartem1@mail.ru", mock.Anything).Return(time.Duration(0), nil)
+				mla.On("RecordFailure", context.Background(), "kanzartem1@mail.ru", mock.Anything).Return(nil)
 			},
 			expectedCode: http.StatusUnauthorized,
 		},
@@ -291,20 +386,53 @@ func TestLogin(t *testing.T) {
 				"password": "q1w2",
 			},
 			mock: func(muu *MockUserUsecase) {
-				muu.On("Login", "kanzartem11@mail.ru", "q1w2").Return("", errors.New("incorrect password"))
+				muu.On("Login", context.Background(), "kanzartem11@mail.ru", "q1w2").Return((*usecase.TokenPair)(nil), usecase.ErrInvalidCredentials)
+			},
+			attempts: func(mla *MockLoginAttemptsUsecase) {
+				mla.On("CheckLogin", context.Background(), "kanzartem11@mail.ru", mock.Anything).Return(time.Duration(0), nil)
+				mla.On("RecordFailure", context.Background(), "kanzartem11@mail.ru", mock.Anything).Return(nil)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "disabled account",
+			requestBody: map[string]string{
+				"email":    "kanzartem11@mail.ru",
+				"password": "q1w2e3",
+			},
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Login", context.Background(), "kanzartem11@mail.ru", "q1w2e3").Return((*usecase.TokenPair)(nil), usecase.ErrUserDisabled)
+			},
+			attempts: func(mla *MockLoginAttemptsUsecase) {
+				mla.On("CheckLogin", context.Background(), "kanzartem11@mail.ru", mock.Anything).Return(time.Duration(0), nil)
 			},
 			expectedCode: http.StatusUnauthorized,
 		},
+		{
+			name: "account locked",
+			requestBody: map[string]string{
+				"email":    "kanzartem11@mail.ru",
+				"password": "q1w2e3",
+			},
+			mock: func(muu *MockUserUsecase) {},
+			attempts: func(mla *MockLoginAttemptsUsecase) {
+				mla.On("CheckLogin", context.Background(), "kanzartem11@mail.ru", mock.Anything).Return(1500*time.Millisecond, usecase.ErrTooManyAttempts)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			retryAfter:   "2",
+		},
 		{
 			name:         "missing body",
 			requestBody:  nil,
 			mock:         func(muu *MockUserUsecase) {},
+			attempts:     func(mla *MockLoginAttemptsUsecase) {},
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name:         "invalid_json",
 			requestBody:  "json",
 			mock:         func(muu *MockUserUsecase) {},
+			attempts:     func(mla *MockLoginAttemptsUsecase) {},
 			expectedCode: http.StatusBadRequest,
 		},
 	}
@@ -313,10 +441,13 @@ func TestLogin(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockUsecase := &MockUserUsecase{}
 			tt.mock(mockUsecase)
+			mockAttempts := &MockLoginAttemptsUsecase{}
+			tt.attempts(mockAttempts)
 
-			handler := delivery.NewLoginHandler(mockUsecase)
+			handler := delivery.NewLoginHandler(mockUsecase, mockAttempts)
 
 			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
 			router.POST("/login", handler.Login)
 
 			body, _ := json.Marshal(tt.requestBody)
@@ -326,15 +457,18 @@ func TestLogin(t *testing.T) {
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.retryAfter, w.Header().Get("Retry-After"))
 
 			if tt.expectedCode == http.StatusOK {
 				var response map[string]string
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				assert.NoError(t, err)
 				assert.Contains(t, response, "token")
+				assert.Contains(t, response, "refreshToken")
 			}
 
 			mockUsecase.AssertExpectations(t)
+			mockAttempts.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,54 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginAttemptsHandler struct {
+	attemptsUsecase usecase.LoginAttemptsUsecase
+}
+
+func NewLoginAttemptsHandler(attemptsUsecase usecase.LoginAttemptsUsecase) *LoginAttemptsHandler {
+	return &LoginAttemptsHandler{attemptsUsecase: attemptsUsecase}
+}
+
+func (h *LoginAttemptsHandler) ListAttempts(c *gin.Context) {
+	var filter entity.LoginAttemptFilter
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(invalidQuery("page"))
+		return
+	}
+	filter.Page = page
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.Error(invalidQuery("limit"))
+		return
+	}
+	filter.Limit = limit
+
+	if failed := c.Query("failed"); failed != "" {
+		filter.FailedOnly, err = strconv.ParseBool(failed)
+		if err != nil {
+			c.Error(invalidQuery("failed"))
+			return
+		}
+	}
+	filter.Email = c.Query("email")
+	filter.IP = c.Query("ip")
+
+	attempts, err := h.attemptsUsecase.ListAttempts(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
@@ -0,0 +1,60 @@
+package delivery
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"pvz/internal/apperr"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+)
+
+var (
+	errInvalidTokenID      = apperr.Unauthorized("invalid_token", "invalid token ID")
+	errInvalidRefreshToken = apperr.Validation("invalid_refresh_token", "invalid refresh token")
+)
+
+type LogoutHandler struct {
+	logoutUsecase usecase.UserUsecase
+}
+
+func NewLogoutHandler(logoutUsecase usecase.UserUsecase) *LogoutHandler {
+	return &LogoutHandler{logoutUsecase: logoutUsecase}
+}
+
+func (h *LogoutHandler) Logout(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	jti, err := uuid.FromString(c.GetString("jti"))
+	if err != nil {
+		c.Error(errInvalidTokenID)
+		return
+	}
+
+	user_id, err := userIDFromContext(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.logoutUsecase.Logout(c.Request.Context(), user_id, jti, c.GetTime("tokenExp"), input.RefreshToken)
+	if errors.Is(err, usecase.ErrInvalidRefreshToken) {
+		// при выходе это ошибка в теле запроса, а не в аутентификации
+		c.Error(errInvalidRefreshToken)
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
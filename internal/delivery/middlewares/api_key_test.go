@@ -0,0 +1,202 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type APIKeyUsecaseMock struct {
+	mock.Mock
+	usecase.APIKeyUsecase
+}
+
+func (m *APIKeyUsecaseMock) Authenticate(ctx context.Context, rawKey string) (*usecase.APIKeyPrincipal, error) {
+	args := m.Called(ctx, rawKey)
+	return args.Get(0).(*usecase.APIKeyPrincipal), args.Error(1)
+}
+
+func TestJWTAuthMiddleware_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.Must(uuid.NewV4())
+	keyID := uuid.Must(uuid.NewV4())
+	allowedPVZ := uuid.Must(uuid.NewV4())
+	otherPVZ := uuid.Must(uuid.NewV4())
+
+	principal := &usecase.APIKeyPrincipal{
+		KeyID:       keyID,
+		UserID:      userID,
+		Role:        "employee",
+		Permissions: []usecase.Permission{usecase.PermReceptionOpen},
+		PVZIDs:      []uuid.UUID{allowedPVZ},
+	}
+
+	tests := []struct {
+		name         string
+		apiKey       string
+		mock         func(*APIKeyUsecaseMock)
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:   "key with permission and pvz in body",
+			apiKey: "pvz_valid",
+			mock: func(m *APIKeyUsecaseMock) {
+				m.On("Authenticate", context.Background(), "pvz_valid").Return(principal, nil)
+			},
+			method:       http.MethodPost,
+			path:         "/receptions",
+			body:         `{"pvzId": "` + allowedPVZ.String() + `"}`,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "pvz in body outside of scope",
+			apiKey: "pvz_valid",
+			mock: func(m *APIKeyUsecaseMock) {
+				m.On("Authenticate", context.Background(), "pvz_valid").Return(principal, nil)
+			},
+			method:       http.MethodPost,
+			path:         "/receptions",
+			body:         `{"pvzId": "` + otherPVZ.String() + `"}`,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:   "pvz in path outside of scope",
+			apiKey: "pvz_valid",
+			mock: func(m *APIKeyUsecaseMock) {
+				m.On("Authenticate", context.Background(), "pvz_valid").Return(principal, nil)
+			},
+			method:       http.MethodPost,
+			path:         "/pvz/" + otherPVZ.String() + "/close_last_reception",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:   "permission not granted to key",
+			apiKey: "pvz_valid",
+			mock: func(m *APIKeyUsecaseMock) {
+				m.On("Authenticate", context.Background(), "pvz_valid").Return(principal, nil)
+			},
+			method:       http.MethodPost,
+			path:         "/pvz/" + allowedPVZ.String() + "/close_last_reception",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:   "invalid key",
+			apiKey: "pvz_revoked",
+			mock: func(m *APIKeyUsecaseMock) {
+				m.On("Authenticate", context.Background(), "pvz_revoked").Return((*usecase.APIKeyPrincipal)(nil), usecase.ErrInvalidAPIKey)
+			},
+			method:       http.MethodPost,
+			path:         "/receptions",
+			body:         `{}`,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKeysMock := new(APIKeyUsecaseMock)
+			tt.mock(apiKeysMock)
+
+			policy := usecase.DefaultPolicy()
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.Use(JWTAuthMiddleware(new(AuthServiceMock), apiKeysMock))
+			handler := func(c *gin.Context) {
+				assert.Equal(t, userID.String(), c.GetString("userID"))
+				assert.Equal(t, "employee", c.GetString("role"))
+				assert.Equal(t, keyID.String(), c.GetString("apiKeyID"))
+				c.Status(http.StatusOK)
+			}
+			router.POST("/receptions", RequirePermission(policy, usecase.PermReceptionOpen), handler)
+			router.POST("/pvz/:pvzId/close_last_reception", RequirePermission(policy, usecase.PermReceptionClose), handler)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("X-API-Key", tt.apiKey)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			apiKeysMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJWTAuthMiddleware_APIKeyContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	allowedPVZ := uuid.Must(uuid.NewV4())
+	creatorID := uuid.Must(uuid.NewV4())
+	apiKeysMock := new(APIKeyUsecaseMock)
+	apiKeysMock.On("Authenticate", context.Background(), "pvz_scanner").Return(&usecase.APIKeyPrincipal{
+		KeyID:       uuid.Must(uuid.NewV4()),
+		UserID:      uuid.Must(uuid.NewV4()),
+		CreatedBy:   creatorID,
+		Role:        "employee",
+		Permissions: []usecase.Permission{usecase.PermPVZRead},
+		PVZIDs:      []uuid.UUID{allowedPVZ},
+	}, nil)
+
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.Use(JWTAuthMiddleware(new(AuthServiceMock), apiKeysMock))
+	router.GET("/pvz", func(c *gin.Context) {
+		assert.False(t, c.GetBool("mfa"))
+		assert.Equal(t, creatorID.String(), c.GetString("apiKeyCreatedBy"))
+		scoped, _ := c.Get("apiKeyPVZIDs")
+		assert.Equal(t, []uuid.UUID{allowedPVZ}, scoped)
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/pvz", nil)
+	req.Header.Set("X-API-Key", "pvz_scanner")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestDenyAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		apiKeyID     string
+		expectedCode int
+	}{
+		{name: "token", expectedCode: http.StatusOK},
+		{name: "api key", apiKeyID: uuid.Must(uuid.NewV4()).String(), expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.POST("/2fa/enroll", func(c *gin.Context) {
+				c.Set("userID", uuid.Must(uuid.NewV4()).String())
+				if tt.apiKeyID != "" {
+					c.Set("apiKeyID", tt.apiKeyID)
+				}
+			}, DenyAPIKey(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest(http.MethodPost, "/2fa/enroll", nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
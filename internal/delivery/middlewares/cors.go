@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"net/http"
+	"pvz/internal/config"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS разрешает запросы с источников из cfg.AllowedOrigins и отвечает
+// на preflight запросы, не передавая их обработчикам.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	origins := make(map[string]struct{}, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		origins[origin] = struct{}{}
+	}
+	_, anyOrigin := origins["*"]
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || len(origins) == 0 {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Origin")
+		if _, ok := origins[origin]; !ok && !anyOrigin {
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		c.Header("Access-Control-Expose-Headers", RequestIDHeader)
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/config"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		origin          string
+		preflight       bool
+		expectedCode    int
+		expectedOrigin  string
+		expectedMethods string
+	}{
+		{
+			name:           "allowed origin",
+			method:         http.MethodGet,
+			origin:         "https://app.example",
+			expectedCode:   http.StatusOK,
+			expectedOrigin: "https://app.example",
+		},
+		{
+			name:         "unknown origin",
+			method:       http.MethodGet,
+			origin:       "https://evil.example",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:            "preflight",
+			method:          http.MethodOptions,
+			origin:          "https://app.example",
+			preflight:       true,
+			expectedCode:    http.StatusNoContent,
+			expectedOrigin:  "https://app.example",
+			expectedMethods: "GET, POST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(CORS(cfg))
+			router.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest(tt.method, "/test", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.expectedMethods, w.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+}
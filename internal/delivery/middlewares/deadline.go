@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestDeadline ограничивает время обработки запроса. Контекст запроса
+// передаётся до хранилищ, поэтому по истечении timeout или при разрыве
+// соединения клиентом запросы к базе отменяются.
+func RequestDeadline(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var requestCtx context.Context
+	router := gin.New()
+	router.Use(RequestDeadline(50 * time.Millisecond))
+	router.GET("/slow", func(c *gin.Context) {
+		requestCtx = c.Request.Context()
+
+		deadline, ok := requestCtx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 50*time.Millisecond)
+
+		<-requestCtx.Done()
+		c.Status(http.StatusGatewayTimeout)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/slow", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.ErrorIs(t, requestCtx.Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"pvz/internal/apperr"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem — тело ответа об ошибке по RFC 7807. Code дублирует вид ошибки
+// стабильным машиночитаемым значением.
+type Problem struct {
+	Type     string               `json:"type"`
+	Title    string               `json:"title"`
+	Status   int                  `json:"status"`
+	Detail   string               `json:"detail,omitempty"`
+	Instance string               `json:"instance,omitempty"`
+	Code     string               `json:"code"`
+	Errors   []usecase.FieldError `json:"errors,omitempty"`
+}
+
+var kindStatus = map[error]int{
+	apperr.ErrNotFound:        http.StatusNotFound,
+	apperr.ErrConflict:        http.StatusConflict,
+	apperr.ErrForbidden:       http.StatusForbidden,
+	apperr.ErrValidation:      http.StatusBadRequest,
+	apperr.ErrUnauthorized:    http.StatusUnauthorized,
+	apperr.ErrTooManyRequests: http.StatusTooManyRequests,
+	apperr.ErrUnavailable:     http.StatusServiceUnavailable,
+	apperr.ErrInternal:        http.StatusInternalServerError,
+}
+
+// ErrorHandler отвечает на последнюю ошибку, добавленную через c.Error,
+// если обработчик сам ничего не записал. Текст ошибок без вида в ответ
+// не попадает, чтобы не раскрывать детали хранилища.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		kind := apperr.Kind(err)
+		status := kindStatus[kind]
+		problem := Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(status),
+			Status:   status,
+			Instance: c.Request.URL.Path,
+			Code:     apperr.Code(err),
+		}
+
+		var verr *usecase.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			problem.Code = "validation_failed"
+			problem.Detail = "validation failed"
+			problem.Errors = verr.Fields
+		case kind == apperr.ErrInternal:
+			// Текст ошибки остаётся только в журнале запросов RequestLogger.
+		default:
+			problem.Detail = detail(err)
+		}
+
+		c.Header("Content-Type", problemContentType)
+		c.JSON(status, problem)
+	}
+}
+
+// abortWithError прерывает цепочку обработчиков, оставляя ответ ErrorHandler.
+func abortWithError(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+func detail(err error) string {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/apperr"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expected     Problem
+	}{
+		{
+			name:         "not found",
+			err:          apperr.NotFound("pvz_not_found", "pvz not found"),
+			expectedCode: http.StatusNotFound,
+			expected:     Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "pvz not found", Instance: "/test", Code: "pvz_not_found"},
+		},
+		{
+			name:         "conflict",
+			err:          usecase.ErrReceptionInProgress,
+			expectedCode: http.StatusConflict,
+			expected:     Problem{Type: "about:blank", Title: "Conflict", Status: http.StatusConflict, Detail: usecase.ErrReceptionInProgress.Message, Instance: "/test", Code: "reception_in_progress"},
+		},
+		{
+			name: "validation",
+			err: &usecase.ValidationError{Fields: []usecase.FieldError{
+				{Field: "email", Message: "is required"},
+			}},
+			expectedCode: http.StatusBadRequest,
+			expected: Problem{Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "validation failed", Instance: "/test", Code: "validation_failed",
+				Errors: []usecase.FieldError{{Field: "email", Message: "is required"}}},
+		},
+		{
+			name:         "internal error is not leaked",
+			err:          errors.New("pq: relation \"pvz\" does not exist"),
+			expectedCode: http.StatusInternalServerError,
+			expected:     Problem{Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError, Instance: "/test", Code: "internal"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.GET("/test", func(c *gin.Context) {
+				c.Error(tt.err)
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+
+			var problem Problem
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, tt.expected, problem)
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package middlewares
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
 	"testing"
 	"time"
 
@@ -17,33 +20,82 @@ type AuthServiceMock struct {
 	mock.Mock
 }
 
-func (m *AuthServiceMock) GenerateToken(userID uuid.UUID, role string) (string, error) {
-	args := m.Called(userID, role)
+func (m *AuthServiceMock) GenerateToken(userID uuid.UUID, role string, mfa bool) (string, error) {
+	args := m.Called(userID, role, mfa)
 	return args.String(0), args.Error(1)
 }
 
+func (m *AuthServiceMock) GenerateChallengeToken(userID uuid.UUID) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *AuthServiceMock) ValidateChallengeToken(tokenString string) (uuid.UUID, error) {
+	args := m.Called(tokenString)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
 func (m *AuthServiceMock) ValidateToken(tokenString string) (*jwt.Token, error) {
 	args := m.Called(tokenString)
 	return args.Get(0).(*jwt.Token), args.Error(1)
 }
 
+func (m *AuthServiceMock) GenerateRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *AuthServiceMock) RotateRefreshToken(ctx context.Context, refreshToken string) (uuid.UUID, string, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.Get(0).(uuid.UUID), args.String(1), args.Error(2)
+}
+
+func (m *AuthServiceMock) RevokeRefreshToken(ctx context.Context, userID uuid.UUID, refreshToken string) error {
+	args := m.Called(ctx, userID, refreshToken)
+	return args.Error(0)
+}
+
+func (m *AuthServiceMock) RevokeToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *AuthServiceMock) IsTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *AuthServiceMock) ActiveUser(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
+	args := m.Called(ctx, userID)
+	if user, ok := args.Get(0).(*entity.User); ok {
+		return user, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func TestJWTAuthMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	t.Run("valide token", func(t *testing.T) {
 		authServiceMock := new(AuthServiceMock)
+		jti := uuid.Must(uuid.NewV4())
+		userID := uuid.Must(uuid.NewV4())
 
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"id":   "1",
-			"exp":  time.Now().Add(time.Second * 5).Unix(),
+			"id":   userID.String(),
+			"jti":  jti.String(),
+			"exp":  float64(time.Now().Add(time.Second * 5).Unix()),
 			"role": "moderator",
 		})
 		token.Valid = true
 
 		authServiceMock.On("ValidateToken", "fake_token").Return(token, nil)
+		authServiceMock.On("IsTokenRevoked", context.Background(), jti).Return(false, nil)
+		authServiceMock.On("ActiveUser", context.Background(), userID).Return(&entity.User{ID: userID, Role: "moderator"}, nil)
 
 		router := gin.New()
-		router.Use(JWTAuthMiddleware(authServiceMock))
+		router.Use(ErrorHandler())
+		router.Use(JWTAuthMiddleware(authServiceMock, nil))
 		router.GET("/test", func(ctx *gin.Context) {
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
@@ -61,7 +113,8 @@ func TestJWTAuthMiddleware(t *testing.T) {
 		authServiceMock := new(AuthServiceMock)
 
 		router := gin.New()
-		router.Use(JWTAuthMiddleware(authServiceMock))
+		router.Use(ErrorHandler())
+		router.Use(JWTAuthMiddleware(authServiceMock, nil))
 		router.GET("/test", func(ctx *gin.Context) {
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
@@ -71,14 +124,15 @@ func TestJWTAuthMiddleware(t *testing.T) {
 		router.ServeHTTP(resp, req)
 
 		assert.Equal(t, http.StatusUnauthorized, resp.Code)
-		assert.Contains(t, resp.Body.String(), "Authorization header is required")
+		assert.Contains(t, resp.Body.String(), `"code":"missing_authorization"`)
 	})
 
 	t.Run("invalid token format", func(t *testing.T) {
 		authServiceMock := new(AuthServiceMock)
 
 		router := gin.New()
-		router.Use(JWTAuthMiddleware(authServiceMock))
+		router.Use(ErrorHandler())
+		router.Use(JWTAuthMiddleware(authServiceMock, nil))
 		router.GET("/test", func(ctx *gin.Context) {
 			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
@@ -89,7 +143,133 @@ func TestJWTAuthMiddleware(t *testing.T) {
 		router.ServeHTTP(resp, req)
 
 		assert.Equal(t, http.StatusUnauthorized, resp.Code)
-		assert.Contains(t, resp.Body.String(), "Invalid token format")
+		assert.Contains(t, resp.Body.String(), `"code":"invalid_token"`)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		authServiceMock := new(AuthServiceMock)
+		jti := uuid.Must(uuid.NewV4())
+		userID := uuid.Must(uuid.NewV4())
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"id":   userID.String(),
+			"jti":  jti.String(),
+			"exp":  float64(time.Now().Add(time.Second * 5).Unix()),
+			"role": "moderator",
+		})
+		token.Valid = true
+
+		authServiceMock.On("ValidateToken", "fake_token").Return(token, nil)
+		authServiceMock.On("IsTokenRevoked", context.Background(), jti).Return(true, nil)
+
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.Use(JWTAuthMiddleware(authServiceMock, nil))
+		router.GET("/test", func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer fake_token")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"code":"token_revoked"`)
+	})
+
+	t.Run("token without jti", func(t *testing.T) {
+		authServiceMock := new(AuthServiceMock)
+		userID := uuid.Must(uuid.NewV4())
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"id":   userID.String(),
+			"exp":  float64(time.Now().Add(time.Second * 5).Unix()),
+			"role": "moderator",
+		})
+		token.Valid = true
+
+		authServiceMock.On("ValidateToken", "fake_token").Return(token, nil)
+
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.Use(JWTAuthMiddleware(authServiceMock, nil))
+		router.GET("/test", func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer fake_token")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		authServiceMock.AssertNotCalled(t, "IsTokenRevoked", mock.Anything)
+	})
+
+	t.Run("disabled user", func(t *testing.T) {
+		authServiceMock := new(AuthServiceMock)
+		jti := uuid.Must(uuid.NewV4())
+		userID := uuid.Must(uuid.NewV4())
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"id":   userID.String(),
+			"jti":  jti.String(),
+			"exp":  float64(time.Now().Add(time.Second * 5).Unix()),
+			"role": "employee",
+		})
+		token.Valid = true
+
+		authServiceMock.On("ValidateToken", "fake_token").Return(token, nil)
+		authServiceMock.On("IsTokenRevoked", context.Background(), jti).Return(false, nil)
+		authServiceMock.On("ActiveUser", context.Background(), userID).Return(nil, usecase.ErrUserDisabled)
+
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.Use(JWTAuthMiddleware(authServiceMock, nil))
+		router.GET("/test", func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer fake_token")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"code":"account_disabled"`)
 	})
 
+	t.Run("role from stored user", func(t *testing.T) {
+		authServiceMock := new(AuthServiceMock)
+		jti := uuid.Must(uuid.NewV4())
+		userID := uuid.Must(uuid.NewV4())
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"id":   userID.String(),
+			"jti":  jti.String(),
+			"exp":  float64(time.Now().Add(time.Second * 5).Unix()),
+			"role": "moderator",
+		})
+		token.Valid = true
+
+		authServiceMock.On("ValidateToken", "fake_token").Return(token, nil)
+		authServiceMock.On("IsTokenRevoked", context.Background(), jti).Return(false, nil)
+		authServiceMock.On("ActiveUser", context.Background(), userID).Return(&entity.User{ID: userID, Role: "employee"}, nil)
+
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.Use(JWTAuthMiddleware(authServiceMock, nil))
+		router.GET("/test", func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{"role": ctx.GetString("role")})
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer fake_token")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"role":"employee"}`, resp.Body.String())
+	})
 }
@@ -1,53 +1,100 @@
 package middlewares
 
 import (
-	"net/http"
+	"pvz/internal/apperr"
 	"pvz/internal/usecase"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
 	"github.com/golang-jwt/jwt"
 )
 
-func JWTAuthMiddleware(authService usecase.AuthUsecase) gin.HandlerFunc {
+var (
+	errMissingAuthorization = apperr.Unauthorized("missing_authorization", "authorization header is required")
+	errInvalidTokenFormat   = apperr.Unauthorized("invalid_token", "invalid token format")
+	errInvalidToken         = apperr.Unauthorized("invalid_token", "invalid token")
+	errTokenRevoked         = apperr.Unauthorized("token_revoked", "token revoked")
+)
+
+// JWTAuthMiddleware принимает Bearer JWT или API ключ в заголовке X-API-Key.
+// В обоих случаях в контекст кладутся одни и те же userID и role.
+func JWTAuthMiddleware(authService usecase.AuthUsecase, apiKeys usecase.APIKeyUsecase) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			apiKeyAuth(c, apiKeys, apiKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			abortWithError(c, errMissingAuthorization)
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
+			abortWithError(c, errInvalidTokenFormat)
 			return
 		}
 
 		tokenString := parts[1]
 		parsedToken, err := authService.ValidateToken(tokenString)
 		if err != nil || !parsedToken.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			abortWithError(c, errInvalidToken)
 			return
 		}
 
 		claims, ok := parsedToken.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			abortWithError(c, errInvalidToken)
 			return
 		}
 
-		userID, ok := claims["id"]
+		userID, ok := claims["id"].(string)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+			abortWithError(c, errInvalidToken)
 			return
 		}
-		role, ok := claims["role"]
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid role in token"})
+		uid, err := uuid.FromString(userID)
+		if err != nil {
+			abortWithError(c, errInvalidToken)
+			return
+		}
+		mfa, _ := claims["mfa"].(bool)
+		jti, _ := claims["jti"].(string)
+		tokenID, err := uuid.FromString(jti)
+		if err != nil {
+			abortWithError(c, errInvalidToken)
+			return
+		}
+
+		revoked, err := authService.IsTokenRevoked(c.Request.Context(), tokenID)
+		if err != nil {
+			abortWithError(c, err)
 			return
 		}
-		c.Set("userID", userID.(string))
-		c.Set("role", role.(string))
+		if revoked {
+			abortWithError(c, errTokenRevoked)
+			return
+		}
+
+		// Роль берётся из базы, а не из токена: после смены роли старый
+		// токен не должен сохранять прежние права.
+		user, err := authService.ActiveUser(c.Request.Context(), uid)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.Set("userID", userID)
+		c.Set("role", user.Role)
+		c.Set("mfa", mfa)
+		c.Set("jti", jti)
+		if exp, ok := claims["exp"].(float64); ok {
+			c.Set("tokenExp", time.Unix(int64(exp), 0))
+		}
 
 		c.Next()
 	}
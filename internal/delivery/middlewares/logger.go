@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"pvz/internal/logging"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern ограничивает принятые от клиента идентификаторы, чтобы
+// в журнал не попадали переводы строк и произвольно длинные значения.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestLogger назначает запросу X-Request-ID, если клиент его не передал,
+// кладёт в контекст логгер с request_id и trace_id и после ответа пишет
+// строку журнала. Пользователь и роль берутся из значений, которые
+// выставляет аутентификация.
+func RequestLogger(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.Must(uuid.NewV4()).String()
+		}
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		logger := base.With(slog.String("request_id", requestID))
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			logger = logger.With(slog.String("trace_id", span.TraceID().String()))
+		}
+		ctx := logging.WithContext(c.Request.Context(), logger)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		attrs := []any{
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if userID := c.GetString("userID"); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID), slog.String("role", c.GetString("role")))
+		}
+		if keyID := c.GetString("apiKeyID"); keyID != "" {
+			attrs = append(attrs, slog.String("api_key_id", keyID))
+		}
+		if err := c.Errors.Last(); err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger.Log(ctx, level, "request", attrs...)
+	}
+}
@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/logging"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		requestID       string
+		status          int
+		err             error
+		expectedLevel   string
+		keepsRequestID  bool
+		authenticatedAs string
+	}{
+		{
+			name:            "propagates request id",
+			requestID:       "req-42",
+			status:          http.StatusOK,
+			expectedLevel:   "INFO",
+			keepsRequestID:  true,
+			authenticatedAs: "employee",
+		},
+		{
+			name:          "generates request id",
+			status:        http.StatusOK,
+			expectedLevel: "INFO",
+		},
+		{
+			name:          "replaces malformed request id",
+			requestID:     "bad id\nforged=1",
+			status:        http.StatusConflict,
+			expectedLevel: "WARN",
+		},
+		{
+			name:           "server error",
+			requestID:      "req-43",
+			status:         http.StatusInternalServerError,
+			err:            errors.New("connection reset"),
+			expectedLevel:  "ERROR",
+			keepsRequestID: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&out, nil))
+
+			router := gin.New()
+			router.Use(RequestLogger(logger))
+			router.GET("/pvz/:pvzId", func(c *gin.Context) {
+				if tt.authenticatedAs != "" {
+					c.Set("userID", "7b3c2a59-0d2e-4c1e-9c5b-2c2f2c9b7a10")
+					c.Set("role", tt.authenticatedAs)
+				}
+				logging.FromContext(c.Request.Context()).Info("from usecase")
+				if tt.err != nil {
+					c.Error(tt.err)
+				}
+				c.Status(tt.status)
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/pvz/1", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(RequestIDHeader)
+			require.NotEmpty(t, requestID)
+			if tt.keepsRequestID {
+				assert.Equal(t, tt.requestID, requestID)
+			} else {
+				assert.NotEqual(t, tt.requestID, requestID)
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			require.Len(t, lines, 2)
+
+			var fromUsecase, access map[string]any
+			require.NoError(t, json.Unmarshal([]byte(lines[0]), &fromUsecase))
+			require.NoError(t, json.Unmarshal([]byte(lines[1]), &access))
+
+			assert.Equal(t, requestID, fromUsecase["request_id"])
+			assert.Equal(t, requestID, access["request_id"])
+			assert.Equal(t, tt.expectedLevel, access["level"])
+			assert.Equal(t, "GET", access["method"])
+			assert.Equal(t, "/pvz/:pvzId", access["route"])
+			assert.Equal(t, float64(tt.status), access["status"])
+			assert.Contains(t, access, "latency")
+			if tt.authenticatedAs != "" {
+				assert.Equal(t, tt.authenticatedAs, access["role"])
+				assert.Contains(t, access, "user_id")
+			} else {
+				assert.NotContains(t, access, "user_id")
+			}
+			if tt.err != nil {
+				assert.Equal(t, tt.err.Error(), access["error"])
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RequestObserver interface {
+	ObserveRequest(route, method string, status int, duration time.Duration)
+}
+
+// RequestMetrics передаёт observer длительность и статус каждого запроса.
+// Метка route — шаблон маршрута, а не путь, чтобы идентификаторы
+// не раздували число рядов.
+func RequestMetrics(observer RequestObserver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		observer.ObserveRequest(route, c.Request.Method, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type observed struct {
+	route  string
+	method string
+	status int
+}
+
+type fakeObserver struct {
+	requests []observed
+}
+
+func (f *fakeObserver) ObserveRequest(route, method string, status int, duration time.Duration) {
+	f.requests = append(f.requests, observed{route: route, method: method, status: status})
+}
+
+func TestRequestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	observer := &fakeObserver{}
+	router := gin.New()
+	router.Use(RequestMetrics(observer))
+	router.GET("/pvz/:pvzId", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/pvz/1", "/pvz/2", "/missing"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, []observed{
+		{route: "/pvz/:pvzId", method: http.MethodGet, status: http.StatusNotFound},
+		{route: "/pvz/:pvzId", method: http.MethodGet, status: http.StatusNotFound},
+		{route: "unmatched", method: http.MethodGet, status: http.StatusNotFound},
+	}, observer.requests)
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"pvz/internal/apperr"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errTwoFactorRequired = apperr.Forbidden("two_factor_required", "two-factor authentication required")
+	errPermissionDenied  = apperr.Forbidden("permission_denied", "permission denied")
+)
+
+func RequirePermission(policy *usecase.Policy, permissions ...usecase.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if policy.TwoFactorRequired(role) && !c.GetBool("mfa") {
+			abortWithError(c, errTwoFactorRequired)
+			return
+		}
+		// API ключ сужает разрешения роли владельца до выданных ключу
+		scoped, isAPIKey := c.Get("apiKeyPermissions")
+		for _, permission := range permissions {
+			if !policy.Allowed(role, permission) {
+				abortWithError(c, errPermissionDenied)
+				return
+			}
+			if isAPIKey && !hasPermission(scoped, permission) {
+				abortWithError(c, errPermissionDenied)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func hasPermission(scoped any, permission usecase.Permission) bool {
+	permissions, _ := scoped.([]usecase.Permission)
+	for _, granted := range permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policyPath := filepath.Join(t.TempDir(), "policy.json")
+	err := os.WriteFile(policyPath, []byte(`{"roles": {"warehouse": ["reception:open", "product:create"]}}`), 0o600)
+	assert.NoError(t, err)
+
+	customPolicy, err := usecase.LoadPolicy(policyPath)
+	assert.NoError(t, err)
+
+	twoFactorPath := filepath.Join(t.TempDir(), "policy.json")
+	err = os.WriteFile(twoFactorPath, []byte(`{"twoFactorRoles": ["moderator"]}`), 0o600)
+	assert.NoError(t, err)
+
+	twoFactorPolicy, err := usecase.LoadPolicy(twoFactorPath)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		policy       *usecase.Policy
+		role         any
+		mfa          bool
+		permissions  []usecase.Permission
+		expectedCode int
+	}{
+		{
+			name:         "allowed",
+			policy:       usecase.DefaultPolicy(),
+			role:         "moderator",
+			mfa:          true,
+			permissions:  []usecase.Permission{usecase.PermPVZCreate},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "second factor not required by default",
+			policy:       usecase.DefaultPolicy(),
+			role:         "moderator",
+			permissions:  []usecase.Permission{usecase.PermPVZRead},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "second factor required by policy file",
+			policy:       twoFactorPolicy,
+			role:         "moderator",
+			permissions:  []usecase.Permission{usecase.PermPVZRead},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "denied",
+			policy:       usecase.DefaultPolicy(),
+			role:         "employee",
+			permissions:  []usecase.Permission{usecase.PermPVZCreate},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "all permissions required",
+			policy:       usecase.DefaultPolicy(),
+			role:         "employee",
+			permissions:  []usecase.Permission{usecase.PermPVZRead, usecase.PermPVZCreate},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "missing role",
+			policy:       usecase.DefaultPolicy(),
+			role:         nil,
+			permissions:  []usecase.Permission{usecase.PermPVZRead},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "role from policy file",
+			policy:       customPolicy,
+			role:         "warehouse",
+			permissions:  []usecase.Permission{usecase.PermReceptionOpen},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.GET("/test", func(ctx *gin.Context) {
+				if tt.role != nil {
+					ctx.Set("role", tt.role)
+				}
+				ctx.Set("mfa", tt.mfa)
+			}, RequirePermission(tt.policy, tt.permissions...), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{"message": "success"})
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
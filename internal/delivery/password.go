@@ -0,0 +1,96 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	passwordUsecase usecase.PasswordUsecase
+}
+
+func NewPasswordHandler(passwordUsecase usecase.PasswordUsecase) *PasswordHandler {
+	return &PasswordHandler{passwordUsecase: passwordUsecase}
+}
+
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var input struct {
+		OldPassword string `json:"oldPassword"`
+		NewPassword string `json:"newPassword"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if input.OldPassword == "" || input.NewPassword == "" {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	err = h.passwordUsecase.ChangePassword(c.Request.Context(), actor, input.OldPassword, input.NewPassword)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RequestPasswordReset всегда отвечает 202, даже если email не зарегистрирован.
+func (h *PasswordHandler) RequestPasswordReset(c *gin.Context) {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if input.Email == "" {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if err := h.passwordUsecase.RequestPasswordReset(c.Request.Context(), input.Email); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *PasswordHandler) ConfirmPasswordReset(c *gin.Context) {
+	var input struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"newPassword"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if input.Token == "" || input.NewPassword == "" {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	err := h.passwordUsecase.ConfirmPasswordReset(c.Request.Context(), requestActor(c), input.Token, input.NewPassword)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,169 @@
+package delivery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasswordUsecase struct {
+	mock.Mock
+}
+
+func (m *MockPasswordUsecase) ChangePassword(ctx context.Context, actor entity.Actor, oldPassword, newPassword string) error {
+	args := m.Called(ctx, actor, oldPassword, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockPasswordUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockPasswordUsecase) ConfirmPasswordReset(ctx context.Context, actor entity.Actor, token, newPassword string) error {
+	args := m.Called(ctx, actor, token, newPassword)
+	return args.Error(0)
+}
+
+func TestChangePasswordHandler(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+
+	tests := []struct {
+		name         string
+		requestBody  any
+		mock         func(*MockPasswordUsecase)
+		expectedCode int
+	}{
+		{
+			name:        "succesful change",
+			requestBody: map[string]any{"oldPassword": "old", "newPassword": "new"},
+			mock: func(m *MockPasswordUsecase) {
+				m.On("ChangePassword", context.Background(), actorWithUser(user_id), "old", "new").Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "empty new password",
+			requestBody:  map[string]any{"oldPassword": "old"},
+			mock:         func(m *MockPasswordUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "wrong current password",
+			requestBody: map[string]any{"oldPassword": "wrong", "newPassword": "new"},
+			mock: func(m *MockPasswordUsecase) {
+				m.On("ChangePassword", context.Background(), actorWithUser(user_id), "wrong", "new").Return(usecase.ErrInvalidPassword)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockPasswordUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewPasswordHandler(mockUsecase)
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.POST("/password/change", func(ctx *gin.Context) {
+				ctx.Set("userID", user_id.String())
+			}, handler.ChangePassword)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest(http.MethodPost, "/password/change", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPasswordResetHandlers(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		requestBody  any
+		mock         func(*MockPasswordUsecase)
+		expectedCode int
+	}{
+		{
+			name:        "reset requested",
+			path:        "/password/reset/request",
+			requestBody: map[string]any{"email": "test@example.com"},
+			mock: func(m *MockPasswordUsecase) {
+				m.On("RequestPasswordReset", context.Background(), "test@example.com").Return(nil)
+			},
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:        "notifier failure",
+			path:        "/password/reset/request",
+			requestBody: map[string]any{"email": "test@example.com"},
+			mock: func(m *MockPasswordUsecase) {
+				m.On("RequestPasswordReset", context.Background(), "test@example.com").Return(errors.New("smtp down"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:        "reset confirmed",
+			path:        "/password/reset/confirm",
+			requestBody: map[string]any{"token": "token", "newPassword": "new"},
+			mock: func(m *MockPasswordUsecase) {
+				m.On("ConfirmPasswordReset", context.Background(), mock.AnythingOfType("entity.Actor"), "token", "new").Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "invalid token",
+			path:        "/password/reset/confirm",
+			requestBody: map[string]any{"token": "used", "newPassword": "new"},
+			mock: func(m *MockPasswordUsecase) {
+				m.On("ConfirmPasswordReset", context.Background(), mock.AnythingOfType("entity.Actor"), "used", "new").Return(usecase.ErrInvalidResetToken)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockPasswordUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewPasswordHandler(mockUsecase)
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.POST("/password/reset/request", handler.RequestPasswordReset)
+			router.POST("/password/reset/confirm", handler.ConfirmPasswordReset)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest(http.MethodPost, tt.path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
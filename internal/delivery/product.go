@@ -17,31 +17,30 @@ func NewProductHandler(productUsecase usecase.ProductUsecase) *ProductHandler {
 }
 
 func (h *ProductHandler) Reception(c *gin.Context) {
-	role, _ := c.Get("role")
-
-	if role.(string) != "employee" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Permision denied"})
-		return
-	}
-
 	var input struct {
 		ProductType string    `json:"type"`
 		ID          uuid.UUID `json:"pvzId"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
 	if input.ID.String() == "" || input.ProductType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	product, err := h.productUsecase.CreateProduct(input.ID, input.ProductType)
+	product, err := h.productUsecase.CreateProduct(c.Request.Context(), actor, input.ID, input.ProductType)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -49,24 +48,23 @@ func (h *ProductHandler) Reception(c *gin.Context) {
 }
 
 func (h *ProductHandler) DeleteLastProduct(c *gin.Context) {
-	role, _ := c.Get("role")
+	pvz_id_string := c.Param("pvzId")
 
-	if role.(string) != "employee" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Permision denied"})
+	pvz_id, err := uuid.FromString(pvz_id_string)
+	if err != nil {
+		c.Error(errInvalidPath)
 		return
 	}
 
-	pvz_id_string := c.Param("pvzId")
-
-	pvz_id, err := uuid.FromString(pvz_id_string)
+	actor, err := actorFromContext(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong query"})
+		c.Error(err)
 		return
 	}
 
-	err = h.productUsecase.DeleteLastProduct(pvz_id)
+	err = h.productUsecase.DeleteLastProduct(c.Request.Context(), actor, pvz_id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(err)
 		return
 	}
 
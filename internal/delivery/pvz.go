@@ -11,6 +11,10 @@ import (
 	"github.com/gofrs/uuid/v5"
 )
 
+// maxPVZPageLimit ограничивает страницу ПВЗ и приёмок: каждая позиция
+// приходит со всеми вложенными товарами.
+const maxPVZPageLimit = 30
+
 type PVZHandler struct {
 	pvzUsecase usecase.PVZUsecase
 }
@@ -20,14 +24,6 @@ func NewPVZHandler(pvzUsecase usecase.PVZUsecase) *PVZHandler {
 }
 
 func (h *PVZHandler) PostPVZ(c *gin.Context) {
-	role, _ := c.Get("role")
-	id, _ := c.Get("userID")
-
-	if role.(string) != "moderator" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Permision denied"})
-		return
-	}
-
 	var input struct {
 		Id               uuid.UUID `json:"id"`
 		RegistrationDate time.Time `json:"registrationDate"`
@@ -35,24 +31,24 @@ func (h *PVZHandler) PostPVZ(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
 	if input.Id.IsNil() || input.City == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
-	idx, err := uuid.FromString(id.(string))
+	actor, err := actorFromContext(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	pvz, err := h.pvzUsecase.CreatePVZ(input.Id, idx, input.City, input.RegistrationDate)
+	pvz, err := h.pvzUsecase.CreatePVZ(c.Request.Context(), input.Id, actor, input.City, input.RegistrationDate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -61,31 +57,19 @@ func (h *PVZHandler) PostPVZ(c *gin.Context) {
 
 func (h *PVZHandler) GetPVZs(c *gin.Context) {
 	var filter entity.Filter
-	role, _ := c.Get("role")
 
-	if role.(string) != "moderator" && role.(string) != "employee" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Permision denied"})
-		return
-	}
-
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	page, limit, cursor, err := pageParams(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+		c.Error(err)
 		return
 	}
 	filter.Page = page
-
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
-		return
-	}
 	filter.Limit = limit
 
 	if startDateStr := c.Query("startDate"); startDateStr != "" {
 		startDate, err := time.Parse(time.RFC3339, startDateStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid startDate format"})
+			c.Error(invalidQuery("startDate"))
 			return
 		}
 		filter.StartDate = &startDate
@@ -94,18 +78,98 @@ func (h *PVZHandler) GetPVZs(c *gin.Context) {
 	if endDateStr := c.Query("endDate"); endDateStr != "" {
 		endDate, err := time.Parse(time.RFC3339, endDateStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid endDate format"})
+			c.Error(invalidQuery("endDate"))
 			return
 		}
 		filter.EndDate = &endDate
 	}
 
+	// ключ, ограниченный ПВЗ, видит в списке только их
+	if scoped, ok := c.Get("apiKeyPVZIDs"); ok {
+		filter.PVZIDs, _ = scoped.([]uuid.UUID)
+	}
+
+	filter.Status = c.Query("status")
+	filter.ProductType = c.Query("type")
+	filter.City = c.Query("city")
+
+	if userID := c.Query("userId"); userID != "" {
+		user_id, err := uuid.FromString(userID)
+		if err != nil {
+			c.Error(invalidQuery("userId"))
+			return
+		}
+		filter.UserID = &user_id
+	}
+
+	if includeEmpty := c.Query("includeEmpty"); includeEmpty != "" {
+		filter.IncludeEmpty, err = strconv.ParseBool(includeEmpty)
+		if err != nil {
+			c.Error(invalidQuery("includeEmpty"))
+			return
+		}
+	}
+
+	filter.Sort = c.DefaultQuery("sort", entity.PVZSortLastReception)
+	switch filter.Sort {
+	case entity.PVZSortLastReception, entity.PVZSortRegistrationDate, entity.PVZSortProductCount:
+	default:
+		c.Error(invalidQuery("sort"))
+		return
+	}
+
 	// Вызов usecase
-	response, err := h.pvzUsecase.GetPVZsWithFilter(c.Request.Context(), filter)
+	response, err := h.pvzUsecase.GetPVZsWithFilter(c.Request.Context(), filter, cursor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+func (h *PVZHandler) GetPVZ(c *gin.Context) {
+	pvz_id, err := uuid.FromString(c.Param("pvzId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	page, limit, cursor, err := pageParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filter := entity.ReceptionFilter{Page: page, Limit: limit}
+	response, err := h.pvzUsecase.GetPVZ(c.Request.Context(), pvz_id, filter, cursor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// pageParams разбирает page, limit и cursor. Курсор заменяет номер страницы,
+// поэтому вместе с ним page не принимается, а в ответе не заполняется.
+func pageParams(c *gin.Context) (page, limit int, cursor string, err error) {
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxPVZPageLimit {
+		return 0, 0, "", invalidQuery("limit")
+	}
+
+	cursor = c.Query("cursor")
+	if cursor != "" {
+		if _, ok := c.GetQuery("page"); ok {
+			return 0, 0, "", invalidQuery("page")
+		}
+		return 0, limit, cursor, nil
+	}
+
+	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, "", invalidQuery("page")
+	}
+	return page, limit, "", nil
+}
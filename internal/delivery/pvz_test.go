@@ -4,11 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
 	"pvz/internal/storage/migrations/entity"
 	"pvz/internal/usecase"
 	"testing"
@@ -24,16 +24,26 @@ type MockPVZUsecase struct {
 	mock.Mock
 }
 
-func (p *MockPVZUsecase) CreatePVZ(id, user_id uuid.UUID, city string, date time.Time) (*entity.PVZ, error) {
-	args := p.Called(id, user_id, city, date)
+func (p *MockPVZUsecase) CreatePVZ(ctx context.Context, id uuid.UUID, actor entity.Actor, city string, date time.Time) (*entity.PVZ, error) {
+	args := p.Called(ctx, id, actor, city, date)
 	return args.Get(0).(*entity.PVZ), args.Error(1)
 }
 
-func (p *MockPVZUsecase) GetPVZsWithFilter(ctx context.Context, filter entity.Filter) (*usecase.PVZListResponse, error) {
-	args := p.Called(ctx, filter)
+func (p *MockPVZUsecase) GetPVZsWithFilter(ctx context.Context, filter entity.Filter, cursor string) (*usecase.PVZListResponse, error) {
+	args := p.Called(ctx, filter, cursor)
 	return args.Get(0).(*usecase.PVZListResponse), args.Error(1)
 }
 
+func (p *MockPVZUsecase) GetPVZ(ctx context.Context, id uuid.UUID, filter entity.ReceptionFilter, cursor string) (*usecase.PVZDetailResponse, error) {
+	args := p.Called(ctx, id, filter, cursor)
+	return args.Get(0).(*usecase.PVZDetailResponse), args.Error(1)
+}
+
+// actorWithUser сверяет автора изменения, не завися от IP и идентификатора запроса.
+func actorWithUser(user_id uuid.UUID) any {
+	return mock.MatchedBy(func(actor entity.Actor) bool { return actor.UserID == user_id })
+}
+
 func TestPostPVZHandler(t *testing.T) {
 	pvz_id := uuid.Must(uuid.NewV4())
 	user_id := uuid.Must(uuid.NewV4())
@@ -58,7 +68,7 @@ func TestPostPVZHandler(t *testing.T) {
 				"city":             "Москва",
 			},
 			mock: func(mru *MockPVZUsecase) {
-				mru.On("CreatePVZ", pvz_id, user_id, "Москва", mock.AnythingOfType("time.Time")).Return(&entity.PVZ{
+				mru.On("CreatePVZ", context.Background(), pvz_id, actorWithUser(user_id), "Москва", mock.AnythingOfType("time.Time")).Return(&entity.PVZ{
 					ID:               pvz_id,
 					City:             "Москва",
 					UserID:           user_id,
@@ -105,7 +115,7 @@ func TestPostPVZHandler(t *testing.T) {
 				"city":             "Ступино",
 			},
 			mock: func(mru *MockPVZUsecase) {
-				mru.On("CreatePVZ", pvz_id, user_id, "Ступино", mock.AnythingOfType("time.Time")).Return(&entity.PVZ{}, errors.New("bad city"))
+				mru.On("CreatePVZ", context.Background(), pvz_id, actorWithUser(user_id), "Ступино", mock.AnythingOfType("time.Time")).Return(&entity.PVZ{}, usecase.ErrUnsupportedCity)
 			},
 			expectedCode: http.StatusBadRequest,
 		},
@@ -131,11 +141,12 @@ func TestPostPVZHandler(t *testing.T) {
 			handler := delivery.NewPVZHandler(mockUsecase)
 
 			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
 			router.POST("/pvz", func(ctx *gin.Context) {
 				ctx.Set("userID", tt.userID)
 				ctx.Set("role", tt.role)
-				handler.PostPVZ(ctx)
-			})
+				ctx.Set("mfa", true)
+			}, middlewares.RequirePermission(usecase.DefaultPolicy(), usecase.PermPVZCreate), handler.PostPVZ)
 
 			body, _ := json.Marshal(tt.requestBody)
 			req, _ := http.NewRequest(http.MethodPost, "/pvz", bytes.NewBuffer(body))
@@ -165,6 +176,7 @@ func TestGetPVZHandler(t *testing.T) {
 		name         string
 		role         string
 		queryParams  string
+		apiKeyPVZIDs []uuid.UUID
 		requestBody  any
 		mock         func(*MockPVZUsecase)
 		expectedCode int
@@ -181,9 +193,10 @@ func TestGetPVZHandler(t *testing.T) {
 				mru.On("GetPVZsWithFilter", context.Background(), entity.Filter{
 					StartDate: &startDate,
 					EndDate:   &endDate,
+					Sort:      entity.PVZSortLastReception,
 					Page:      1,
 					Limit:     10,
-				}).Return(&usecase.PVZListResponse{
+				}, "").Return(&usecase.PVZListResponse{
 					PVZs: []entity.ListPVZ{
 						{
 							Pvz: entity.PVZ{
@@ -291,6 +304,99 @@ func TestGetPVZHandler(t *testing.T) {
 			mock:         func(mru *MockPVZUsecase) {},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name:        "cursor",
+			role:        "moderator",
+			queryParams: "cursor=abc&limit=2",
+			mock: func(mru *MockPVZUsecase) {
+				mru.On("GetPVZsWithFilter", context.Background(), entity.Filter{Sort: entity.PVZSortLastReception, Limit: 2}, "abc").Return(&usecase.PVZListResponse{
+					PVZs:       []entity.ListPVZ{},
+					Limit:      2,
+					NextCursor: "def",
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]any{
+				"pvzs":        []any{},
+				"limit":       float64(2),
+				"total":       float64(0),
+				"next_cursor": "def",
+			},
+		},
+		{
+			name:        "filters and sort",
+			role:        "moderator",
+			queryParams: "city=Казань&status=close&type=обувь&userId=" + user_id.String() + "&includeEmpty=true&sort=product_count",
+			mock: func(mru *MockPVZUsecase) {
+				mru.On("GetPVZsWithFilter", context.Background(), entity.Filter{
+					Status:       "close",
+					ProductType:  "обувь",
+					City:         "Казань",
+					UserID:       &user_id,
+					IncludeEmpty: true,
+					Sort:         entity.PVZSortProductCount,
+					Page:         1,
+					Limit:        10,
+				}, "").Return(&usecase.PVZListResponse{PVZs: []entity.ListPVZ{}, Page: 1, Limit: 10}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "api key limited to pvzs",
+			role:         "employee",
+			apiKeyPVZIDs: []uuid.UUID{pvz_id},
+			mock: func(mru *MockPVZUsecase) {
+				mru.On("GetPVZsWithFilter", context.Background(), entity.Filter{
+					PVZIDs: []uuid.UUID{pvz_id},
+					Sort:   entity.PVZSortLastReception,
+					Page:   1,
+					Limit:  10,
+				}, "").Return(&usecase.PVZListResponse{PVZs: []entity.ListPVZ{}, Page: 1, Limit: 10}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unknown sort",
+			role:         "moderator",
+			queryParams:  "sort=city",
+			mock:         func(mru *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid user id",
+			role:         "moderator",
+			queryParams:  "userId=bad",
+			mock:         func(mru *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid include empty",
+			role:         "moderator",
+			queryParams:  "includeEmpty=maybe",
+			mock:         func(mru *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "page below one",
+			role:         "moderator",
+			queryParams:  "page=0&limit=10",
+			mock:         func(mru *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "zero limit",
+			role:         "moderator",
+			queryParams:  "page=1&limit=0",
+			mock:         func(mru *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "limit above 30",
+			role:         "moderator",
+			queryParams:  "page=1&limit=31",
+			mock:         func(mru *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
 		{
 			name: "wrong role",
 			role: "user",
@@ -312,10 +418,14 @@ func TestGetPVZHandler(t *testing.T) {
 			handler := delivery.NewPVZHandler(mockUsecase)
 
 			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
 			router.GET("/pvz", func(ctx *gin.Context) {
 				ctx.Set("role", tt.role)
-				handler.GetPVZs(ctx)
-			})
+				ctx.Set("mfa", true)
+				if tt.apiKeyPVZIDs != nil {
+					ctx.Set("apiKeyPVZIDs", tt.apiKeyPVZIDs)
+				}
+			}, middlewares.RequirePermission(usecase.DefaultPolicy(), usecase.PermPVZRead), handler.GetPVZs)
 
 			req, _ := http.NewRequest(http.MethodGet, "/pvz?"+tt.queryParams, nil)
 			req.Header.Set("Content-Type", "application/json")
@@ -335,3 +445,145 @@ func TestGetPVZHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPVZByIDHandler(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	reception_id := uuid.Must(uuid.NewV4())
+	date := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		path         string
+		mock         func(*MockPVZUsecase)
+		expectedCode int
+		expectedBody map[string]any
+	}{
+		{
+			name: "success",
+			path: "/pvz/" + pvz_id.String() + "?page=2&limit=5",
+			mock: func(mpu *MockPVZUsecase) {
+				mpu.On("GetPVZ", context.Background(), pvz_id, entity.ReceptionFilter{Page: 2, Limit: 5}, "").Return(&usecase.PVZDetailResponse{
+					Pvz: entity.PVZ{ID: pvz_id, City: "Казань", RegistrationDate: date},
+					Receptions: []entity.Receptions{
+						{ID: reception_id, DateTime: date, PVZID: pvz_id, Status: "in_progress", Products: []entity.Products{}},
+					},
+					TotalReceptions: 6,
+					TotalProducts:   40,
+					OpenReceptionID: &reception_id,
+					Page:            2,
+					Limit:           5,
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]any{
+				"totalReceptions": float64(6),
+				"totalProducts":   float64(40),
+				"openReceptionId": reception_id.String(),
+				"page":            float64(2),
+				"limit":           float64(5),
+			},
+		},
+		{
+			name: "defaults",
+			path: "/pvz/" + pvz_id.String(),
+			mock: func(mpu *MockPVZUsecase) {
+				mpu.On("GetPVZ", context.Background(), pvz_id, entity.ReceptionFilter{Page: 1, Limit: 10}, "").Return(&usecase.PVZDetailResponse{
+					Pvz:        entity.PVZ{ID: pvz_id},
+					Receptions: []entity.Receptions{},
+					Page:       1,
+					Limit:      10,
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]any{
+				"openReceptionId": nil,
+				"receptions":      []any{},
+			},
+		},
+		{
+			name: "not found",
+			path: "/pvz/" + pvz_id.String(),
+			mock: func(mpu *MockPVZUsecase) {
+				mpu.On("GetPVZ", context.Background(), pvz_id, entity.ReceptionFilter{Page: 1, Limit: 10}, "").Return((*usecase.PVZDetailResponse)(nil), usecase.ErrPVZNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: map[string]any{"code": "pvz_not_found"},
+		},
+		{
+			name:         "invalid id",
+			path:         "/pvz/42",
+			mock:         func(mpu *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "limit too large",
+			path:         "/pvz/" + pvz_id.String() + "?limit=31",
+			mock:         func(mpu *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "cursor",
+			path: "/pvz/" + pvz_id.String() + "?cursor=abc&limit=5",
+			mock: func(mpu *MockPVZUsecase) {
+				mpu.On("GetPVZ", context.Background(), pvz_id, entity.ReceptionFilter{Limit: 5}, "abc").Return(&usecase.PVZDetailResponse{
+					Pvz:        entity.PVZ{ID: pvz_id},
+					Receptions: []entity.Receptions{},
+					Limit:      5,
+					NextCursor: "def",
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: map[string]any{
+				"next_cursor": "def",
+				"page":        nil,
+			},
+		},
+		{
+			name: "tampered cursor",
+			path: "/pvz/" + pvz_id.String() + "?cursor=abc",
+			mock: func(mpu *MockPVZUsecase) {
+				mpu.On("GetPVZ", context.Background(), pvz_id, entity.ReceptionFilter{Limit: 10}, "abc").Return((*usecase.PVZDetailResponse)(nil), usecase.ErrInvalidCursor)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: map[string]any{"code": "invalid_cursor"},
+		},
+		{
+			name:         "cursor with page",
+			path:         "/pvz/" + pvz_id.String() + "?cursor=abc&page=2",
+			mock:         func(mpu *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "page below one",
+			path:         "/pvz/" + pvz_id.String() + "?page=0",
+			mock:         func(mpu *MockPVZUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockPVZUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewPVZHandler(mockUsecase)
+
+			router := gin.New()
+			router.Use(middlewares.ErrorHandler())
+			router.GET("/pvz/:pvzId", handler.GetPVZ)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			var responseBody map[string]any
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responseBody))
+			for key, value := range tt.expectedBody {
+				assert.Equal(t, value, responseBody[key], key)
+			}
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
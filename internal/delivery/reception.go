@@ -17,30 +17,29 @@ func NewReceptionHandler(receptionUsecase usecase.ReceptionUsecase) *ReceptionHa
 }
 
 func (h *ReceptionHandler) Reception(c *gin.Context) {
-	role, _ := c.Get("role")
-
-	if role.(string) != "employee" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Permision denied"})
-		return
-	}
-
 	var input struct {
 		ID uuid.UUID `json:"pvzId"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
 	if input.ID.IsNil() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	actor, err := actorFromContext(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	reception, err := h.receptionUsecase.CreateReception(input.ID)
+	reception, err := h.receptionUsecase.CreateReception(c.Request.Context(), actor, input.ID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -48,24 +47,23 @@ func (h *ReceptionHandler) Reception(c *gin.Context) {
 }
 
 func (h *ReceptionHandler) UpdateReceptionStatus(c *gin.Context) {
-	role, _ := c.Get("role")
+	pvz_id_string := c.Param("pvzId")
 
-	if role.(string) != "employee" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Permision denied"})
+	pvz_id, err := uuid.FromString(pvz_id_string)
+	if err != nil {
+		c.Error(errInvalidPath)
 		return
 	}
 
-	pvz_id_string := c.Param("pvzId")
-
-	pvz_id, err := uuid.FromString(pvz_id_string)
+	actor, err := actorFromContext(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong query"})
+		c.Error(err)
 		return
 	}
 
-	reception, err := h.receptionUsecase.UpdateReceptionStatus(pvz_id)
+	reception, err := h.receptionUsecase.UpdateReceptionStatus(c.Request.Context(), actor, pvz_id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
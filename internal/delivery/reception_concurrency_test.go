@@ -0,0 +1,226 @@
+package delivery_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receptionsTable — таблица reception в памяти. Вторую незакрытую приёмку
+// она отклоняет при вставке той же ошибкой, что и storage при нарушении
+// индекса, но сам индекс здесь не проверяется: это делает
+// TestReceptionHandler_ConcurrentCreatePostgres.
+type receptionsTable struct {
+	mu         sync.Mutex
+	receptions []entity.Receptions
+}
+
+func (r *receptionsTable) CreateReception(ctx context.Context, id uuid.UUID, actor entity.Actor) (*entity.Receptions, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reception := range r.receptions {
+		if reception.PVZID == id && reception.Status == "in_progress" {
+			return nil, storage.ErrReceptionInProgress
+		}
+	}
+	reception := entity.Receptions{ID: uuid.Must(uuid.NewV4()), DateTime: time.Now(), PVZID: id, Status: "in_progress"}
+	r.receptions = append(r.receptions, reception)
+	return &reception, nil
+}
+
+func (r *receptionsTable) GetLastReceptionStatus(ctx context.Context, id uuid.UUID) (uuid.UUID, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.receptions) - 1; i >= 0; i-- {
+		if r.receptions[i].PVZID == id {
+			return r.receptions[i].ID, r.receptions[i].Status, nil
+		}
+	}
+	return uuid.UUID{}, "", sql.ErrNoRows
+}
+
+func (r *receptionsTable) UpdateReceptionStatus(ctx context.Context, reception_id uuid.UUID, actor entity.Actor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.receptions {
+		if r.receptions[i].ID == reception_id {
+			r.receptions[i].Status = "close"
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *receptionsTable) GetReceptionById(ctx context.Context, reception_id uuid.UUID) (*entity.Receptions, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reception := range r.receptions {
+		if reception.ID == reception_id {
+			return &reception, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *receptionsTable) inProgress(pvz_id uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, reception := range r.receptions {
+		if reception.PVZID == pvz_id && reception.Status == "in_progress" {
+			count++
+		}
+	}
+	return count
+}
+
+type receptionsTx struct {
+	storage.Tx
+	table *receptionsTable
+}
+
+func (r *receptionsTx) WithTx(ctx context.Context, fn func(tx storage.Tx) error) error {
+	return fn(r)
+}
+
+func (r *receptionsTx) Receptions() storage.ReceptionPostgresStorage {
+	return r.table
+}
+
+func (r *receptionsTx) PVZ() storage.PVZPostgresStorage {
+	return anyPVZ{}
+}
+
+type anyPVZ struct {
+	storage.PVZPostgresStorage
+}
+
+func (anyPVZ) GetPVZById(ctx context.Context, id uuid.UUID) (*entity.PVZ, error) {
+	return &entity.PVZ{ID: id, City: "Москва"}, nil
+}
+
+type assignedEverywhere struct {
+	storage.AssignmentsPostgresStorage
+}
+
+func (assignedEverywhere) IsAssigned(ctx context.Context, user_id, pvz_id uuid.UUID) (bool, error) {
+	return true, nil
+}
+
+// TestReceptionHandler_ConcurrentCreateConflictMapping проверяет, что при
+// одновременных запросах ошибка вставки доходит до клиента как 409.
+func TestReceptionHandler_ConcurrentCreateConflictMapping(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const requests = 50
+	pvz_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
+
+	// ПВЗ без приёмок: первая приёмка открывается так же, как следующие
+	table := &receptionsTable{}
+	receptionUsecase := usecase.NewReceptionUsecase(&receptionsTx{table: table}, assignedEverywhere{}, usecase.DefaultPolicy(), usecase.NopEventRecorder{})
+
+	counts := createReceptionsConcurrently(delivery.NewReceptionHandler(receptionUsecase), user_id, pvz_id, requests)
+
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: requests - 1}, counts)
+	assert.Equal(t, 1, table.inProgress(pvz_id))
+}
+
+// TestReceptionHandler_ConcurrentCreatePostgres открывает приёмки в пустом
+// ПВЗ на настоящей базе: блокировать ещё нечего, и лишние приёмки отсекает
+// только индекс reception_one_in_progress_per_pvz. Запускается, если задана
+// PVZ_TEST_DATABASE_URL; к этой базе применяются миграции.
+func TestReceptionHandler_ConcurrentCreatePostgres(t *testing.T) {
+	dsn := os.Getenv("PVZ_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PVZ_TEST_DATABASE_URL is not set")
+	}
+	gin.SetMode(gin.TestMode)
+
+	const requests = 50
+	ctx := context.Background()
+
+	db, err := storage.OpenDB(dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	provider, err := migrations.NewProvider(db)
+	require.NoError(t, err)
+	_, err = provider.Up(ctx)
+	require.NoError(t, err)
+
+	user, err := storage.NewUsersStorage(db).CreateUser(ctx, uuid.Must(uuid.NewV4()).String()+"@example.com", "hash", "employee", entity.Actor{})
+	require.NoError(t, err)
+	pvz_id := uuid.Must(uuid.NewV4())
+	_, err = storage.NewPVZPostgresStorage(db).CreatePVZ(ctx, pvz_id, entity.Actor{UserID: user.ID}, "Москва", time.Now())
+	require.NoError(t, err)
+	assignments := storage.NewAssignmentsPostgresStorage(db)
+	require.NoError(t, assignments.AssignEmployee(ctx, user.ID, pvz_id))
+
+	receptionUsecase := usecase.NewReceptionUsecase(storage.NewTxManager(db), assignments, usecase.DefaultPolicy(), usecase.NopEventRecorder{})
+
+	counts := createReceptionsConcurrently(delivery.NewReceptionHandler(receptionUsecase), user.ID, pvz_id, requests)
+
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: requests - 1}, counts)
+
+	var inProgress int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM reception WHERE pvz_id = $1 AND status_name = 'in_progress'", pvz_id).Scan(&inProgress)
+	require.NoError(t, err)
+	assert.Equal(t, 1, inProgress)
+}
+
+// createReceptionsConcurrently отправляет requests одновременных запросов
+// на открытие приёмки и возвращает число ответов по статусам.
+func createReceptionsConcurrently(handler *delivery.ReceptionHandler, user_id, pvz_id uuid.UUID, requests int) map[int]int {
+	router := gin.New()
+	router.Use(middlewares.ErrorHandler())
+	router.POST("/receptions", func(ctx *gin.Context) {
+		ctx.Set("userID", user_id.String())
+		ctx.Set("role", "employee")
+	}, middlewares.RequirePermission(usecase.DefaultPolicy(), usecase.PermReceptionOpen), handler.Reception)
+
+	body := []byte(`{"pvzId": "` + pvz_id.String() + `"}`)
+	start := make(chan struct{})
+	codes := make(chan int, requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			req, _ := http.NewRequest(http.MethodPost, "/receptions", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			codes <- w.Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	return counts
+}
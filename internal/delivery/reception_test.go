@@ -2,13 +2,16 @@ package delivery_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
 	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
 	"testing"
 	"time"
 
@@ -22,19 +25,20 @@ type MockReceptionUsecase struct {
 	mock.Mock
 }
 
-func (m *MockReceptionUsecase) CreateReception(id uuid.UUID) (*entity.Receptions, error) {
-	args := m.Called(id)
+func (m *MockReceptionUsecase) CreateReception(ctx context.Context, actor entity.Actor, id uuid.UUID) (*entity.Receptions, error) {
+	args := m.Called(ctx, actor, id)
 	return args.Get(0).(*entity.Receptions), args.Error(1)
 }
 
-func (m *MockReceptionUsecase) UpdateReceptionStatus(pvz_id uuid.UUID) (*entity.Receptions, error) {
-	args := m.Called(pvz_id)
+func (m *MockReceptionUsecase) UpdateReceptionStatus(ctx context.Context, actor entity.Actor, pvz_id uuid.UUID) (*entity.Receptions, error) {
+	args := m.Called(ctx, actor, pvz_id)
 	return args.Get(0).(*entity.Receptions), args.Error(1)
 }
 
 func TestReceptionHandler(t *testing.T) {
 	receptionID := uuid.Must(uuid.NewV4())
 	pvzID := uuid.Must(uuid.NewV4())
+	userID := uuid.Must(uuid.NewV4())
 	date := time.Now()
 	gin.SetMode(gin.TestMode)
 
@@ -53,7 +57,7 @@ func TestReceptionHandler(t *testing.T) {
 				"pvzId": pvzID.String(),
 			},
 			mock: func(m *MockReceptionUsecase) {
-				m.On("CreateReception", pvzID).Return(&entity.Receptions{
+				m.On("CreateReception", context.Background(), actorWithUser(userID), pvzID).Return(&entity.Receptions{
 					ID:       receptionID,
 					DateTime: date,
 					PVZID:    pvzID,
@@ -101,9 +105,33 @@ func TestReceptionHandler(t *testing.T) {
 				"pvzId": pvzID.String(),
 			},
 			mock: func(m *MockReceptionUsecase) {
-				m.On("CreateReception", pvzID).Return(&entity.Receptions{}, errors.New("no available receptions"))
+				m.On("CreateReception", context.Background(), actorWithUser(userID), pvzID).Return(&entity.Receptions{}, usecase.ErrReceptionInProgress)
 			},
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusConflict,
+			expectedBody: gin.H{},
+		},
+		{
+			name: "usecase error",
+			role: "employee",
+			requestBody: map[string]any{
+				"pvzId": pvzID.String(),
+			},
+			mock: func(m *MockReceptionUsecase) {
+				m.On("CreateReception", context.Background(), actorWithUser(userID), pvzID).Return(&entity.Receptions{}, errors.New("failed to check reception status"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: gin.H{},
+		},
+		{
+			name: "employee is not assigned to pvz",
+			role: "employee",
+			requestBody: map[string]any{
+				"pvzId": pvzID.String(),
+			},
+			mock: func(m *MockReceptionUsecase) {
+				m.On("CreateReception", context.Background(), actorWithUser(userID), pvzID).Return(&entity.Receptions{}, usecase.ErrNotAssigned)
+			},
+			expectedCode: http.StatusForbidden,
 			expectedBody: gin.H{},
 		},
 	}
@@ -116,10 +144,11 @@ func TestReceptionHandler(t *testing.T) {
 			handler := delivery.NewReceptionHandler(mockUsecase)
 
 			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
 			router.POST("/receptions", func(ctx *gin.Context) {
+				ctx.Set("userID", userID.String())
 				ctx.Set("role", tt.role)
-				handler.Reception(ctx)
-			})
+			}, middlewares.RequirePermission(usecase.DefaultPolicy(), usecase.PermReceptionOpen), handler.Reception)
 
 			body, _ := json.Marshal(tt.requestBody)
 			req, _ := http.NewRequest(http.MethodPost, "/receptions", bytes.NewBuffer(body))
@@ -152,6 +181,7 @@ func TestReceptionHandler(t *testing.T) {
 func TestUpdateReceptionHandler(t *testing.T) {
 	receptionID := uuid.Must(uuid.NewV4())
 	pvzID := uuid.Must(uuid.NewV4())
+	userID := uuid.Must(uuid.NewV4())
 	date := time.Now()
 	gin.SetMode(gin.TestMode)
 
@@ -170,7 +200,7 @@ func TestUpdateReceptionHandler(t *testing.T) {
 			role:        "employee",
 			requestBody: nil,
 			mock: func(m *MockReceptionUsecase) {
-				m.On("UpdateReceptionStatus", pvzID).Return(&entity.Receptions{
+				m.On("UpdateReceptionStatus", context.Background(), actorWithUser(userID), pvzID).Return(&entity.Receptions{
 					ID:       receptionID,
 					DateTime: date,
 					PVZID:    pvzID,
@@ -209,10 +239,21 @@ func TestUpdateReceptionHandler(t *testing.T) {
 			role:        "employee",
 			requestBody: nil,
 			mock: func(m *MockReceptionUsecase) {
-				m.On("UpdateReceptionStatus", pvzID).Return(&entity.Receptions{}, errors.New("no available receptions"))
+				m.On("UpdateReceptionStatus", context.Background(), actorWithUser(userID), pvzID).Return(&entity.Receptions{}, usecase.ErrNoOpenReception)
 			},
 			expectedCode: http.StatusBadRequest,
-			expectedBody: gin.H{"error": "no available receptions"},
+			expectedBody: gin.H{},
+		},
+		{
+			name:        "employee is not assigned to pvz",
+			param:       pvzID,
+			role:        "employee",
+			requestBody: nil,
+			mock: func(m *MockReceptionUsecase) {
+				m.On("UpdateReceptionStatus", context.Background(), actorWithUser(userID), pvzID).Return(&entity.Receptions{}, usecase.ErrNotAssigned)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: gin.H{},
 		},
 	}
 
@@ -224,10 +265,11 @@ func TestUpdateReceptionHandler(t *testing.T) {
 			handler := delivery.NewReceptionHandler(mockUsecase)
 
 			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
 			router.POST("/pvz/:pvzId/close_last_reception", func(ctx *gin.Context) {
+				ctx.Set("userID", userID.String())
 				ctx.Set("role", tt.role)
-				handler.UpdateReceptionStatus(ctx)
-			})
+			}, middlewares.RequirePermission(usecase.DefaultPolicy(), usecase.PermReceptionClose), handler.UpdateReceptionStatus)
 
 			body, _ := json.Marshal(tt.requestBody)
 			req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/pvz/%v/close_last_reception", tt.param), bytes.NewBuffer(body))
@@ -0,0 +1,40 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefreshHandler struct {
+	refreshUsecase usecase.UserUsecase
+}
+
+func NewRefreshHandler(refreshUsecase usecase.UserUsecase) *RefreshHandler {
+	return &RefreshHandler{refreshUsecase: refreshUsecase}
+}
+
+func (h *RefreshHandler) Refresh(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if input.RefreshToken == "" {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	tokens, err := h.refreshUsecase.Refresh(c.Request.Context(), input.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
@@ -23,18 +23,13 @@ func (h *RegisterHandler) Register(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		c.Error(errInvalidRequest)
 		return
 	}
 
-	if input.Email == "" || input.Password == "" || input.Role == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
-		return
-	}
-
-	id, err := h.registerUsecase.Register(input.Email, input.Password, input.Role)
+	id, err := h.registerUsecase.Register(c.Request.Context(), requestActor(c), input.Email, input.Password, input.Role)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
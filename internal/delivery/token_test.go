@@ -0,0 +1,186 @@
+package delivery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
+	"pvz/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name         string
+		requestBody  any
+		mock         func(*MockUserUsecase)
+		expectedCode int
+	}{
+		{
+			name: "successful refresh",
+			requestBody: map[string]string{
+				"refreshToken": "refresh_token",
+			},
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Refresh", context.Background(), "refresh_token").Return(&usecase.TokenPair{AccessToken: "token", RefreshToken: "new_refresh_token"}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "revoked refresh token",
+			requestBody: map[string]string{
+				"refreshToken": "refresh_token",
+			},
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Refresh", context.Background(), "refresh_token").Return((*usecase.TokenPair)(nil), usecase.ErrInvalidRefreshToken)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "empty refresh token",
+			requestBody:  map[string]string{},
+			mock:         func(muu *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid json",
+			requestBody:  "json",
+			mock:         func(muu *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockUserUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewRefreshHandler(mockUsecase)
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.POST("/token/refresh", handler.Refresh)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewBuffer(body))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedCode == http.StatusOK {
+				var response map[string]string
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, "token", response["token"])
+				assert.Equal(t, "new_refresh_token", response["refreshToken"])
+			}
+
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user_id := uuid.Must(uuid.NewV4())
+	jti := uuid.Must(uuid.NewV4())
+	exp := time.Unix(time.Now().Add(time.Minute).Unix(), 0)
+
+	tests := []struct {
+		name         string
+		jti          string
+		requestBody  any
+		mock         func(*MockUserUsecase)
+		expectedCode int
+	}{
+		{
+			name: "successful logout",
+			jti:  jti.String(),
+			requestBody: map[string]string{
+				"refreshToken": "refresh_token",
+			},
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Logout", context.Background(), user_id, jti, exp, "refresh_token").Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:        "logout without refresh token",
+			jti:         jti.String(),
+			requestBody: nil,
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Logout", context.Background(), user_id, jti, exp, "").Return(nil)
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name: "unknown refresh token",
+			jti:  jti.String(),
+			requestBody: map[string]string{
+				"refreshToken": "unknown",
+			},
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Logout", context.Background(), user_id, jti, exp, "unknown").Return(usecase.ErrInvalidRefreshToken)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "storage error",
+			jti:         jti.String(),
+			requestBody: nil,
+			mock: func(muu *MockUserUsecase) {
+				muu.On("Logout", context.Background(), user_id, jti, exp, "").Return(errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:         "bad token id",
+			jti:          "123",
+			requestBody:  nil,
+			mock:         func(muu *MockUserUsecase) {},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockUserUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewLogoutHandler(mockUsecase)
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.POST("/logout", func(ctx *gin.Context) {
+				ctx.Set("userID", user_id.String())
+				ctx.Set("jti", tt.jti)
+				ctx.Set("tokenExp", exp)
+				handler.Logout(ctx)
+			})
+
+			var body []byte
+			if tt.requestBody != nil {
+				body, _ = json.Marshal(tt.requestBody)
+			}
+			req, _ := http.NewRequest(http.MethodPost, "/logout", bytes.NewBuffer(body))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
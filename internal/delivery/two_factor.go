@@ -0,0 +1,62 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	twoFactorUsecase usecase.TwoFactorUsecase
+}
+
+func NewTwoFactorHandler(twoFactorUsecase usecase.TwoFactorUsecase) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorUsecase: twoFactorUsecase}
+}
+
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	user_id, err := userIDFromContext(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	enrollment, err := h.twoFactorUsecase.Enroll(c.Request.Context(), user_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	var input struct {
+		Code string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if input.Code == "" {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	user_id, err := userIDFromContext(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	codes, err := h.twoFactorUsecase.Verify(c.Request.Context(), user_id, input.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
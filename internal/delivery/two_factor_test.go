@@ -0,0 +1,78 @@
+package delivery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyTwoFactorHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  any
+		mock         func(*MockUserUsecase)
+		expectedCode int
+	}{
+		{
+			name:        "succesful verification",
+			requestBody: map[string]string{"challengeToken": "challenge", "code": "123456"},
+			mock: func(muu *MockUserUsecase) {
+				muu.On("VerifyLogin", context.Background(), "challenge", "123456").Return(&usecase.TokenPair{AccessToken: "token", RefreshToken: "refresh"}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:        "wrong code",
+			requestBody: map[string]string{"challengeToken": "challenge", "code": "000000"},
+			mock: func(muu *MockUserUsecase) {
+				muu.On("VerifyLogin", context.Background(), "challenge", "000000").Return((*usecase.TokenPair)(nil), usecase.ErrInvalidTwoFactorCode)
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:        "too many attempts",
+			requestBody: map[string]string{"challengeToken": "challenge", "code": "000000"},
+			mock: func(muu *MockUserUsecase) {
+				muu.On("VerifyLogin", context.Background(), "challenge", "000000").Return((*usecase.TokenPair)(nil), usecase.ErrTooManyAttempts)
+			},
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			name:         "missing code",
+			requestBody:  map[string]string{"challengeToken": "challenge"},
+			mock:         func(muu *MockUserUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockUserUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewLoginHandler(mockUsecase, &MockLoginAttemptsUsecase{})
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.POST("/login/2fa", handler.VerifyTwoFactor)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest(http.MethodPost, "/login/2fa", bytes.NewBuffer(body))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
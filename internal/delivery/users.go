@@ -0,0 +1,132 @@
+package delivery
+
+import (
+	"net/http"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+)
+
+type UsersHandler struct {
+	usersUsecase usecase.UserManagementUsecase
+}
+
+func NewUsersHandler(usersUsecase usecase.UserManagementUsecase) *UsersHandler {
+	return &UsersHandler{usersUsecase: usersUsecase}
+}
+
+func (h *UsersHandler) ListUsers(c *gin.Context) {
+	var filter entity.UserFilter
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(invalidQuery("page"))
+		return
+	}
+	filter.Page = page
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.Error(invalidQuery("limit"))
+		return
+	}
+	filter.Limit = limit
+	filter.Role = c.Query("role")
+
+	response, err := h.usersUsecase.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *UsersHandler) GetUser(c *gin.Context) {
+	user_id, err := uuid.FromString(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	user, err := h.usersUsecase.GetUser(c.Request.Context(), user_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UsersHandler) ChangeRole(c *gin.Context) {
+	user_id, err := uuid.FromString(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	if input.Role == "" {
+		c.Error(errInvalidRequest)
+		return
+	}
+
+	user, err := h.usersUsecase.ChangeRole(c.Request.Context(), requestActor(c), user_id, input.Role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UsersHandler) DisableUser(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+func (h *UsersHandler) EnableUser(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h *UsersHandler) setDisabled(c *gin.Context, disabled bool) {
+	user_id, err := uuid.FromString(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	user, err := h.usersUsecase.SetDisabled(c.Request.Context(), requestActor(c), user_id, disabled)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UsersHandler) DeleteUser(c *gin.Context) {
+	user_id, err := uuid.FromString(c.Param("userId"))
+	if err != nil {
+		c.Error(errInvalidPath)
+		return
+	}
+
+	err = h.usersUsecase.DeleteUser(c.Request.Context(), requestActor(c), user_id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
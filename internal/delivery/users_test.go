@@ -0,0 +1,229 @@
+package delivery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pvz/internal/delivery"
+	"pvz/internal/delivery/middlewares"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserManagementUsecase struct {
+	mock.Mock
+}
+
+func (m *MockUserManagementUsecase) ListUsers(ctx context.Context, filter entity.UserFilter) (*usecase.UserListResponse, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(*usecase.UserListResponse), args.Error(1)
+}
+
+func (m *MockUserManagementUsecase) GetUser(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserManagementUsecase) ChangeRole(ctx context.Context, actor entity.Actor, id uuid.UUID, role string) (*entity.User, error) {
+	args := m.Called(ctx, actor, id, role)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserManagementUsecase) SetDisabled(ctx context.Context, actor entity.Actor, id uuid.UUID, disabled bool) (*entity.User, error) {
+	args := m.Called(ctx, actor, id, disabled)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserManagementUsecase) DeleteUser(ctx context.Context, actor entity.Actor, id uuid.UUID) error {
+	args := m.Called(ctx, actor, id)
+	return args.Error(0)
+}
+
+func TestListUsersHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		role         string
+		queryParams  string
+		mock         func(*MockUserManagementUsecase)
+		expectedCode int
+	}{
+		{
+			name:        "succesful list",
+			role:        "moderator",
+			queryParams: "page=2&limit=5&role=employee",
+			mock: func(m *MockUserManagementUsecase) {
+				m.On("ListUsers", context.Background(), entity.UserFilter{Role: "employee", Page: 2, Limit: 5}).
+					Return(&usecase.UserListResponse{Users: []entity.User{}, Page: 2, Limit: 5}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "wrong role",
+			role:         "employee",
+			mock:         func(m *MockUserManagementUsecase) {},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "invalid limit",
+			role:         "moderator",
+			queryParams:  "limit=1000",
+			mock:         func(m *MockUserManagementUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "unknown role filter",
+			role:        "moderator",
+			queryParams: "role=courier",
+			mock: func(m *MockUserManagementUsecase) {
+				m.On("ListUsers", context.Background(), entity.UserFilter{Role: "courier", Page: 1, Limit: 10}).
+					Return((*usecase.UserListResponse)(nil), usecase.ErrUnknownRole)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockUserManagementUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewUsersHandler(mockUsecase)
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.GET("/users", func(ctx *gin.Context) {
+				ctx.Set("role", tt.role)
+				ctx.Set("mfa", true)
+			}, middlewares.RequirePermission(usecase.DefaultPolicy(), usecase.PermUserRead), handler.ListUsers)
+
+			req, _ := http.NewRequest(http.MethodGet, "/users?"+tt.queryParams, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestChangeRoleHandler(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	moderator_id := uuid.Must(uuid.NewV4())
+
+	tests := []struct {
+		name         string
+		userID       string
+		requestBody  any
+		mock         func(*MockUserManagementUsecase)
+		expectedCode int
+	}{
+		{
+			name:        "succesful change",
+			userID:      user_id.String(),
+			requestBody: map[string]any{"role": "moderator"},
+			mock: func(m *MockUserManagementUsecase) {
+				m.On("ChangeRole", context.Background(), actorWithUser(moderator_id), user_id, "moderator").Return(&entity.User{ID: user_id, Role: "moderator"}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "bad user id",
+			userID:       "123",
+			requestBody:  map[string]any{"role": "moderator"},
+			mock:         func(m *MockUserManagementUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "empty role",
+			userID:       user_id.String(),
+			requestBody:  map[string]any{},
+			mock:         func(m *MockUserManagementUsecase) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "user not found",
+			userID:      user_id.String(),
+			requestBody: map[string]any{"role": "employee"},
+			mock: func(m *MockUserManagementUsecase) {
+				m.On("ChangeRole", context.Background(), actorWithUser(moderator_id), user_id, "employee").Return((*entity.User)(nil), usecase.ErrUserNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockUserManagementUsecase{}
+			tt.mock(mockUsecase)
+
+			handler := delivery.NewUsersHandler(mockUsecase)
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.PATCH("/users/:userId/role", func(ctx *gin.Context) {
+				ctx.Set("userID", moderator_id.String())
+			}, handler.ChangeRole)
+
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest(http.MethodPatch, "/users/"+tt.userID+"/role", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteUserHandler(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	moderator_id := uuid.Must(uuid.NewV4())
+
+	tests := []struct {
+		name         string
+		deleteError  error
+		expectedCode int
+	}{
+		{
+			name:         "succesful delete",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "user has pvz",
+			deleteError:  usecase.ErrUserInUse,
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase := &MockUserManagementUsecase{}
+			mockUsecase.On("DeleteUser", context.Background(), actorWithUser(moderator_id), user_id).Return(tt.deleteError)
+
+			handler := delivery.NewUsersHandler(mockUsecase)
+
+			router := gin.Default()
+			router.Use(middlewares.ErrorHandler())
+			router.DELETE("/users/:userId", func(ctx *gin.Context) {
+				ctx.Set("userID", moderator_id.String())
+			}, handler.DeleteUser)
+
+			req, _ := http.NewRequest(http.MethodDelete, "/users/"+user_id.String(), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package logging передаёт slog.Logger через контекст, чтобы записи из
+// usecase и хранилищ несли request_id запроса, в рамках которого сделаны.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type contextKey struct{}
+
+// WithContext возвращает контекст с логгером logger.
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext возвращает логгер запроса или slog.Default(), если его нет.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// QueryHook подходит для storage.OpenDB: на уровне debug пишет каждый
+// запрос к базе логгером запроса.
+func QueryHook(ctx context.Context, name, query string) (context.Context, func(err error)) {
+	logger := FromContext(ctx)
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return ctx, func(error) {}
+	}
+
+	start := time.Now()
+	return ctx, func(err error) {
+		attrs := []any{slog.String("query", name), slog.Duration("latency", time.Since(start))}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+		logger.DebugContext(ctx, "sql query", attrs...)
+	}
+}
@@ -0,0 +1,66 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"pvz/internal/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), logging.FromContext(context.Background()))
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	ctx := logging.WithContext(context.Background(), logger)
+	assert.Same(t, logger, logging.FromContext(ctx))
+}
+
+func TestQueryHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    slog.Level
+		err      error
+		expected []string
+	}{
+		{
+			name:     "debug",
+			level:    slog.LevelDebug,
+			expected: []string{"request_id=req-1", `msg="sql query"`, `query="select pvz"`, "latency="},
+		},
+		{
+			name:     "query error",
+			level:    slog.LevelDebug,
+			err:      errors.New("deadlock detected"),
+			expected: []string{`error="deadlock detected"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: tt.level})).With("request_id", "req-1")
+			ctx := logging.WithContext(context.Background(), logger)
+
+			_, done := logging.QueryHook(ctx, "select pvz", "SELECT * FROM pvz")
+			done(tt.err)
+
+			for _, part := range tt.expected {
+				assert.Contains(t, out.String(), part)
+			}
+		})
+	}
+
+	t.Run("info level skips queries", func(t *testing.T) {
+		var out bytes.Buffer
+		ctx := logging.WithContext(context.Background(), slog.New(slog.NewTextHandler(&out, nil)))
+
+		_, done := logging.QueryHook(ctx, "select pvz", "SELECT * FROM pvz")
+		done(nil)
+
+		assert.Empty(t, out.String())
+	})
+}
@@ -0,0 +1,125 @@
+// Package metrics собирает метрики Prometheus: HTTP запросы, запросы к базе,
+// состояние пула соединений и бизнес-события.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pvz"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+	pvzCreated    *prometheus.CounterVec
+	receptions    *prometheus.CounterVec
+	products      *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by query name and result.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"query", "result"}),
+		pvzCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pvz_created_total",
+			Help:      "PVZs created by city.",
+		}, []string{"city"}),
+		receptions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "receptions_total",
+			Help:      "Receptions opened and closed by city.",
+		}, []string{"city", "event"}),
+		products: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "products_total",
+			Help:      "Products added and deleted by city and product type.",
+		}, []string{"city", "type", "event"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.queryDuration,
+		m.pvzCreated,
+		m.receptions,
+		m.products,
+	)
+	return m
+}
+
+// RegisterDB добавляет статистику пула соединений из sql.DB.Stats().
+func (m *Metrics) RegisterDB(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration) {
+	labels := prometheus.Labels{"route": route, "method": method, "status": strconv.Itoa(status)}
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// QueryHook подходит для storage.OpenDB.
+func (m *Metrics) QueryHook(ctx context.Context, name, query string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+		m.queryDuration.WithLabelValues(name, result).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) PVZCreated(city string) {
+	m.pvzCreated.WithLabelValues(city).Inc()
+}
+
+func (m *Metrics) ReceptionOpened(city string) {
+	m.receptions.WithLabelValues(city, "opened").Inc()
+}
+
+func (m *Metrics) ReceptionClosed(city string) {
+	m.receptions.WithLabelValues(city, "closed").Inc()
+}
+
+func (m *Metrics) ProductAdded(city, productType string) {
+	m.products.WithLabelValues(city, productType, "added").Inc()
+}
+
+func (m *Metrics) ProductDeleted(city, productType string) {
+	m.products.WithLabelValues(city, productType, "deleted").Inc()
+}
@@ -0,0 +1,61 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"pvz/internal/metrics"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+
+	m.ObserveRequest("/pvz", "POST", 201, 30*time.Millisecond)
+	m.ObserveRequest("/pvz", "POST", 201, 10*time.Millisecond)
+	m.PVZCreated("Москва")
+	m.ReceptionOpened("Казань")
+	m.ReceptionClosed("Казань")
+	m.ProductAdded("Казань", "обувь")
+	m.ProductDeleted("Казань", "обувь")
+
+	_, done := m.QueryHook(context.Background(), "select pvz", "SELECT * FROM pvz")
+	done(nil)
+	_, done = m.QueryHook(context.Background(), "insert reception", "INSERT INTO reception")
+	done(errors.New("unique violation"))
+
+	out := scrape(t, m)
+
+	for _, line := range []string{
+		`pvz_http_requests_total{method="POST",route="/pvz",status="201"} 2`,
+		`pvz_http_request_duration_seconds_count{method="POST",route="/pvz",status="201"} 2`,
+		`pvz_pvz_created_total{city="Москва"} 1`,
+		`pvz_receptions_total{city="Казань",event="opened"} 1`,
+		`pvz_receptions_total{city="Казань",event="closed"} 1`,
+		`pvz_products_total{city="Казань",event="added",type="обувь"} 1`,
+		`pvz_products_total{city="Казань",event="deleted",type="обувь"} 1`,
+		`pvz_db_query_duration_seconds_count{query="select pvz",result="ok"} 1`,
+		`pvz_db_query_duration_seconds_count{query="insert reception",result="error"} 1`,
+		`go_goroutines`,
+	} {
+		assert.Contains(t, out, line)
+	}
+}
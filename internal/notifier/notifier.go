@@ -0,0 +1,69 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notifier доставляет пользователю служебные сообщения.
+type Notifier interface {
+	SendPasswordReset(email, token string, expiresAt time.Time) error
+}
+
+type Message struct {
+	Kind      string    `json:"kind"`
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// LogNotifier пишет сообщения в лог процесса. Подходит только для разработки.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) SendPasswordReset(email, token string, expiresAt time.Time) error {
+	log.Printf("password reset for %s: token %s, expires at %s", email, token, expiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// FileNotifier дописывает сообщения в файл по одному JSON-объекту на строку.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) SendPasswordReset(email, token string, expiresAt time.Time) error {
+	return n.write(Message{Kind: "password_reset", Email: email, Token: token, ExpiresAt: expiresAt})
+}
+
+func (n *FileNotifier) write(message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
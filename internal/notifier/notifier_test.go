@@ -0,0 +1,39 @@
+package notifier_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"pvz/internal/notifier"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileNotifier_SendPasswordReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	n := notifier.NewFileNotifier(path)
+	expiresAt := time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, n.SendPasswordReset("first@example.com", "token-1", expiresAt))
+	require.NoError(t, n.SendPasswordReset("second@example.com", "token-2", expiresAt))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var messages []notifier.Message
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var message notifier.Message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &message))
+		messages = append(messages, message)
+	}
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, notifier.Message{Kind: "password_reset", Email: "first@example.com", Token: "token-1", ExpiresAt: expiresAt}, messages[0])
+	assert.Equal(t, "token-2", messages[1].Token)
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"pvz/internal/storage/migrations/entity"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
+)
+
+type APIKeysPostgresStorage interface {
+	CreateAPIKey(ctx context.Context, key entity.APIKey, actor entity.Actor) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID, actor entity.Actor) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID, used_at time.Time) error
+}
+
+type APIKeysPostgresStorageImpl struct {
+	db DBTX
+}
+
+func NewAPIKeysPostgresStorage(db DBTX) *APIKeysPostgresStorageImpl {
+	return &APIKeysPostgresStorageImpl{db: db}
+}
+
+const apiKeyColumns = "key_id, name, prefix, key_hash, user_id, created_by, permissions, pvz_ids, expires_at, revoked, created_at, last_used_at"
+
+// CreateAPIKey и RevokeAPIKey пишут ключ в аудит без хэша: у поля Hash
+// нет JSON-представления.
+func (a *APIKeysPostgresStorageImpl) CreateAPIKey(ctx context.Context, key entity.APIKey, actor entity.Actor) error {
+	query := `
+		INSERT INTO api_keys (key_id, name, prefix, key_hash, user_id, created_by, permissions, pvz_ids, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	return atomic(ctx, a.db, func(tx DBTX) error {
+		_, err := tx.ExecContext(ctx, query, key.ID, key.Name, key.Prefix, key.Hash, key.UserID, key.CreatedBy,
+			pq.Array(key.Permissions), pq.Array(uuidStrings(key.PVZIDs)), key.ExpiresAt, key.CreatedAt)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actor, AuditAPIKeyCreate, AuditEntityAPIKey, key.ID, nil, key)
+	})
+}
+
+func (a *APIKeysPostgresStorageImpl) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1"
+
+	return scanAPIKey(a.db.QueryRowContext(ctx, query, hash))
+}
+
+func (a *APIKeysPostgresStorageImpl) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys ORDER BY created_at DESC"
+
+	rows, err := a.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []entity.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (a *APIKeysPostgresStorageImpl) RevokeAPIKey(ctx context.Context, id uuid.UUID, actor entity.Actor) error {
+	selectQuery := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_id = $1 FOR UPDATE"
+	query := "UPDATE api_keys SET revoked = TRUE WHERE key_id = $1"
+
+	return atomic(ctx, a.db, func(tx DBTX) error {
+		before, err := scanAPIKey(tx.QueryRowContext(ctx, selectQuery, id))
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+
+		after := *before
+		after.Revoked = true
+		return recordAudit(ctx, tx, actor, AuditAPIKeyRevoke, AuditEntityAPIKey, id, before, after)
+	})
+}
+
+func (a *APIKeysPostgresStorageImpl) TouchAPIKey(ctx context.Context, id uuid.UUID, used_at time.Time) error {
+	query := "UPDATE api_keys SET last_used_at = $2 WHERE key_id = $1"
+
+	_, err := a.db.ExecContext(ctx, query, id, used_at)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var key entity.APIKey
+	var pvz_ids []string
+	var expires_at, last_used_at sql.NullTime
+
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &key.UserID, &key.CreatedBy,
+		pq.Array(&key.Permissions), pq.Array(&pvz_ids), &expires_at, &key.Revoked, &key.CreatedAt, &last_used_at)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range pvz_ids {
+		pvz_id, err := uuid.FromString(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pvz id %q: %w", id, err)
+		}
+		key.PVZIDs = append(key.PVZIDs, pvz_id)
+	}
+	if expires_at.Valid {
+		key.ExpiresAt = &expires_at.Time
+	}
+	if last_used_at.Valid {
+		key.LastUsedAt = &last_used_at.Time
+	}
+	return &key, nil
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, id.String())
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// auditStateWithout проверяет, что состояние в аудите не содержит секрета.
+type auditStateWithout string
+
+func (a auditStateWithout) Match(v driver.Value) bool {
+	state, ok := v.(string)
+	return ok && !strings.Contains(state, string(a))
+}
+
+func TestAPIKeysStorage_CreateAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	apiKeysStorage := storage.NewAPIKeysPostgresStorage(db)
+	admin_id := uuid.Must(uuid.NewV4())
+	key := entity.APIKey{
+		ID:          uuid.Must(uuid.NewV4()),
+		Name:        "integration",
+		Prefix:      "pvz_abcd",
+		Hash:        "secret-hash",
+		UserID:      uuid.Must(uuid.NewV4()),
+		CreatedBy:   admin_id,
+		Permissions: []string{"pvz:read"},
+		CreatedAt:   time.Now(),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO api_keys").
+		WithArgs(key.ID, key.Name, key.Prefix, key.Hash, key.UserID, key.CreatedBy, sqlmock.AnyArg(), sqlmock.AnyArg(), key.ExpiresAt, key.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(admin_id, storage.AuditAPIKeyCreate, storage.AuditEntityAPIKey, key.ID, nil, auditStateWithout("secret-hash"), "", "", nil, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = apiKeysStorage.CreateAPIKey(context.Background(), key, entity.Actor{UserID: admin_id})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeysStorage_RevokeAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	apiKeysStorage := storage.NewAPIKeysPostgresStorage(db)
+	admin_id := uuid.Must(uuid.NewV4())
+	key_id := uuid.Must(uuid.NewV4())
+	columns := []string{"key_id", "name", "prefix", "key_hash", "user_id", "created_by", "permissions", "pvz_ids", "expires_at", "revoked", "created_at", "last_used_at"}
+
+	tests := []struct {
+		name        string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT .* FROM api_keys WHERE key_id = \\$1 FOR UPDATE").
+					WithArgs(key_id).WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(key_id, "integration", "pvz_abcd", "secret-hash", admin_id, admin_id, "{pvz:read}", "{}", nil, false, time.Now(), nil))
+				mock.ExpectExec("UPDATE api_keys SET revoked = TRUE WHERE key_id = \\$1").
+					WithArgs(key_id).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(admin_id, storage.AuditAPIKeyRevoke, storage.AuditEntityAPIKey, key_id,
+						auditStateWithout("secret-hash"), auditStateWithout("secret-hash"), "", "", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "not found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT .* FROM api_keys WHERE key_id = \\$1 FOR UPDATE").
+					WithArgs(key_id).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := apiKeysStorage.RevokeAPIKey(context.Background(), key_id, entity.Actor{UserID: admin_id})
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
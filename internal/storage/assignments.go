@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type AssignmentsPostgresStorage interface {
+	AssignEmployee(ctx context.Context, user_id, pvz_id uuid.UUID) error
+	UnassignEmployee(ctx context.Context, user_id, pvz_id uuid.UUID) (bool, error)
+	IsAssigned(ctx context.Context, user_id, pvz_id uuid.UUID) (bool, error)
+}
+
+type AssignmentsPostgresStorageImpl struct {
+	db *sql.DB
+}
+
+func NewAssignmentsPostgresStorage(db *sql.DB) *AssignmentsPostgresStorageImpl {
+	return &AssignmentsPostgresStorageImpl{db: db}
+}
+
+func (a *AssignmentsPostgresStorageImpl) AssignEmployee(ctx context.Context, user_id, pvz_id uuid.UUID) error {
+	query := "INSERT INTO employee_pvz_assignments (user_id, pvz_id) VALUES ($1, $2) ON CONFLICT (user_id, pvz_id) DO NOTHING"
+
+	_, err := a.db.ExecContext(ctx, query, user_id, pvz_id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *AssignmentsPostgresStorageImpl) UnassignEmployee(ctx context.Context, user_id, pvz_id uuid.UUID) (bool, error) {
+	query := "DELETE FROM employee_pvz_assignments WHERE user_id = $1 AND pvz_id = $2"
+
+	res, err := a.db.ExecContext(ctx, query, user_id, pvz_id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (a *AssignmentsPostgresStorageImpl) IsAssigned(ctx context.Context, user_id, pvz_id uuid.UUID) (bool, error) {
+	var assigned bool
+	query := "SELECT EXISTS (SELECT 1 FROM employee_pvz_assignments WHERE user_id = $1 AND pvz_id = $2)"
+
+	err := a.db.QueryRowContext(ctx, query, user_id, pvz_id).Scan(&assigned)
+	if err != nil {
+		return false, err
+	}
+	return assigned, nil
+}
@@ -0,0 +1,91 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"pvz/internal/storage"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignmentsPostgresStorage_IsAssigned(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	pvz_id := uuid.Must(uuid.NewV4())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewAssignmentsPostgresStorage(db)
+
+	tests := []struct {
+		name        string
+		mock        func()
+		expected    bool
+		expectedErr error
+	}{
+		{
+			name: "assigned",
+			mock: func() {
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM employee_pvz_assignments WHERE user_id = \\$1 AND pvz_id = \\$2\\)").
+					WithArgs(user_id, pvz_id).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			expected: true,
+		},
+		{
+			name: "not assigned",
+			mock: func() {
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM employee_pvz_assignments WHERE user_id = \\$1 AND pvz_id = \\$2\\)").
+					WithArgs(user_id, pvz_id).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expected: false,
+		},
+		{
+			name: "db error",
+			mock: func() {
+				mock.ExpectQuery("SELECT EXISTS").
+					WithArgs(user_id, pvz_id).WillReturnError(errors.New("db error"))
+			},
+			expectedErr: errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			assigned, err := storage.IsAssigned(context.Background(), user_id, pvz_id)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expected, assigned)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAssignmentsPostgresStorage_AssignEmployee(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	pvz_id := uuid.Must(uuid.NewV4())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewAssignmentsPostgresStorage(db)
+
+	mock.ExpectExec("INSERT INTO employee_pvz_assignments").
+		WithArgs(user_id, pvz_id).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = storage.AssignEmployee(context.Background(), user_id, pvz_id)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"pvz/internal/storage/migrations/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const (
+	AuditEntityPVZ       = "pvz"
+	AuditEntityReception = "reception"
+	AuditEntityProduct   = "product"
+	AuditEntityUser      = "user"
+	AuditEntityAPIKey    = "api_key"
+
+	AuditPVZCreate          = "pvz.create"
+	AuditReceptionOpen      = "reception.open"
+	AuditReceptionClose     = "reception.close"
+	AuditProductCreate      = "product.create"
+	AuditProductDelete      = "product.delete"
+	AuditUserRegister       = "user.register"
+	AuditUserRoleChange     = "user.role_change"
+	AuditUserDisable        = "user.disable"
+	AuditUserEnable         = "user.enable"
+	AuditUserDelete         = "user.delete"
+	AuditUserPasswordChange = "user.password_change"
+	AuditUserPasswordReset  = "user.password_reset"
+	AuditAPIKeyCreate       = "api_key.create"
+	AuditAPIKeyRevoke       = "api_key.revoke"
+)
+
+const auditEventsSelection = "event_id, actor_id, api_key_id, api_key_created_by, action, entity_type, entity_id, before, after, request_id, ip, created_at"
+
+type AuditPostgresStorage interface {
+	ListEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error)
+	CountEvents(ctx context.Context, filter entity.AuditFilter) (int, error)
+}
+
+type AuditPostgresStorageImpl struct {
+	db *sql.DB
+}
+
+func NewAuditPostgresStorage(db *sql.DB) *AuditPostgresStorageImpl {
+	return &AuditPostgresStorageImpl{db: db}
+}
+
+const auditFilterCondition = `
+		WHERE ($1::uuid IS NULL OR actor_id = $1)
+			AND ($2 = '' OR entity_type = $2)
+			AND ($3::uuid IS NULL OR entity_id = $3)
+			AND ($4::timestamp IS NULL OR created_at >= $4)
+			AND ($5::timestamp IS NULL OR created_at < $5)
+`
+
+func (a *AuditPostgresStorageImpl) ListEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error) {
+	query := "SELECT " + auditEventsSelection + " FROM audit_events" + auditFilterCondition +
+		"ORDER BY created_at DESC, event_id DESC LIMIT $6 OFFSET $7"
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	rows, err := a.db.QueryContext(ctx, query, auditFilterArgs(filter, filter.Limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []entity.AuditEvent{}
+	for rows.Next() {
+		var event entity.AuditEvent
+		var actor_id, api_key_id, api_key_created_by uuid.NullUUID
+		var before, after []byte
+
+		err := rows.Scan(&event.ID, &actor_id, &api_key_id, &api_key_created_by, &event.Action, &event.EntityType, &event.EntityID,
+			&before, &after, &event.RequestID, &event.IP, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if actor_id.Valid {
+			event.ActorID = &actor_id.UUID
+		}
+		if api_key_id.Valid {
+			event.APIKeyID = &api_key_id.UUID
+			event.APIKeyCreatedBy = &api_key_created_by.UUID
+		}
+		event.Before = before
+		event.After = after
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return events, nil
+}
+
+func (a *AuditPostgresStorageImpl) CountEvents(ctx context.Context, filter entity.AuditFilter) (int, error) {
+	query := "SELECT COUNT(*) FROM audit_events" + auditFilterCondition
+
+	var count int
+	err := a.db.QueryRowContext(ctx, query, auditFilterArgs(filter)...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	return count, nil
+}
+
+func auditFilterArgs(filter entity.AuditFilter, extra ...any) []any {
+	args := []any{nullableUUID(filter.ActorID), filter.EntityType, nullableUUID(filter.EntityID), nil, nil}
+	if filter.From != nil {
+		args[3] = *filter.From
+	}
+	if filter.To != nil {
+		args[4] = *filter.To
+	}
+	return append(args, extra...)
+}
+
+func nullableUUID(id *uuid.UUID) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+// recordAudit пишет событие в той же транзакции, что и само изменение,
+// поэтому изменение без записи в журнале зафиксировать нельзя.
+func recordAudit(ctx context.Context, tx DBTX, actor entity.Actor, action, entityType string, entityID uuid.UUID, before, after any) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_events (actor_id, action, entity_type, entity_id, before, after, request_id, ip, api_key_id, api_key_created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err = tx.ExecContext(ctx, query, optionalUUID(actor.UserID), action, entityType, entityID, beforeJSON, afterJSON,
+		actor.RequestID, actor.IP, optionalUUID(actor.APIKeyID), optionalUUID(actor.APIKeyCreatedBy))
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// optionalUUID пишет пустой идентификатор как NULL.
+func optionalUUID(id uuid.UUID) any {
+	if id.IsNil() {
+		return nil
+	}
+	return id
+}
+
+func auditJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit state: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,55 @@
+package storage_test
+
+import (
+	"context"
+	"encoding/json"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditPostgresStorage_ListEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewAuditPostgresStorage(db)
+
+	actor_id := uuid.Must(uuid.NewV4())
+	key_id := uuid.Must(uuid.NewV4())
+	creator_id := uuid.Must(uuid.NewV4())
+	product_id := uuid.Must(uuid.NewV4())
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	created_at := from.Add(time.Hour)
+
+	filter := entity.AuditFilter{ActorID: &actor_id, EntityType: "product", From: &from, Page: 2, Limit: 10}
+
+	rows := sqlmock.NewRows([]string{"event_id", "actor_id", "api_key_id", "api_key_created_by", "action", "entity_type", "entity_id", "before", "after", "request_id", "ip", "created_at"}).
+		AddRow(int64(7), actor_id, key_id, creator_id, "product.delete", "product", product_id, []byte(`{"type":"обувь"}`), nil, "req-1", "10.0.0.1", created_at).
+		AddRow(int64(6), nil, nil, nil, "product.create", "product", product_id, nil, []byte(`{"type":"обувь"}`), "", "", created_at)
+	mock.ExpectQuery("SELECT event_id, actor_id, api_key_id, api_key_created_by, action, entity_type, entity_id, before, after, request_id, ip, created_at FROM audit_events WHERE .* LIMIT \\$6 OFFSET \\$7").
+		WithArgs(actor_id, "product", nil, from, nil, 10, 10).
+		WillReturnRows(rows)
+
+	events, err := storage.ListEvents(context.Background(), filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.AuditEvent{
+		{
+			ID: 7, ActorID: &actor_id, APIKeyID: &key_id, APIKeyCreatedBy: &creator_id, Action: "product.delete", EntityType: "product", EntityID: product_id,
+			Before: json.RawMessage(`{"type":"обувь"}`), RequestID: "req-1", IP: "10.0.0.1", CreatedAt: created_at,
+		},
+		{
+			ID: 6, Action: "product.create", EntityType: "product", EntityID: product_id,
+			After: json.RawMessage(`{"type":"обувь"}`), CreatedAt: created_at,
+		},
+	}, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
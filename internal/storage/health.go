@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+type HealthPostgresStorage interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int64, error)
+}
+
+type HealthPostgresStorageImpl struct {
+	db *sql.DB
+}
+
+func NewHealthPostgresStorage(db *sql.DB) *HealthPostgresStorageImpl {
+	return &HealthPostgresStorageImpl{db: db}
+}
+
+func (h *HealthPostgresStorageImpl) Ping(ctx context.Context) error {
+	return h.db.PingContext(ctx)
+}
+
+// SchemaVersion возвращает версию последней применённой миграции goose
+// или 0, если миграции ещё не применялись.
+func (h *HealthPostgresStorageImpl) SchemaVersion(ctx context.Context) (int64, error) {
+	query := "SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied"
+
+	var version int64
+	err := h.db.QueryRowContext(ctx, query).Scan(&version)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
@@ -0,0 +1,58 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"pvz/internal/storage"
+)
+
+func TestHealthStorage_SchemaVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewHealthPostgresStorage(db)
+
+	tests := []struct {
+		name        string
+		mock        func()
+		expected    int64
+		expectedErr error
+	}{
+		{
+			name: "applied migrations",
+			mock: func() {
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(version_id\\), 0\\) FROM goose_db_version").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(int64(20250509090000)))
+			},
+			expected: 20250509090000,
+		},
+		{
+			name: "no version table",
+			mock: func() {
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(version_id\\), 0\\) FROM goose_db_version").
+					WillReturnError(&pq.Error{Code: "42P01"})
+			},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			version, err := storage.SchemaVersion(context.Background())
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expected, version)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
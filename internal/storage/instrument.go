@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// QueryHook вызывается перед каждым запросом к базе. Возвращённая функция
+// вызывается после ответа базы с ошибкой запроса. name — короткое имя
+// запроса вида "select pvz", пригодное для метрик и трассировки.
+type QueryHook func(ctx context.Context, name, query string) (context.Context, func(err error))
+
+// OpenDB открывает пул соединений Postgres, в котором каждый запрос
+// проходит через hooks. Так запросы из хранилищ, транзакций и миграций
+// измеряются одинаково, без изменений в самих хранилищах.
+func OpenDB(dsn string, hooks ...QueryHook) (*sql.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&hookedConnector{Connector: connector, hooks: hooks}), nil
+}
+
+var queryTablePattern = regexp.MustCompile(`(?i)\b(?:from|into|update)\s+([a-z_][a-z0-9_.]*)`)
+
+// QueryName возвращает операцию и первую таблицу запроса.
+func QueryName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+
+	verb := strings.ToLower(fields[0])
+	if match := queryTablePattern.FindStringSubmatch(query); match != nil {
+		return verb + " " + strings.ToLower(match[1])
+	}
+	return verb
+}
+
+type hookedConnector struct {
+	driver.Connector
+	hooks []QueryHook
+}
+
+func (c *hookedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedConn{Conn: conn, hooks: c.hooks}, nil
+}
+
+// hookedConn перехватывает QueryContext и ExecContext, остальные методы
+// передаёт соединению pq без изменений.
+type hookedConn struct {
+	driver.Conn
+	hooks []QueryHook
+}
+
+func (c *hookedConn) before(ctx context.Context, query string) (context.Context, func(error)) {
+	name := QueryName(query)
+	afters := make([]func(error), 0, len(c.hooks))
+	for _, hook := range c.hooks {
+		var after func(error)
+		ctx, after = hook(ctx, name, query)
+		afters = append(afters, after)
+	}
+	return ctx, func(err error) {
+		for i := len(afters) - 1; i >= 0; i-- {
+			afters[i](err)
+		}
+	}
+}
+
+func (c *hookedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, after := c.before(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	after(err)
+	return rows, err
+}
+
+func (c *hookedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, after := c.before(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	after(err)
+	return result, err
+}
+
+func (c *hookedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *hookedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *hookedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *hookedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
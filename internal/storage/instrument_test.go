@@ -0,0 +1,30 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"pvz/internal/storage"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "SELECT id, city FROM pvz WHERE id = $1", expected: "select pvz"},
+		{query: "\n\t\tINSERT INTO reception (pvz_id) VALUES ($1)", expected: "insert reception"},
+		{query: "UPDATE reception SET status = 'close' WHERE id = $1", expected: "update reception"},
+		{query: "DELETE FROM products WHERE id = $1 RETURNING type", expected: "delete products"},
+		{query: "WITH last AS (SELECT id FROM products) DELETE FROM products", expected: "with products"},
+		{query: "SELECT 1", expected: "select"},
+		{query: "  ", expected: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, storage.QueryName(tt.query))
+		})
+	}
+}
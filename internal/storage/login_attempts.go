@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"pvz/internal/storage/migrations/entity"
+	"sync"
+	"time"
+)
+
+// LoginThrottleStorage хранит счётчики неудачных входов по ключу
+// (учётная запись или IP-адрес).
+type LoginThrottleStorage interface {
+	GetThrottle(ctx context.Context, key string) (*entity.LoginThrottle, error)
+	IncrementFailures(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	LockUntil(ctx context.Context, key string, until time.Time) error
+	ResetThrottle(ctx context.Context, key string) error
+}
+
+type LoginAttemptsPostgresStorage interface {
+	LoginThrottleStorage
+	RecordAttempt(ctx context.Context, attempt entity.LoginAttempt) error
+	ListAttempts(ctx context.Context, filter entity.LoginAttemptFilter) ([]entity.LoginAttempt, error)
+}
+
+type LoginAttemptsPostgresStorageImpl struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptsPostgresStorage(db *sql.DB) *LoginAttemptsPostgresStorageImpl {
+	return &LoginAttemptsPostgresStorageImpl{db: db}
+}
+
+func (l *LoginAttemptsPostgresStorageImpl) GetThrottle(ctx context.Context, key string) (*entity.LoginThrottle, error) {
+	var throttle entity.LoginThrottle
+	var locked_until sql.NullTime
+	query := "SELECT throttle_key, failures, last_failure_at, locked_until FROM login_throttle WHERE throttle_key = $1"
+
+	err := l.db.QueryRowContext(ctx, query, key).Scan(&throttle.Key, &throttle.Failures, &throttle.LastFailureAt, &locked_until)
+	if err != nil {
+		return nil, err
+	}
+	throttle.LockedUntil = locked_until.Time
+	return &throttle, nil
+}
+
+// IncrementFailures начинает отсчёт заново, если предыдущая неудача была
+// раньше, чем window назад.
+func (l *LoginAttemptsPostgresStorageImpl) IncrementFailures(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	query := `
+		INSERT INTO login_throttle (throttle_key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (throttle_key) DO UPDATE SET
+			failures = CASE WHEN login_throttle.last_failure_at < $3 THEN 1 ELSE login_throttle.failures + 1 END,
+			last_failure_at = $2
+		RETURNING failures
+	`
+
+	var failures int
+	err := l.db.QueryRowContext(ctx, query, key, now, now.Add(-window)).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+	return failures, nil
+}
+
+func (l *LoginAttemptsPostgresStorageImpl) LockUntil(ctx context.Context, key string, until time.Time) error {
+	query := "UPDATE login_throttle SET locked_until = $2 WHERE throttle_key = $1"
+
+	_, err := l.db.ExecContext(ctx, query, key, until)
+	return err
+}
+
+func (l *LoginAttemptsPostgresStorageImpl) ResetThrottle(ctx context.Context, key string) error {
+	query := "DELETE FROM login_throttle WHERE throttle_key = $1"
+
+	_, err := l.db.ExecContext(ctx, query, key)
+	return err
+}
+
+func (l *LoginAttemptsPostgresStorageImpl) RecordAttempt(ctx context.Context, attempt entity.LoginAttempt) error {
+	query := "INSERT INTO login_attempts (attempt_id, email, ip, success, attempted_at) VALUES ($1, $2, $3, $4, $5)"
+
+	_, err := l.db.ExecContext(ctx, query, attempt.ID, attempt.Email, attempt.IP, attempt.Success, attempt.AttemptedAt)
+	return err
+}
+
+func (l *LoginAttemptsPostgresStorageImpl) ListAttempts(ctx context.Context, filter entity.LoginAttemptFilter) ([]entity.LoginAttempt, error) {
+	query := `
+		SELECT attempt_id, email, ip, success, attempted_at
+		FROM login_attempts
+		WHERE ($1 = '' OR email = $1)
+			AND ($2 = '' OR ip = $2)
+			AND (NOT $3 OR success = FALSE)
+		ORDER BY attempted_at DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	rows, err := l.db.QueryContext(ctx, query, filter.Email, filter.IP, filter.FailedOnly, filter.Limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query login attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []entity.LoginAttempt{}
+	for rows.Next() {
+		var attempt entity.LoginAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.Email, &attempt.IP, &attempt.Success, &attempt.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// MemoryLoginThrottleStorage держит счётчики в памяти процесса.
+// Используется, когда база недоступна.
+type MemoryLoginThrottleStorage struct {
+	mu        sync.Mutex
+	throttles map[string]entity.LoginThrottle
+}
+
+func NewMemoryLoginThrottleStorage() *MemoryLoginThrottleStorage {
+	return &MemoryLoginThrottleStorage{throttles: make(map[string]entity.LoginThrottle)}
+}
+
+func (m *MemoryLoginThrottleStorage) GetThrottle(ctx context.Context, key string) (*entity.LoginThrottle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	throttle, ok := m.throttles[key]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &throttle, nil
+}
+
+func (m *MemoryLoginThrottleStorage) IncrementFailures(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	throttle, ok := m.throttles[key]
+	if !ok || throttle.LastFailureAt.Before(now.Add(-window)) {
+		throttle = entity.LoginThrottle{Key: key, LockedUntil: throttle.LockedUntil}
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	m.throttles[key] = throttle
+	return throttle.Failures, nil
+}
+
+func (m *MemoryLoginThrottleStorage) LockUntil(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if throttle, ok := m.throttles[key]; ok {
+		throttle.LockedUntil = until
+		m.throttles[key] = throttle
+	}
+	return nil
+}
+
+func (m *MemoryLoginThrottleStorage) ResetThrottle(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.throttles, key)
+	return nil
+}
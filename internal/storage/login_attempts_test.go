@@ -0,0 +1,57 @@
+package storage_test
+
+import (
+	"context"
+	"pvz/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptsPostgresStorage_IncrementFailures(t *testing.T) {
+	now := time.Now()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewLoginAttemptsPostgresStorage(db)
+
+	rows := sqlmock.NewRows([]string{"failures"}).AddRow(3)
+	mock.ExpectQuery("INSERT INTO login_throttle .* ON CONFLICT \\(throttle_key\\) DO UPDATE .* RETURNING failures").
+		WithArgs("account:user@example.com", now, now.Add(-time.Minute)).WillReturnRows(rows)
+
+	failures, err := storage.IncrementFailures(context.Background(), "account:user@example.com", now, time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, failures)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemoryLoginThrottleStorage(t *testing.T) {
+	storage := storage.NewMemoryLoginThrottleStorage()
+	now := time.Now()
+
+	failures, _ := storage.IncrementFailures(context.Background(), "ip:10.0.0.1", now.Add(-2*time.Minute), time.Minute)
+	assert.Equal(t, 1, failures)
+
+	// предыдущая неудача вне окна, счёт начинается заново
+	failures, _ = storage.IncrementFailures(context.Background(), "ip:10.0.0.1", now, time.Minute)
+	assert.Equal(t, 1, failures)
+
+	failures, _ = storage.IncrementFailures(context.Background(), "ip:10.0.0.1", now, time.Minute)
+	assert.Equal(t, 2, failures)
+
+	assert.NoError(t, storage.LockUntil(context.Background(), "ip:10.0.0.1", now.Add(time.Minute)))
+	throttle, err := storage.GetThrottle(context.Background(), "ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Minute), throttle.LockedUntil)
+
+	assert.NoError(t, storage.ResetThrottle(context.Background(), "ip:10.0.0.1"))
+	_, err = storage.GetThrottle(context.Background(), "ip:10.0.0.1")
+	assert.Error(t, err)
+}
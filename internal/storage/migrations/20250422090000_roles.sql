@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TYPE role ADD VALUE IF NOT EXISTS 'admin';
+ALTER TYPE role ADD VALUE IF NOT EXISTS 'auditor';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- значения из enum в PostgreSQL удалить нельзя, откат не требуется
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE login_attempts (
+    attempt_id UUID PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    success BOOLEAN NOT NULL,
+    attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX login_attempts_attempted_at_idx ON login_attempts (attempted_at DESC);
+
+CREATE TABLE login_throttle (
+    throttle_key VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_throttle;
+DROP TABLE IF EXISTS login_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Закрываем все незакрытые приёмки, кроме последней, иначе индекс не создастся.
+UPDATE reception SET status_name = 'close'
+WHERE status_name = 'in_progress'
+    AND reception_id NOT IN (
+        SELECT DISTINCT ON (pvz_id) reception_id
+        FROM reception
+        WHERE status_name = 'in_progress'
+        ORDER BY pvz_id, date_time DESC
+    );
+
+CREATE UNIQUE INDEX reception_one_in_progress_per_pvz ON reception (pvz_id) WHERE status_name = 'in_progress';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS reception_one_in_progress_per_pvz;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Роли задаются политикой доступа (auth.policy_path), поэтому enum в базе
+-- не должен ограничивать их набор.
+ALTER TABLE users ALTER COLUMN role_name TYPE TEXT USING role_name::text;
+ALTER TABLE users ADD CONSTRAINT users_role_name_not_empty CHECK (role_name <> '');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- откат невозможен, если в таблице есть роли вне enum
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_name_not_empty;
+ALTER TABLE users ALTER COLUMN role_name TYPE role USING role_name::role;
+-- +goose StatementEnd
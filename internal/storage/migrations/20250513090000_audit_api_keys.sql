@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Действия по API ключу записываются от имени владельца ключа; эти колонки
+-- показывают, каким ключом и кем выпущенным выполнено действие.
+ALTER TABLE audit_events ADD COLUMN api_key_id UUID;
+ALTER TABLE audit_events ADD COLUMN api_key_created_by UUID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE audit_events DROP COLUMN IF EXISTS api_key_created_by;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS api_key_id;
+-- +goose StatementEnd
@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
@@ -16,8 +17,15 @@ type PVZ struct {
 type User struct {
 	ID       uuid.UUID `json:"user_id"`
 	Email    string    `json:"email"`
-	Password string    `json:"password"`
+	Password string    `json:"-"`
 	Role     string    `json:"role"`
+	Disabled bool      `json:"disabled"`
+}
+
+type UserFilter struct {
+	Role  string
+	Page  int
+	Limit int
 }
 
 type Receptions struct {
@@ -40,9 +48,144 @@ type ListPVZ struct {
 	Receptions []Receptions `json:"receptions"`
 }
 
+// PVZSummary — сводка по всем приёмкам ПВЗ, а не только по текущей странице.
+type PVZSummary struct {
+	Receptions      int
+	Products        int
+	OpenReceptionID *uuid.UUID
+}
+
+// Keyset — позиция в списке, упорядоченном по ключу сортировки и ID по
+// убыванию. Ключ — Time, а при сортировке по числу товаров — Count.
+// Следующая страница начинается сразу после позиции.
+type Keyset struct {
+	Time  time.Time
+	Count int
+	ID    uuid.UUID
+}
+
+// Сортировки списка ПВЗ, все по убыванию.
+const (
+	PVZSortLastReception    = "last_reception"
+	PVZSortRegistrationDate = "registration_date"
+	PVZSortProductCount     = "product_count"
+)
+
+// Filter: StartDate, EndDate, Status и ProductType отбирают приёмки, City,
+// UserID и PVZIDs — сами ПВЗ; PVZIDs, отличный от nil, оставляет только
+// перечисленные ПВЗ. Без IncludeEmpty в список попадают только ПВЗ, у
+// которых есть подходящие приёмки. С After страница выбирается по ключу,
+// а Page не учитывается.
 type Filter struct {
-	StartDate *time.Time
-	EndDate   *time.Time
-	Page      int
-	Limit     int
+	StartDate    *time.Time
+	EndDate      *time.Time
+	Status       string
+	ProductType  string
+	City         string
+	UserID       *uuid.UUID
+	PVZIDs       []uuid.UUID
+	IncludeEmpty bool
+	Sort         string
+	Page         int
+	Limit        int
+	After        *Keyset
+}
+
+// ReceptionFilter: с After страница выбирается по ключу, а Page не учитывается.
+type ReceptionFilter struct {
+	Page  int
+	Limit int
+	After *Keyset
+}
+
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Hash      string
+	ExpiresAt time.Time
+	Revoked   bool
+}
+
+type LoginAttempt struct {
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email"`
+	IP          string    `json:"ip"`
+	Success     bool      `json:"success"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+}
+
+type LoginAttemptFilter struct {
+	Email      string
+	IP         string
+	FailedOnly bool
+	Page       int
+	Limit      int
+}
+
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+type TOTP struct {
+	UserID         uuid.UUID
+	Secret         string
+	Confirmed      bool
+	LastUsedStep   int64
+	FailedAttempts int
+	LastFailureAt  time.Time
+}
+
+type APIKey struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Prefix      string      `json:"prefix"`
+	Hash        string      `json:"-"`
+	UserID      uuid.UUID   `json:"userId"`
+	CreatedBy   uuid.UUID   `json:"createdBy"`
+	Permissions []string    `json:"permissions"`
+	PVZIDs      []uuid.UUID `json:"pvzIds"`
+	ExpiresAt   *time.Time  `json:"expiresAt,omitempty"`
+	Revoked     bool        `json:"revoked"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	LastUsedAt  *time.Time  `json:"lastUsedAt,omitempty"`
+}
+
+// Actor описывает, кто и откуда выполняет изменение. Пишется в журнал аудита.
+// Для запросов по API ключу UserID — владелец ключа, APIKeyID и
+// APIKeyCreatedBy отличают такие действия от действий самого пользователя.
+type Actor struct {
+	UserID          uuid.UUID
+	Role            string
+	APIKeyID        uuid.UUID
+	APIKeyCreatedBy uuid.UUID
+	RequestID       string
+	IP              string
+}
+
+type AuditEvent struct {
+	ID              int64           `json:"id"`
+	ActorID         *uuid.UUID      `json:"actorId"`
+	APIKeyID        *uuid.UUID      `json:"apiKeyId,omitempty"`
+	APIKeyCreatedBy *uuid.UUID      `json:"apiKeyCreatedBy,omitempty"`
+	Action          string          `json:"action"`
+	EntityType      string          `json:"entityType"`
+	EntityID        uuid.UUID       `json:"entityId"`
+	Before          json.RawMessage `json:"before,omitempty"`
+	After           json.RawMessage `json:"after,omitempty"`
+	RequestID       string          `json:"requestId"`
+	IP              string          `json:"ip"`
+	CreatedAt       time.Time       `json:"createdAt"`
+}
+
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	EntityType string
+	EntityID   *uuid.UUID
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	Limit      int
 }
@@ -0,0 +1,46 @@
+// Package migrations хранит SQL миграции goose и встраивает их в бинарник.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// LatestVersion возвращает версию последней миграции — ту, до которой
+// должна быть обновлена схема для работы сервиса.
+func LatestVersion() (int64, error) {
+	files, err := fs.Glob(FS, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, name := range files {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return 0, fmt.Errorf("invalid migration name %s", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration name %s: %w", name, err)
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
+
+// NewProvider создаёт goose провайдер, применяющий встроенные миграции к db.
+func NewProvider(db *sql.DB) (*goose.Provider, error) {
+	return goose.NewProvider(goose.DialectPostgres, db, FS)
+}
@@ -0,0 +1,34 @@
+package migrations_test
+
+import (
+	"pvz/internal/storage/migrations"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestVersion(t *testing.T) {
+	version, err := migrations.LatestVersion()
+
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, version, int64(20250513090000))
+}
+
+func TestNewProvider(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	provider, err := migrations.NewProvider(db)
+	require.NoError(t, err)
+
+	latest, err := migrations.LatestVersion()
+	require.NoError(t, err)
+
+	sources := provider.ListSources()
+	require.NotEmpty(t, sources)
+	assert.Equal(t, int64(20250415215907), sources[0].Version)
+	assert.Equal(t, latest, sources[len(sources)-1].Version)
+}
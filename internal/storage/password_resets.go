@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type PasswordResetsPostgresStorage interface {
+	CreateResetToken(ctx context.Context, id, user_id uuid.UUID, hash string, expires_at time.Time) error
+	UseResetToken(ctx context.Context, hash string) (uuid.UUID, error)
+	InvalidateUserResetTokens(ctx context.Context, user_id uuid.UUID) error
+}
+
+type PasswordResetsPostgresStorageImpl struct {
+	db DBTX
+}
+
+func NewPasswordResetsPostgresStorage(db DBTX) *PasswordResetsPostgresStorageImpl {
+	return &PasswordResetsPostgresStorageImpl{db: db}
+}
+
+func (p *PasswordResetsPostgresStorageImpl) CreateResetToken(ctx context.Context, id, user_id uuid.UUID, hash string, expires_at time.Time) error {
+	query := "INSERT INTO password_reset_tokens (token_id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)"
+
+	_, err := p.db.ExecContext(ctx, query, id, user_id, hash, expires_at)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UseResetToken атомарно помечает токен использованным и возвращает владельца.
+// Для уже использованного или просроченного токена возвращается sql.ErrNoRows.
+func (p *PasswordResetsPostgresStorageImpl) UseResetToken(ctx context.Context, hash string) (uuid.UUID, error) {
+	query := `
+		UPDATE password_reset_tokens
+		SET used = TRUE
+		WHERE token_hash = $1 AND used = FALSE AND expires_at > NOW()
+		RETURNING user_id
+	`
+
+	var user_id uuid.UUID
+	err := p.db.QueryRowContext(ctx, query, hash).Scan(&user_id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return user_id, nil
+}
+
+func (p *PasswordResetsPostgresStorageImpl) InvalidateUserResetTokens(ctx context.Context, user_id uuid.UUID) error {
+	query := "UPDATE password_reset_tokens SET used = TRUE WHERE user_id = $1 AND used = FALSE"
+
+	_, err := p.db.ExecContext(ctx, query, user_id)
+	return err
+}
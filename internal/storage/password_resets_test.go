@@ -0,0 +1,63 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetsPostgresStorage_UseResetToken(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewPasswordResetsPostgresStorage(db)
+
+	tests := []struct {
+		name        string
+		mock        func()
+		expected    uuid.UUID
+		expectedErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"user_id"}).AddRow(user_id)
+				mock.ExpectQuery("UPDATE password_reset_tokens SET used = TRUE WHERE token_hash = \\$1 AND used = FALSE AND expires_at > NOW\\(\\) RETURNING user_id").
+					WithArgs("hash").WillReturnRows(rows)
+			},
+			expected: user_id,
+		},
+		{
+			name: "used or expired",
+			mock: func() {
+				mock.ExpectQuery("UPDATE password_reset_tokens").
+					WithArgs("hash").WillReturnError(sql.ErrNoRows)
+			},
+			expected:    uuid.Nil,
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			user_id, err := storage.UseResetToken(context.Background(), "hash")
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expected, user_id)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -1,54 +1,76 @@
 package storage
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"pvz/internal/storage/migrations/entity"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
 )
 
+// ErrUnsupportedProductType возвращается, если типа нет в перечислении product_type.
+var ErrUnsupportedProductType = errors.New("unsupported product type")
+
 type ProductPostgresStorage interface {
-	CreateProduct(id uuid.UUID, product_type string) (*entity.Products, error)
-	DeleteProduct(product_id uuid.UUID) error
-	GetLastProductID(reception_id uuid.UUID) (uuid.UUID, error)
+	CreateProduct(ctx context.Context, id uuid.UUID, product_type string, actor entity.Actor) (*entity.Products, error)
+	DeleteProduct(ctx context.Context, product_id uuid.UUID, actor entity.Actor) (*entity.Products, error)
+	GetLastProductID(ctx context.Context, reception_id uuid.UUID) (uuid.UUID, error)
 }
 
 type ProductPostgresStorageImpl struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewProductPostgresStorage(db *sql.DB) *ProductPostgresStorageImpl {
+func NewProductPostgresStorage(db DBTX) *ProductPostgresStorageImpl {
 	return &ProductPostgresStorageImpl{db: db}
 }
 
-func (p *ProductPostgresStorageImpl) CreateProduct(id uuid.UUID, product_type string) (*entity.Products, error) {
-	product_id := uuid.Must(uuid.NewV4())
-	date := time.Now()
+func (p *ProductPostgresStorageImpl) CreateProduct(ctx context.Context, id uuid.UUID, product_type string, actor entity.Actor) (*entity.Products, error) {
+	product := &entity.Products{ID: uuid.Must(uuid.NewV4()), DateTime: time.Now(), Type: product_type, ReceptionId: id}
 	query := "INSERT INTO product (product_id, date_time, type_name, reception_id) VALUES ($1, $2, $3, $4)"
 
-	_, err := p.db.Exec(query, product_id, date, product_type, id)
+	err := atomic(ctx, p.db, func(tx DBTX) error {
+		_, err := tx.ExecContext(ctx, query, product.ID, product.DateTime, product_type, id)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "22P02" {
+			return ErrUnsupportedProductType
+		} else if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actor, AuditProductCreate, AuditEntityProduct, product.ID, nil, product)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &entity.Products{ID: product_id, DateTime: date, Type: product_type, ReceptionId: id}, nil
+	return product, nil
 }
 
-func (p *ProductPostgresStorageImpl) DeleteProduct(product_id uuid.UUID) error {
-	query := "delete from product where product_id = $1"
+// DeleteProduct удаляет строку физически, поэтому её последнее состояние
+// сохраняется в журнале аудита.
+func (p *ProductPostgresStorageImpl) DeleteProduct(ctx context.Context, product_id uuid.UUID, actor entity.Actor) (*entity.Products, error) {
+	query := "DELETE FROM product WHERE product_id = $1 RETURNING product_id, date_time, type_name, reception_id"
 
-	_, err := p.db.Exec(query, product_id)
+	var before entity.Products
+	err := atomic(ctx, p.db, func(tx DBTX) error {
+		err := tx.QueryRowContext(ctx, query, product_id).Scan(&before.ID, &before.DateTime, &before.Type, &before.ReceptionId)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actor, AuditProductDelete, AuditEntityProduct, product_id, before, nil)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return &before, nil
 }
 
-func (p *ProductPostgresStorageImpl) GetLastProductID(reception_id uuid.UUID) (uuid.UUID, error) {
+func (p *ProductPostgresStorageImpl) GetLastProductID(ctx context.Context, reception_id uuid.UUID) (uuid.UUID, error) {
 	var product_id uuid.UUID
 	query := "SELECT product_id FROM product WHERE reception_id = $1 ORDER BY date_time DESC LIMIT 1"
 
-	err := p.db.QueryRow(query, reception_id).Scan(&product_id)
+	err := p.db.QueryRowContext(ctx, query, reception_id).Scan(&product_id)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
@@ -1,6 +1,7 @@
 package storage_test
 
 import (
+	"context"
 	"pvz/internal/storage"
 	"pvz/internal/storage/migrations/entity"
 	"testing"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,6 +17,13 @@ func TestProductPostgresStorage_CreateProduct(t *testing.T) {
 	reception_id := uuid.Must(uuid.NewV4())
 	product_type := "одежда"
 	date := time.Now()
+	actor := entity.Actor{
+		UserID:          uuid.Must(uuid.NewV4()),
+		APIKeyID:        uuid.Must(uuid.NewV4()),
+		APIKeyCreatedBy: uuid.Must(uuid.NewV4()),
+		RequestID:       "req-1",
+		IP:              "10.0.0.1",
+	}
 
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -22,6 +31,7 @@ func TestProductPostgresStorage_CreateProduct(t *testing.T) {
 	}
 	defer db.Close()
 
+	errUnsupportedType := storage.ErrUnsupportedProductType
 	storage := storage.NewProductPostgresStorage(db)
 
 	tests := []struct {
@@ -37,8 +47,13 @@ func TestProductPostgresStorage_CreateProduct(t *testing.T) {
 			reception_id: reception_id,
 			product_type: product_type,
 			mock: func() {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO product").
 					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), product_type, reception_id).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(actor.UserID, "product.create", "product", sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "req-1", "10.0.0.1", actor.APIKeyID, actor.APIKeyCreatedBy).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 			expected: &entity.Products{
 				DateTime:    date,
@@ -47,13 +62,25 @@ func TestProductPostgresStorage_CreateProduct(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name:         "unsupported type",
+			reception_id: reception_id,
+			product_type: "мебель",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO product").
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "мебель", reception_id).WillReturnError(&pq.Error{Code: "22P02"})
+				mock.ExpectRollback()
+			},
+			expectedErr: errUnsupportedType,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			product, err := storage.CreateProduct(tt.reception_id, tt.product_type)
+			product, err := storage.CreateProduct(context.Background(), tt.reception_id, tt.product_type, actor)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -110,7 +137,7 @@ func TestProductPostgresStorage_GetLastProductID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			product_id, err := storage.GetLastProductID(tt.reception_id)
+			product_id, err := storage.GetLastProductID(context.Background(), tt.reception_id)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -126,3 +153,35 @@ func TestProductPostgresStorage_GetLastProductID(t *testing.T) {
 		})
 	}
 }
+
+func TestProductPostgresStorage_DeleteProduct(t *testing.T) {
+	product_id := uuid.Must(uuid.NewV4())
+	reception_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4())}
+	date := time.Now()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewProductPostgresStorage(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM product WHERE product_id = \\$1 RETURNING").
+		WithArgs(product_id).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "date_time", "type_name", "reception_id"}).
+			AddRow(product_id, date, "обувь", reception_id))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(actor.UserID, "product.delete", "product", product_id, sqlmock.AnyArg(), nil, "", "", nil, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	deleted, err := storage.DeleteProduct(context.Background(), product_id, actor)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "обувь", deleted.Type)
+	assert.Equal(t, reception_id, deleted.ReceptionId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
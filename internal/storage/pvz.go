@@ -3,41 +3,65 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"pvz/internal/storage/migrations/entity"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrUnsupportedCity возвращается, если города нет в перечислении city.
+	ErrUnsupportedCity = errors.New("unsupported city")
+	// ErrPVZExists возвращается, если ПВЗ с таким id уже есть.
+	ErrPVZExists = errors.New("pvz already exists")
 )
 
 type PVZPostgresStorage interface {
-	CreatePVZ(id, user_id uuid.UUID, city string, date time.Time) (*entity.PVZ, error)
-	GetPVZById(id uuid.UUID) (*entity.PVZ, error)
+	CreatePVZ(ctx context.Context, id uuid.UUID, actor entity.Actor, city string, date time.Time) (*entity.PVZ, error)
+	GetPVZById(ctx context.Context, id uuid.UUID) (*entity.PVZ, error)
 	GetPVZsWithFilter(ctx context.Context, filter entity.Filter) ([]entity.ListPVZ, error)
 	CountPVZsWithFilter(ctx context.Context, filter entity.Filter) (int, error)
+	GetPVZReceptions(ctx context.Context, pvz_id uuid.UUID, filter entity.ReceptionFilter) ([]entity.Receptions, error)
+	GetPVZSummary(ctx context.Context, pvz_id uuid.UUID) (*entity.PVZSummary, error)
 }
 
 type PVZPostgresStorageImpl struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewPVZPostgresStorage(db *sql.DB) *PVZPostgresStorageImpl {
+func NewPVZPostgresStorage(db DBTX) *PVZPostgresStorageImpl {
 	return &PVZPostgresStorageImpl{db: db}
 }
 
-func (p *PVZPostgresStorageImpl) CreatePVZ(id, user_id uuid.UUID, city string, date time.Time) (*entity.PVZ, error) {
+func (p *PVZPostgresStorageImpl) CreatePVZ(ctx context.Context, id uuid.UUID, actor entity.Actor, city string, date time.Time) (*entity.PVZ, error) {
+	pvz := &entity.PVZ{ID: id, RegistrationDate: date, City: city, UserID: actor.UserID}
 	query := "INSERT INTO pvz (pvz_id, registration_date, city_name, user_id) VALUES ($1, $2, $3, $4)"
-	_, err := p.db.Exec(query, id, date, city, user_id)
+
+	err := atomic(ctx, p.db, func(tx DBTX) error {
+		_, err := tx.ExecContext(ctx, query, id, date, city, actor.UserID)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "22P02" {
+			return ErrUnsupportedCity
+		} else if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "pvz_pkey" {
+			return ErrPVZExists
+		} else if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actor, AuditPVZCreate, AuditEntityPVZ, id, nil, pvz)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &entity.PVZ{ID: id, RegistrationDate: date, City: city}, nil // проверить дату
+	return pvz, nil
 }
 
-func (p *PVZPostgresStorageImpl) GetPVZById(id uuid.UUID) (*entity.PVZ, error) {
+func (p *PVZPostgresStorageImpl) GetPVZById(ctx context.Context, id uuid.UUID) (*entity.PVZ, error) {
 	var pvz entity.PVZ
 	query := "SELECT * FROM pvz WHERE pvz_id = $1"
-	err := p.db.QueryRow(query, id).Scan(&pvz.ID, &pvz.RegistrationDate, &pvz.City, &pvz.UserID)
+	err := p.db.QueryRowContext(ctx, query, id).Scan(&pvz.ID, &pvz.RegistrationDate, &pvz.City, &pvz.UserID)
 
 	if err == sql.ErrNoRows {
 		return nil, sql.ErrNoRows
@@ -47,94 +71,125 @@ func (p *PVZPostgresStorageImpl) GetPVZById(id uuid.UUID) (*entity.PVZ, error) {
 	return &pvz, nil
 }
 
+// pvzReceptionCondition отбирает приёмки, pvzProductCondition — их товары,
+// pvzCondition — сами ПВЗ. Параметры $1–$8 задаёт pvzFilterArgs.
+const (
+	pvzReceptionCondition = `($1::timestamp IS NULL OR r.date_time >= $1)
+			AND ($2::timestamp IS NULL OR r.date_time <= $2)
+			AND ($3 = '' OR r.status_name::text = $3)
+			AND ($4 = '' OR EXISTS (
+				SELECT 1 FROM product t WHERE t.reception_id = r.reception_id AND t.type_name::text = $4
+			))`
+	pvzProductCondition = `($4 = '' OR pr.type_name::text = $4)`
+	pvzCondition        = `($5 = '' OR p.city_name::text = $5) AND ($6::uuid IS NULL OR p.user_id = $6)
+			AND ($8::uuid[] IS NULL OR p.pvz_id = ANY($8))`
+)
+
+// pvzSorts — ключи сортировки списка ПВЗ по сгруппированным строкам ПВЗ,
+// приёмок и товаров. ПВЗ без приёмок при сортировке по последней приёмке
+// встают на место даты регистрации.
+var pvzSorts = map[string]struct{ key, keyType string }{
+	entity.PVZSortLastReception:    {key: "COALESCE(MAX(r.date_time), p.registration_date)", keyType: "timestamp"},
+	entity.PVZSortRegistrationDate: {key: "p.registration_date", keyType: "timestamp"},
+	entity.PVZSortProductCount:     {key: "COUNT(pr.product_id)", keyType: "bigint"},
+}
+
+// GetPVZsWithFilter возвращает страницу ПВЗ с подходящими приёмками и их
+// товарами. LIMIT и OFFSET применяются к ПВЗ, а не к строкам соединения,
+// поэтому страница совпадает с подсчётом CountPVZsWithFilter. ПВЗ
+// упорядочены по filter.Sort (по умолчанию по последней приёмке) и ID по
+// убыванию; filter.After продолжает список после этой пары вместо OFFSET.
 func (r *PVZPostgresStorageImpl) GetPVZsWithFilter(ctx context.Context, filter entity.Filter) ([]entity.ListPVZ, error) {
+	sortBy := filter.Sort
+	if sortBy == "" {
+		sortBy = entity.PVZSortLastReception
+	}
+	sort, ok := pvzSorts[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown pvz sort %q", filter.Sort)
+	}
+
 	query := `
-		WITH filtered_receptions AS (
-			SELECT r.reception_id, r.date_time, r.pvz_id, r.status_name
-			FROM reception r
-			WHERE ($1::timestamp IS NULL OR r.date_time >= $1)
-			AND ($2::timestamp IS NULL OR r.date_time <= $2)
+		WITH page AS (
+			SELECT p.pvz_id, p.registration_date, p.city_name, ` + sort.key + ` AS sort_key
+			FROM pvz p
+			LEFT JOIN reception r ON r.pvz_id = p.pvz_id
+			AND ` + pvzReceptionCondition + `
+			LEFT JOIN product pr ON pr.reception_id = r.reception_id AND ` + pvzProductCondition + `
+			WHERE ` + pvzCondition + `
+			GROUP BY p.pvz_id
+			HAVING ($7::boolean OR COUNT(r.reception_id) > 0)
+			AND ($12::uuid IS NULL OR (` + sort.key + `, p.pvz_id) < ($11::` + sort.keyType + `, $12))
+			ORDER BY sort_key DESC, p.pvz_id DESC
+			LIMIT $9 OFFSET $10
 		)
-		SELECT 
+		SELECT
 			p.pvz_id, p.registration_date, p.city_name,
-			r.reception_id, r.date_time, r.pvz_id, r.status_name,
-			pr.product_id, pr.date_time, pr.type_name, pr.reception_id
-		FROM pvz p
-		JOIN filtered_receptions r ON p.pvz_id = r.pvz_id
-		LEFT JOIN product pr ON pr.reception_id = r.reception_id
-		ORDER BY r.date_time DESC
-		LIMIT $3 OFFSET $4
+			r.reception_id, r.date_time, r.status_name,
+			pr.product_id, pr.date_time, pr.type_name
+		FROM page p
+		LEFT JOIN reception r ON r.pvz_id = p.pvz_id
+			AND ` + pvzReceptionCondition + `
+		LEFT JOIN product pr ON pr.reception_id = r.reception_id AND ` + pvzProductCondition + `
+		ORDER BY p.sort_key DESC, p.pvz_id DESC, r.date_time DESC, r.reception_id DESC, pr.date_time
 	`
 
-	offset := (filter.Page - 1) * filter.Limit
-
-	rows, err := r.db.QueryContext(ctx, query, filter.StartDate, filter.EndDate, filter.Limit, offset)
+	offset, afterKey, afterID := pageArgs(filter.Page, filter.Limit, filter.After)
+	if filter.After != nil && sortBy == entity.PVZSortProductCount {
+		afterKey = filter.After.Count
+	}
+	rows, err := r.db.QueryContext(ctx, query, pvzFilterArgs(filter, filter.Limit, offset, afterKey, afterID)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query pvzs: %w", err)
 	}
 	defer rows.Close()
 
-	var result []entity.ListPVZ
-	var currentPVZ *entity.ListPVZ
-	var lastPVZID uuid.UUID
-	var lastReceptionID uuid.UUID
-
+	result := []entity.ListPVZ{}
 	for rows.Next() {
 		var (
-			pvzID               uuid.UUID
-			pvzRegistrationDate time.Time
-			pvzCity             string
-			receptionID         uuid.UUID
-			receptionDateTime   time.Time
-			receptionPVZID      uuid.UUID
-			receptionStatus     string
-			productID           uuid.UUID
-			productDateTime     time.Time
-			productType         string
-			productReceptionID  uuid.UUID
+			pvz               entity.PVZ
+			receptionID       uuid.NullUUID
+			receptionDateTime sql.NullTime
+			receptionStatus   sql.NullString
+			productID         uuid.NullUUID
+			productDateTime   sql.NullTime
+			productType       sql.NullString
 		)
 
 		err := rows.Scan(
-			&pvzID, &pvzRegistrationDate, &pvzCity,
-			&receptionID, &receptionDateTime, &receptionPVZID, &receptionStatus,
-			&productID, &productDateTime, &productType, &productReceptionID,
+			&pvz.ID, &pvz.RegistrationDate, &pvz.City,
+			&receptionID, &receptionDateTime, &receptionStatus,
+			&productID, &productDateTime, &productType,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		if currentPVZ == nil || pvzID != lastPVZID {
-			result = append(result, entity.ListPVZ{
-				Pvz: entity.PVZ{
-					ID:               pvzID,
-					RegistrationDate: pvzRegistrationDate,
-					City:             pvzCity,
-				},
-				Receptions: []entity.Receptions{},
-			})
-			currentPVZ = &result[len(result)-1]
-			lastPVZID = pvzID
-			lastReceptionID = uuid.Nil
+		if len(result) == 0 || result[len(result)-1].Pvz.ID != pvz.ID {
+			result = append(result, entity.ListPVZ{Pvz: pvz, Receptions: []entity.Receptions{}})
 		}
+		current := &result[len(result)-1]
 
-		if receptionID != lastReceptionID {
-			currentPVZ.Receptions = append(currentPVZ.Receptions, entity.Receptions{
-				ID:       receptionID,
-				DateTime: receptionDateTime,
-				PVZID:    receptionPVZID,
-				Status:   receptionStatus,
+		if !receptionID.Valid {
+			continue
+		}
+		if len(current.Receptions) == 0 || current.Receptions[len(current.Receptions)-1].ID != receptionID.UUID {
+			current.Receptions = append(current.Receptions, entity.Receptions{
+				ID:       receptionID.UUID,
+				DateTime: receptionDateTime.Time,
+				PVZID:    pvz.ID,
+				Status:   receptionStatus.String,
 				Products: []entity.Products{},
 			})
-			lastReceptionID = receptionID
 		}
 
-		if !productID.IsNil() {
-			lastReception := &currentPVZ.Receptions[len(currentPVZ.Receptions)-1]
-			lastReception.Products = append(lastReception.Products, entity.Products{
-				ID:          productID,
-				DateTime:    productDateTime,
-				Type:        productType,
-				ReceptionId: productReceptionID,
+		if productID.Valid {
+			last := &current.Receptions[len(current.Receptions)-1]
+			last.Products = append(last.Products, entity.Products{
+				ID:          productID.UUID,
+				DateTime:    productDateTime.Time,
+				Type:        productType.String,
+				ReceptionId: receptionID.UUID,
 			})
 		}
 	}
@@ -148,18 +203,125 @@ func (r *PVZPostgresStorageImpl) GetPVZsWithFilter(ctx context.Context, filter e
 
 func (r *PVZPostgresStorageImpl) CountPVZsWithFilter(ctx context.Context, filter entity.Filter) (int, error) {
 	query := `
-		SELECT COUNT(DISTINCT p.pvz_id)
+		SELECT COUNT(*)
 		FROM pvz p
-		JOIN reception r ON p.pvz_id = r.pvz_id
-		WHERE ($1::timestamp IS NULL OR r.date_time >= $1)
-		AND ($2::timestamp IS NULL OR r.date_time <= $2)
+		WHERE ` + pvzCondition + `
+		AND ($7::boolean OR EXISTS (
+			SELECT 1 FROM reception r WHERE r.pvz_id = p.pvz_id
+			AND ` + pvzReceptionCondition + `
+		))
 	`
 
 	var count int
-	err := r.db.QueryRowContext(ctx, query, filter.StartDate, filter.EndDate).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, pvzFilterArgs(filter)...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count pvzs: %w", err)
 	}
 
 	return count, nil
 }
+
+func pvzFilterArgs(filter entity.Filter, extra ...any) []any {
+	var pvz_ids any
+	if filter.PVZIDs != nil {
+		pvz_ids = pq.Array(uuidStrings(filter.PVZIDs))
+	}
+	args := []any{filter.StartDate, filter.EndDate, filter.Status, filter.ProductType, filter.City, nullableUUID(filter.UserID), filter.IncludeEmpty, pvz_ids}
+	return append(args, extra...)
+}
+
+// GetPVZReceptions возвращает страницу приёмок ПВЗ, новые первыми, вместе
+// с товарами. Страница отсчитывается по приёмкам, а не по строкам соединения
+// с товарами.
+func (r *PVZPostgresStorageImpl) GetPVZReceptions(ctx context.Context, pvz_id uuid.UUID, filter entity.ReceptionFilter) ([]entity.Receptions, error) {
+	query := `
+		WITH page AS (
+			SELECT reception_id, date_time, pvz_id, status_name
+			FROM reception
+			WHERE pvz_id = $1
+			AND ($4::timestamp IS NULL OR (date_time, reception_id) < ($4, $5::uuid))
+			ORDER BY date_time DESC, reception_id DESC
+			LIMIT $2 OFFSET $3
+		)
+		SELECT
+			r.reception_id, r.date_time, r.pvz_id, r.status_name,
+			pr.product_id, pr.date_time, pr.type_name
+		FROM page r
+		LEFT JOIN product pr ON pr.reception_id = r.reception_id
+		ORDER BY r.date_time DESC, r.reception_id DESC, pr.date_time
+	`
+
+	offset, afterTime, afterID := pageArgs(filter.Page, filter.Limit, filter.After)
+	rows, err := r.db.QueryContext(ctx, query, pvz_id, filter.Limit, offset, afterTime, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query receptions: %w", err)
+	}
+	defer rows.Close()
+
+	receptions := []entity.Receptions{}
+	for rows.Next() {
+		var (
+			reception       entity.Receptions
+			productID       uuid.NullUUID
+			productDateTime sql.NullTime
+			productType     sql.NullString
+		)
+		err := rows.Scan(
+			&reception.ID, &reception.DateTime, &reception.PVZID, &reception.Status,
+			&productID, &productDateTime, &productType,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if len(receptions) == 0 || receptions[len(receptions)-1].ID != reception.ID {
+			reception.Products = []entity.Products{}
+			receptions = append(receptions, reception)
+		}
+
+		if productID.Valid {
+			last := &receptions[len(receptions)-1]
+			last.Products = append(last.Products, entity.Products{
+				ID:          productID.UUID,
+				DateTime:    productDateTime.Time,
+				Type:        productType.String,
+				ReceptionId: reception.ID,
+			})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return receptions, nil
+}
+
+func (r *PVZPostgresStorageImpl) GetPVZSummary(ctx context.Context, pvz_id uuid.UUID) (*entity.PVZSummary, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM reception WHERE pvz_id = $1),
+			(SELECT COUNT(*) FROM product pr JOIN reception r ON r.reception_id = pr.reception_id WHERE r.pvz_id = $1),
+			(SELECT reception_id FROM reception WHERE pvz_id = $1 AND status_name = 'in_progress' LIMIT 1)
+	`
+
+	var summary entity.PVZSummary
+	var openReceptionID uuid.NullUUID
+	err := r.db.QueryRowContext(ctx, query, pvz_id).Scan(&summary.Receptions, &summary.Products, &openReceptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize pvz: %w", err)
+	}
+
+	if openReceptionID.Valid {
+		summary.OpenReceptionID = &openReceptionID.UUID
+	}
+	return &summary, nil
+}
+
+// pageArgs возвращает OFFSET и границу ключа для запроса страницы. С ключом
+// страница начинается сразу после него, и OFFSET не нужен.
+func pageArgs(page, limit int, after *entity.Keyset) (offset int, afterTime, afterID any) {
+	if after != nil {
+		return 0, after.Time, after.ID
+	}
+	return (page - 1) * limit, nil, nil
+}
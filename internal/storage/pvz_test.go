@@ -3,6 +3,7 @@ package storage_test
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"pvz/internal/storage"
 	"pvz/internal/storage/migrations/entity"
 	"testing"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,6 +27,7 @@ func TestPVZPostgresStorage_CreatePVZ(t *testing.T) {
 	}
 	defer db.Close()
 
+	errPVZExists := storage.ErrPVZExists
 	storage := storage.NewPVZPostgresStorage(db)
 
 	tests := []struct {
@@ -43,18 +46,53 @@ func TestPVZPostgresStorage_CreatePVZ(t *testing.T) {
 			city:    city,
 			date:    date,
 			mock: func() {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO pvz").
 					WithArgs(pvz_id, date, city, user_id).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(user_id, "pvz.create", "pvz", pvz_id, nil, sqlmock.AnyArg(), "", "", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 			expectedErr: nil,
 		},
+		{
+			name:    "audit failure rolls back",
+			pvz_id:  pvz_id,
+			user_id: user_id,
+			city:    city,
+			date:    date,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO pvz").
+					WithArgs(pvz_id, date, city, user_id).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO audit_events").WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+			},
+			expectedErr: fmt.Errorf("failed to record audit event: %w", sql.ErrConnDone),
+		},
+		{
+			name:    "pvz exists",
+			pvz_id:  pvz_id,
+			user_id: user_id,
+			city:    city,
+			date:    date,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO pvz").
+					WithArgs(pvz_id, date, city, user_id).
+					WillReturnError(&pq.Error{Code: "23505", Constraint: "pvz_pkey"})
+				mock.ExpectRollback()
+			},
+			expectedErr: errPVZExists,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			pvz, err := storage.CreatePVZ(tt.pvz_id, tt.user_id, tt.city, tt.date)
+			pvz, err := storage.CreatePVZ(context.Background(), tt.pvz_id, entity.Actor{UserID: tt.user_id}, tt.city, tt.date)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -125,7 +163,7 @@ func TestPVZPostgresStorage_GetPVZById(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			pvz, err := storage.GetPVZById(tt.pvz_id)
+			pvz, err := storage.GetPVZById(context.Background(), tt.pvz_id)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -144,17 +182,15 @@ func TestPVZPostgresStorage_GetPVZById(t *testing.T) {
 
 func TestPVZPostgresStorage_GetPVZsWithFilter(t *testing.T) {
 	pvz_id := uuid.Must(uuid.NewV4())
+	other_pvz_id := uuid.Must(uuid.NewV4())
 	reception_id := uuid.Must(uuid.NewV4())
+	empty_reception_id := uuid.Must(uuid.NewV4())
+	other_reception_id := uuid.Must(uuid.NewV4())
 	product_id := uuid.Must(uuid.NewV4())
+	second_product_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
 	date := time.Now()
 
-	filter := entity.Filter{
-		StartDate: &date,
-		EndDate:   &date,
-		Page:      1,
-		Limit:     10,
-	}
-
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -163,31 +199,38 @@ func TestPVZPostgresStorage_GetPVZsWithFilter(t *testing.T) {
 
 	storage := storage.NewPVZPostgresStorage(db)
 
+	columns := []string{"pvz_id", "registration_date", "city_name",
+		"reception_id", "date_time", "status_name",
+		"product_id", "date_time", "type_name"}
+
 	tests := []struct {
 		name        string
-		pvz_id      uuid.UUID
-		mock        func()
+		filter      entity.Filter
+		mock        func(filter entity.Filter)
 		expected    []entity.ListPVZ
 		expectedErr error
 	}{
 		{
 			name:   "success",
-			pvz_id: pvz_id,
-			mock: func() {
-				rows := sqlmock.NewRows([]string{"pvz_id", "registration_date", "city_name",
-					"reception_id", "date_time", "pvz_id", "status_name",
-					"product_id", "date_time", "type_name", "reception_id"}).AddRow(pvz_id, date, "Москва",
-					reception_id, date, pvz_id, "close",
-					product_id, date, "одежда", reception_id)
+			filter: entity.Filter{StartDate: &date, EndDate: &date, Page: 1, Limit: 10},
+			mock: func(filter entity.Filter) {
+				rows := sqlmock.NewRows(columns).AddRow(pvz_id, date, "Москва",
+					reception_id, date, "close",
+					product_id, date, "одежда")
 				mock.ExpectQuery(`
-				WITH filtered_receptions AS .*
-				SELECT .*
-				FROM pvz p
-				JOIN filtered_receptions r ON p.pvz_id = r.pvz_id
-				LEFT JOIN product pr ON pr.reception_id = r.reception_id
-				ORDER BY r.date_time DESC
-				LIMIT .* OFFSET .*
-			`).WithArgs(filter.StartDate, filter.EndDate, filter.Limit, 0).
+				WITH page AS .*
+				COALESCE\(MAX\(r.date_time\), p.registration_date\) AS sort_key
+				.*
+				GROUP BY p.pvz_id
+				HAVING \(\$7::boolean OR COUNT\(r.reception_id\) > 0\)
+				.*
+				ORDER BY sort_key DESC, p.pvz_id DESC
+				LIMIT \$9 OFFSET \$10
+				.*
+				FROM page p
+				LEFT JOIN reception r .*
+				LEFT JOIN product pr ON pr.reception_id = r.reception_id .*
+			`).WithArgs(filter.StartDate, filter.EndDate, "", "", "", nil, false, nil, filter.Limit, 0, nil, nil).
 					WillReturnRows(rows)
 			},
 			expected: []entity.ListPVZ{
@@ -217,13 +260,74 @@ func TestPVZPostgresStorage_GetPVZsWithFilter(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name:   "groups every product of every pvz on the page",
+			filter: entity.Filter{Page: 3, Limit: 2},
+			mock: func(filter entity.Filter) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(pvz_id, date, "Москва", reception_id, date, "in_progress", product_id, date, "одежда").
+					AddRow(pvz_id, date, "Москва", reception_id, date, "in_progress", second_product_id, date, "обувь").
+					AddRow(pvz_id, date, "Москва", empty_reception_id, date.Add(-time.Hour), "close", nil, nil, nil).
+					AddRow(other_pvz_id, date, "Казань", other_reception_id, date.Add(-2*time.Hour), "close", nil, nil, nil)
+				mock.ExpectQuery("WITH page AS").
+					WithArgs(filter.StartDate, filter.EndDate, "", "", "", nil, false, nil, 2, 4, nil, nil).
+					WillReturnRows(rows)
+			},
+			expected: []entity.ListPVZ{
+				{
+					Pvz: entity.PVZ{ID: pvz_id, RegistrationDate: date, City: "Москва"},
+					Receptions: []entity.Receptions{
+						{ID: reception_id, DateTime: date, PVZID: pvz_id, Status: "in_progress", Products: []entity.Products{
+							{ID: product_id, DateTime: date, Type: "одежда", ReceptionId: reception_id},
+							{ID: second_product_id, DateTime: date, Type: "обувь", ReceptionId: reception_id},
+						}},
+						{ID: empty_reception_id, DateTime: date.Add(-time.Hour), PVZID: pvz_id, Status: "close", Products: []entity.Products{}},
+					},
+				},
+				{
+					Pvz: entity.PVZ{ID: other_pvz_id, RegistrationDate: date, City: "Казань"},
+					Receptions: []entity.Receptions{
+						{ID: other_reception_id, DateTime: date.Add(-2 * time.Hour), PVZID: other_pvz_id, Status: "close", Products: []entity.Products{}},
+					},
+				},
+			},
+		},
+		{
+			name: "includes pvz without receptions",
+			filter: entity.Filter{
+				Status: "close", ProductType: "обувь", City: "Казань", UserID: &user_id,
+				IncludeEmpty: true, Sort: entity.PVZSortRegistrationDate, Page: 1, Limit: 10,
+			},
+			mock: func(filter entity.Filter) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(other_pvz_id, date, "Казань", other_reception_id, date, "close", product_id, date, "обувь").
+					AddRow(pvz_id, date.Add(-time.Hour), "Казань", nil, nil, nil, nil, nil, nil)
+				mock.ExpectQuery(`p.registration_date AS sort_key .* ORDER BY sort_key DESC`).
+					WithArgs(nil, nil, "close", "обувь", "Казань", user_id, true, nil, 10, 0, nil, nil).
+					WillReturnRows(rows)
+			},
+			expected: []entity.ListPVZ{
+				{
+					Pvz: entity.PVZ{ID: other_pvz_id, RegistrationDate: date, City: "Казань"},
+					Receptions: []entity.Receptions{
+						{ID: other_reception_id, DateTime: date, PVZID: other_pvz_id, Status: "close", Products: []entity.Products{
+							{ID: product_id, DateTime: date, Type: "обувь", ReceptionId: other_reception_id},
+						}},
+					},
+				},
+				{
+					Pvz:        entity.PVZ{ID: pvz_id, RegistrationDate: date.Add(-time.Hour), City: "Казань"},
+					Receptions: []entity.Receptions{},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.mock()
+			tt.mock(tt.filter)
 
-			pvz, err := storage.GetPVZsWithFilter(context.Background(), filter)
+			pvz, err := storage.GetPVZsWithFilter(context.Background(), tt.filter)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -239,3 +343,139 @@ func TestPVZPostgresStorage_GetPVZsWithFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestPVZPostgresStorage_CountPVZsWithFilter(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	pvz_id := uuid.Must(uuid.NewV4())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewPVZPostgresStorage(db)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM pvz p .* .* AND \(\$7::boolean OR EXISTS \(.*FROM reception r`).
+		WithArgs(nil, nil, "in_progress", "", "Москва", user_id, true, `{"`+pvz_id.String()+`"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := storage.CountPVZsWithFilter(context.Background(), entity.Filter{
+		Status: "in_progress", City: "Москва", UserID: &user_id, PVZIDs: []uuid.UUID{pvz_id}, IncludeEmpty: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPVZPostgresStorage_GetPVZReceptions(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	open_id := uuid.Must(uuid.NewV4())
+	closed_id := uuid.Must(uuid.NewV4())
+	product_id := uuid.Must(uuid.NewV4())
+	date := time.Now()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewPVZPostgresStorage(db)
+
+	rows := sqlmock.NewRows([]string{"reception_id", "date_time", "pvz_id", "status_name", "product_id", "date_time", "type_name"}).
+		AddRow(open_id, date, pvz_id, "in_progress", nil, nil, nil).
+		AddRow(closed_id, date.Add(-time.Hour), pvz_id, "close", product_id, date.Add(-time.Hour), "обувь")
+	mock.ExpectQuery("WITH page AS").WithArgs(pvz_id, 5, 5, nil, nil).WillReturnRows(rows)
+
+	receptions, err := storage.GetPVZReceptions(context.Background(), pvz_id, entity.ReceptionFilter{Page: 2, Limit: 5})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.Receptions{
+		{ID: open_id, DateTime: date, PVZID: pvz_id, Status: "in_progress", Products: []entity.Products{}},
+		{ID: closed_id, DateTime: date.Add(-time.Hour), PVZID: pvz_id, Status: "close", Products: []entity.Products{
+			{ID: product_id, DateTime: date.Add(-time.Hour), Type: "обувь", ReceptionId: closed_id},
+		}},
+	}, receptions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPVZPostgresStorage_GetPVZSummary(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	open_id := uuid.Must(uuid.NewV4())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewPVZPostgresStorage(db)
+
+	tests := []struct {
+		name     string
+		openID   any
+		expected *entity.PVZSummary
+	}{
+		{
+			name:     "open reception",
+			openID:   open_id,
+			expected: &entity.PVZSummary{Receptions: 4, Products: 17, OpenReceptionID: &open_id},
+		},
+		{
+			name:     "all receptions closed",
+			openID:   nil,
+			expected: &entity.PVZSummary{Receptions: 4, Products: 17},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery("SELECT").WithArgs(pvz_id).
+				WillReturnRows(sqlmock.NewRows([]string{"receptions", "products", "open"}).AddRow(4, 17, tt.openID))
+
+			summary, err := storage.GetPVZSummary(context.Background(), pvz_id)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, summary)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPVZPostgresStorage_Keyset(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	after := &entity.Keyset{Time: time.Now(), ID: uuid.Must(uuid.NewV4())}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewPVZPostgresStorage(db)
+
+	mock.ExpectQuery(`\(COALESCE\(MAX\(r.date_time\), p.registration_date\), p.pvz_id\) < \(\$11::timestamp, \$12\)`).
+		WithArgs(nil, nil, "", "", "", nil, false, nil, 10, 0, after.Time, after.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"pvz_id"}))
+	_, err = storage.GetPVZsWithFilter(context.Background(), entity.Filter{Page: 3, Limit: 10, After: after})
+	assert.NoError(t, err)
+
+	counted := &entity.Keyset{Count: 7, ID: after.ID}
+	mock.ExpectQuery(`\(COUNT\(pr.product_id\), p.pvz_id\) < \(\$11::bigint, \$12\)`).
+		WithArgs(nil, nil, "", "", "", nil, false, nil, 10, 0, 7, after.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"pvz_id"}))
+	_, err = storage.GetPVZsWithFilter(context.Background(), entity.Filter{Sort: entity.PVZSortProductCount, Limit: 10, After: counted})
+	assert.NoError(t, err)
+
+	_, err = storage.GetPVZsWithFilter(context.Background(), entity.Filter{Sort: "city", Limit: 10})
+	assert.EqualError(t, err, `unknown pvz sort "city"`)
+
+	mock.ExpectQuery(`\(date_time, reception_id\) < \(\$4, \$5::uuid\)`).
+		WithArgs(pvz_id, 10, 0, after.Time, after.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"reception_id"}))
+	_, err = storage.GetPVZReceptions(context.Background(), pvz_id, entity.ReceptionFilter{Limit: 10, After: after})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,48 +1,64 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"pvz/internal/storage/migrations/entity"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
 )
 
+// ErrReceptionInProgress возвращается, когда у ПВЗ уже есть незакрытая приёмка.
+// Ограничение обеспечивает частичный уникальный индекс reception_one_in_progress_per_pvz.
+var ErrReceptionInProgress = errors.New("pvz already has a reception in progress")
+
 type ReceptionPostgresStorage interface {
-	CreateReception(id uuid.UUID) (*entity.Receptions, error)
-	GetLastReceptionStatus(id uuid.UUID) (uuid.UUID, string, error)
-	UpdateReceptionStatus(reception_id uuid.UUID) error
-	GetReceptionById(reception_id uuid.UUID) (*entity.Receptions, error)
+	CreateReception(ctx context.Context, id uuid.UUID, actor entity.Actor) (*entity.Receptions, error)
+	GetLastReceptionStatus(ctx context.Context, id uuid.UUID) (uuid.UUID, string, error)
+	UpdateReceptionStatus(ctx context.Context, reception_id uuid.UUID, actor entity.Actor) error
+	GetReceptionById(ctx context.Context, reception_id uuid.UUID) (*entity.Receptions, error)
 }
 
 type ReceptionPostgresStorageImpl struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewReceptionPostgresStorage(db *sql.DB) *ReceptionPostgresStorageImpl {
+func NewReceptionPostgresStorage(db DBTX) *ReceptionPostgresStorageImpl {
 	return &ReceptionPostgresStorageImpl{db: db}
 }
 
-func (r *ReceptionPostgresStorageImpl) CreateReception(id uuid.UUID) (*entity.Receptions, error) {
-	reception_id := uuid.Must(uuid.NewV4())
-	date := time.Now()
-	status := "in_progress"
+func (r *ReceptionPostgresStorageImpl) CreateReception(ctx context.Context, id uuid.UUID, actor entity.Actor) (*entity.Receptions, error) {
+	reception := &entity.Receptions{ID: uuid.Must(uuid.NewV4()), DateTime: time.Now(), PVZID: id, Status: "in_progress"}
 	query := "INSERT INTO reception (reception_id, date_time, pvz_id, status_name) VALUES ($1, $2, $3, $4)"
 
-	_, err := r.db.Exec(query, reception_id, date, id, status)
+	err := atomic(ctx, r.db, func(tx DBTX) error {
+		_, err := tx.ExecContext(ctx, query, reception.ID, reception.DateTime, id, reception.Status)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "reception_one_in_progress_per_pvz" {
+			return ErrReceptionInProgress
+		} else if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actor, AuditReceptionOpen, AuditEntityReception, reception.ID, nil, reception)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &entity.Receptions{ID: reception_id, DateTime: date, PVZID: id, Status: status}, nil
+	return reception, nil
 }
 
-func (r *ReceptionPostgresStorageImpl) GetLastReceptionStatus(id uuid.UUID) (uuid.UUID, string, error) {
+// GetLastReceptionStatus блокирует строку последней приёмки до конца транзакции,
+// чтобы её нельзя было закрыть, пока в неё добавляется или из неё удаляется товар.
+func (r *ReceptionPostgresStorageImpl) GetLastReceptionStatus(ctx context.Context, id uuid.UUID) (uuid.UUID, string, error) {
 	var status string
 	var reception_id uuid.UUID
-	query := "SELECT reception_id, status_name FROM reception WHERE pvz_id = $1 ORDER BY date_time DESC LIMIT 1"
+	query := "SELECT reception_id, status_name FROM reception WHERE pvz_id = $1 ORDER BY date_time DESC LIMIT 1 FOR UPDATE"
 
-	err := r.db.QueryRow(query, id).Scan(&reception_id, &status)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&reception_id, &status)
 	if err != nil || err == sql.ErrNoRows {
 		if err == sql.ErrNoRows {
 			return uuid.UUID{}, "", sql.ErrNoRows
@@ -53,24 +69,32 @@ func (r *ReceptionPostgresStorageImpl) GetLastReceptionStatus(id uuid.UUID) (uui
 	return reception_id, status, err
 }
 
-func (r *ReceptionPostgresStorageImpl) UpdateReceptionStatus(reception_id uuid.UUID) error {
-	query := "UPDATE reception SET status_name = 'close' WHERE reception_id = $1"
+func (r *ReceptionPostgresStorageImpl) UpdateReceptionStatus(ctx context.Context, reception_id uuid.UUID, actor entity.Actor) error {
+	return atomic(ctx, r.db, func(tx DBTX) error {
+		var before entity.Receptions
+		query := "SELECT reception_id, date_time, pvz_id, status_name FROM reception WHERE reception_id = $1 FOR UPDATE"
+		err := tx.QueryRowContext(ctx, query, reception_id).Scan(&before.ID, &before.DateTime, &before.PVZID, &before.Status)
+		if err != nil {
+			return err
+		}
 
-	_, err := r.db.Exec(query, reception_id)
-	if err != nil {
-		return err
-	}
+		if _, err := tx.ExecContext(ctx, "UPDATE reception SET status_name = 'close' WHERE reception_id = $1", reception_id); err != nil {
+			return err
+		}
 
-	return nil
+		after := before
+		after.Status = "close"
+		return recordAudit(ctx, tx, actor, AuditReceptionClose, AuditEntityReception, reception_id, before, after)
+	})
 }
 
-func (r *ReceptionPostgresStorageImpl) GetReceptionById(reception_id uuid.UUID) (*entity.Receptions, error) {
+func (r *ReceptionPostgresStorageImpl) GetReceptionById(ctx context.Context, reception_id uuid.UUID) (*entity.Receptions, error) {
 	query := "SELECT * FROM reception WHERE reception_id = $1"
 	var date time.Time
 	var pvz_id uuid.UUID
 	var status string
 
-	err := r.db.QueryRow(query, reception_id).Scan(&reception_id, &date, &pvz_id, &status)
+	err := r.db.QueryRowContext(ctx, query, reception_id).Scan(&reception_id, &date, &pvz_id, &status)
 	if err != nil {
 		return nil, err
 	}
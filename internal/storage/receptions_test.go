@@ -1,6 +1,7 @@
 package storage_test
 
 import (
+	"context"
 	"database/sql"
 	"pvz/internal/storage"
 	"pvz/internal/storage/migrations/entity"
@@ -9,11 +10,14 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestReceptionPostgresStorage_CreateReception(t *testing.T) {
 	pvz_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4())}
+	errInProgress := storage.ErrReceptionInProgress
 
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -33,18 +37,35 @@ func TestReceptionPostgresStorage_CreateReception(t *testing.T) {
 			name: "success",
 			id:   pvz_id,
 			mock: func() {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO reception").
 					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pvz_id, "in_progress").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(actor.UserID, "reception.open", "reception", sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "", "", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 			expectedErr: nil,
 		},
+		{
+			name: "reception already in progress",
+			id:   pvz_id,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO reception").
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pvz_id, "in_progress").
+					WillReturnError(&pq.Error{Code: "23505", Constraint: "reception_one_in_progress_per_pvz"})
+				mock.ExpectRollback()
+			},
+			expectedErr: errInProgress,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			reception, err := storage.CreateReception(tt.id)
+			reception, err := storage.CreateReception(context.Background(), tt.id, actor)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -113,7 +134,7 @@ func TestReceptionPostgresStorage_GetLastReceptionStatus(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			actualID, actualStatus, err := storage.GetLastReceptionStatus(tt.input)
+			actualID, actualStatus, err := storage.GetLastReceptionStatus(context.Background(), tt.input)
 
 			assert.Equal(t, tt.expectedID, actualID)
 			assert.Equal(t, tt.expectedStatus, actualStatus)
@@ -173,7 +194,7 @@ func TestReceptionPostgresStorage_GetReceptionByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			reception, err := storage.GetReceptionById(tt.input)
+			reception, err := storage.GetReceptionById(context.Background(), tt.input)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"pvz/internal/storage/migrations/entity"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type TokensPostgresStorage interface {
+	CreateRefreshToken(ctx context.Context, id, user_id uuid.UUID, hash string, expires_at time.Time) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, id uuid.UUID) (bool, error)
+	RevokeUserRefreshTokens(ctx context.Context, user_id uuid.UUID) error
+	RevokeAccessToken(ctx context.Context, jti uuid.UUID, expires_at time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error)
+}
+
+type TokensPostgresStorageImpl struct {
+	db DBTX
+}
+
+func NewTokensPostgresStorage(db DBTX) *TokensPostgresStorageImpl {
+	return &TokensPostgresStorageImpl{db: db}
+}
+
+func (t *TokensPostgresStorageImpl) CreateRefreshToken(ctx context.Context, id, user_id uuid.UUID, hash string, expires_at time.Time) error {
+	query := "INSERT INTO refresh_tokens (token_id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)"
+
+	_, err := t.db.ExecContext(ctx, query, id, user_id, hash, expires_at)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *TokensPostgresStorageImpl) GetRefreshTokenByHash(ctx context.Context, hash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	query := "SELECT token_id, user_id, token_hash, expires_at, revoked FROM refresh_tokens WHERE token_hash = $1"
+
+	err := t.db.QueryRowContext(ctx, query, hash).Scan(&token.ID, &token.UserID, &token.Hash, &token.ExpiresAt, &token.Revoked)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeRefreshToken возвращает false, если токен уже был отозван ранее.
+func (t *TokensPostgresStorageImpl) RevokeRefreshToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := "UPDATE refresh_tokens SET revoked = TRUE WHERE token_id = $1 AND revoked = FALSE"
+
+	res, err := t.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (t *TokensPostgresStorageImpl) RevokeUserRefreshTokens(ctx context.Context, user_id uuid.UUID) error {
+	query := "UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1 AND revoked = FALSE"
+
+	_, err := t.db.ExecContext(ctx, query, user_id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *TokensPostgresStorageImpl) RevokeAccessToken(ctx context.Context, jti uuid.UUID, expires_at time.Time) error {
+	query := "INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING"
+
+	_, err := t.db.ExecContext(ctx, query, jti, expires_at)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *TokensPostgresStorageImpl) IsAccessTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	var revoked bool
+	query := "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)"
+
+	err := t.db.QueryRowContext(ctx, query, jti).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
@@ -0,0 +1,145 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokensPostgresStorage_GetRefreshTokenByHash(t *testing.T) {
+	token_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
+	expires_at := time.Now().Add(time.Hour)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewTokensPostgresStorage(db)
+
+	tests := []struct {
+		name        string
+		hash        string
+		mock        func()
+		expected    *entity.RefreshToken
+		expectedErr error
+	}{
+		{
+			name: "success",
+			hash: "hash",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"token_id", "user_id", "token_hash", "expires_at", "revoked"}).
+					AddRow(token_id, user_id, "hash", expires_at, false)
+				mock.ExpectQuery("SELECT token_id, user_id, token_hash, expires_at, revoked FROM refresh_tokens WHERE token_hash = \\$1").
+					WithArgs("hash").WillReturnRows(rows)
+			},
+			expected: &entity.RefreshToken{
+				ID:        token_id,
+				UserID:    user_id,
+				Hash:      "hash",
+				ExpiresAt: expires_at,
+				Revoked:   false,
+			},
+		},
+		{
+			name: "not found",
+			hash: "hash",
+			mock: func() {
+				mock.ExpectQuery("SELECT token_id, user_id, token_hash, expires_at, revoked FROM refresh_tokens WHERE token_hash = \\$1").
+					WithArgs("hash").WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			token, err := storage.GetRefreshTokenByHash(context.Background(), tt.hash)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expected, token)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTokensPostgresStorage_RevokeRefreshToken(t *testing.T) {
+	token_id := uuid.Must(uuid.NewV4())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewTokensPostgresStorage(db)
+
+	tests := []struct {
+		name     string
+		mock     func()
+		expected bool
+	}{
+		{
+			name: "revoked",
+			mock: func() {
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = TRUE").
+					WithArgs(token_id).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expected: true,
+		},
+		{
+			name: "already revoked",
+			mock: func() {
+				mock.ExpectExec("UPDATE refresh_tokens SET revoked = TRUE").
+					WithArgs(token_id).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			ok, err := storage.RevokeRefreshToken(context.Background(), token_id)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTokensPostgresStorage_IsAccessTokenRevoked(t *testing.T) {
+	jti := uuid.Must(uuid.NewV4())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewTokensPostgresStorage(db)
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM revoked_tokens WHERE jti = \\$1\\)").
+		WithArgs(jti).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	revoked, err := storage.IsAccessTokenRevoked(context.Background(), jti)
+
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
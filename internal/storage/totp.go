@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage/migrations/entity"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type TOTPPostgresStorage interface {
+	GetTOTP(ctx context.Context, user_id uuid.UUID) (*entity.TOTP, error)
+	SaveTOTP(ctx context.Context, user_id uuid.UUID, secret string) error
+	ConfirmTOTP(ctx context.Context, user_id uuid.UUID, recoveryHashes []string) error
+	UseTOTPStep(ctx context.Context, user_id uuid.UUID, step int64) (bool, error)
+	RegisterTOTPFailure(ctx context.Context, user_id uuid.UUID, now time.Time, window time.Duration) error
+	UseRecoveryCode(ctx context.Context, user_id uuid.UUID, hash string) (bool, error)
+}
+
+type TOTPPostgresStorageImpl struct {
+	db DBTX
+}
+
+func NewTOTPPostgresStorage(db DBTX) *TOTPPostgresStorageImpl {
+	return &TOTPPostgresStorageImpl{db: db}
+}
+
+func (t *TOTPPostgresStorageImpl) GetTOTP(ctx context.Context, user_id uuid.UUID) (*entity.TOTP, error) {
+	var totp entity.TOTP
+	var last_failure_at sql.NullTime
+	query := `
+		SELECT user_id, secret, confirmed, last_used_step, failed_attempts, last_failure_at
+		FROM user_totp
+		WHERE user_id = $1
+	`
+
+	err := t.db.QueryRowContext(ctx, query, user_id).Scan(&totp.UserID, &totp.Secret, &totp.Confirmed, &totp.LastUsedStep, &totp.FailedAttempts, &last_failure_at)
+	if err != nil {
+		return nil, err
+	}
+	totp.LastFailureAt = last_failure_at.Time
+	return &totp, nil
+}
+
+// SaveTOTP заменяет секрет только у неподтверждённой регистрации.
+func (t *TOTPPostgresStorageImpl) SaveTOTP(ctx context.Context, user_id uuid.UUID, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = $2, last_used_step = 0, failed_attempts = 0
+		WHERE user_totp.confirmed = FALSE
+	`
+
+	res, err := t.db.ExecContext(ctx, query, user_id, secret)
+	if err != nil {
+		return err
+	}
+	return checkAffected(res)
+}
+
+// ConfirmTOTP включает второй фактор и заменяет коды восстановления
+// в одной транзакции.
+func (t *TOTPPostgresStorageImpl) ConfirmTOTP(ctx context.Context, user_id uuid.UUID, recoveryHashes []string) error {
+	return atomic(ctx, t.db, func(tx DBTX) error {
+		res, err := tx.ExecContext(ctx, "UPDATE user_totp SET confirmed = TRUE WHERE user_id = $1", user_id)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(res); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM totp_recovery_codes WHERE user_id = $1", user_id); err != nil {
+			return err
+		}
+
+		for _, hash := range recoveryHashes {
+			query := "INSERT INTO totp_recovery_codes (code_id, user_id, code_hash) VALUES ($1, $2, $3)"
+			if _, err := tx.ExecContext(ctx, query, uuid.Must(uuid.NewV4()), user_id, hash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UseTOTPStep запоминает использованный временной шаг, чтобы один и тот же
+// код нельзя было предъявить дважды. Возвращает false для уже использованного шага.
+func (t *TOTPPostgresStorageImpl) UseTOTPStep(ctx context.Context, user_id uuid.UUID, step int64) (bool, error) {
+	query := `
+		UPDATE user_totp SET last_used_step = $2, failed_attempts = 0
+		WHERE user_id = $1 AND last_used_step < $2
+	`
+
+	res, err := t.db.ExecContext(ctx, query, user_id, step)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (t *TOTPPostgresStorageImpl) RegisterTOTPFailure(ctx context.Context, user_id uuid.UUID, now time.Time, window time.Duration) error {
+	query := `
+		UPDATE user_totp SET
+			failed_attempts = CASE WHEN last_failure_at IS NULL OR last_failure_at < $3 THEN 1 ELSE failed_attempts + 1 END,
+			last_failure_at = $2
+		WHERE user_id = $1
+	`
+
+	_, err := t.db.ExecContext(ctx, query, user_id, now, now.Add(-window))
+	return err
+}
+
+func (t *TOTPPostgresStorageImpl) UseRecoveryCode(ctx context.Context, user_id uuid.UUID, hash string) (bool, error) {
+	query := "UPDATE totp_recovery_codes SET used = TRUE WHERE user_id = $1 AND code_hash = $2 AND used = FALSE"
+
+	res, err := t.db.ExecContext(ctx, query, user_id, hash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
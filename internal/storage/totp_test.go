@@ -0,0 +1,67 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPPostgresStorage_ConfirmTOTP(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewTOTPPostgresStorage(db)
+
+	tests := []struct {
+		name        string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE user_totp SET confirmed = TRUE WHERE user_id = \\$1").
+					WithArgs(user_id).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM totp_recovery_codes WHERE user_id = \\$1").
+					WithArgs(user_id).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO totp_recovery_codes").
+					WithArgs(sqlmock.AnyArg(), user_id, "hash1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO totp_recovery_codes").
+					WithArgs(sqlmock.AnyArg(), user_id, "hash2").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "not enrolled",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE user_totp SET confirmed = TRUE").
+					WithArgs(user_id).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := storage.ConfirmTOTP(context.Background(), user_id, []string{"hash1", "hash2"})
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBTX — общий для *sql.DB и *sql.Tx набор методов. Хранилища, принимающие
+// DBTX, можно использовать как отдельно, так и внутри транзакции.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Tx выдаёт хранилища, работающие в одной транзакции.
+type Tx interface {
+	PVZ() PVZPostgresStorage
+	Receptions() ReceptionPostgresStorage
+	Products() ProductPostgresStorage
+	Users() UsersPostgresStorage
+	Tokens() TokensPostgresStorage
+	PasswordResets() PasswordResetsPostgresStorage
+}
+
+type TxManager interface {
+	WithTx(ctx context.Context, fn func(tx Tx) error) error
+}
+
+type TxManagerImpl struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *TxManagerImpl {
+	return &TxManagerImpl{db: db}
+}
+
+// WithTx фиксирует транзакцию, если fn завершилась без ошибки, иначе откатывает её.
+func (m *TxManagerImpl) WithTx(ctx context.Context, fn func(tx Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&txStorages{tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type txStorages struct {
+	tx *sql.Tx
+}
+
+func (t *txStorages) PVZ() PVZPostgresStorage {
+	return NewPVZPostgresStorage(t.tx)
+}
+
+func (t *txStorages) Receptions() ReceptionPostgresStorage {
+	return NewReceptionPostgresStorage(t.tx)
+}
+
+func (t *txStorages) Products() ProductPostgresStorage {
+	return NewProductPostgresStorage(t.tx)
+}
+
+func (t *txStorages) Users() UsersPostgresStorage {
+	return NewUsersStorage(t.tx)
+}
+
+func (t *txStorages) Tokens() TokensPostgresStorage {
+	return NewTokensPostgresStorage(t.tx)
+}
+
+func (t *txStorages) PasswordResets() PasswordResetsPostgresStorage {
+	return NewPasswordResetsPostgresStorage(t.tx)
+}
+
+// atomic выполняет fn в транзакции. Если хранилище уже работает внутри
+// транзакции, fn выполняется в ней же.
+func atomic(ctx context.Context, db DBTX, fn func(tx DBTX) error) error {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxManager_WithTx(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	reception_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4())}
+
+	t.Run("product is added under reception lock in one transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT reception_id, status_name FROM reception WHERE pvz_id = \\$1 ORDER BY date_time DESC LIMIT 1 FOR UPDATE").
+			WithArgs(pvz_id).
+			WillReturnRows(sqlmock.NewRows([]string{"reception_id", "status_name"}).AddRow(reception_id, "in_progress"))
+		mock.ExpectExec("INSERT INTO product").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "обувь", reception_id).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err = storage.NewTxManager(db).WithTx(context.Background(), func(tx storage.Tx) error {
+			id, _, err := tx.Receptions().GetLastReceptionStatus(context.Background(), pvz_id)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Products().CreateProduct(context.Background(), id, "обувь", actor)
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error rolls back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		closed := errors.New("no available receptions")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT reception_id, status_name FROM reception WHERE pvz_id = \\$1 ORDER BY date_time DESC LIMIT 1 FOR UPDATE").
+			WithArgs(pvz_id).
+			WillReturnRows(sqlmock.NewRows([]string{"reception_id", "status_name"}).AddRow(reception_id, "close"))
+		mock.ExpectRollback()
+
+		err = storage.NewTxManager(db).WithTx(context.Background(), func(tx storage.Tx) error {
+			_, status, err := tx.Receptions().GetLastReceptionStatus(context.Background(), pvz_id)
+			if err != nil {
+				return err
+			}
+			if status == "close" {
+				return closed
+			}
+			return nil
+		})
+
+		assert.Equal(t, closed, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -1,29 +1,43 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"pvz/internal/storage/migrations/entity"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
 )
 
+var ErrUserInUse = errors.New("user is referenced by other records")
+
 type UsersPostgresStorage interface {
-	GetUserByEmail(email string) (*entity.User, bool, error)
-	CreateUser(email, password, role string) (*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, bool, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	CreateUser(ctx context.Context, email, password, role string, actor entity.Actor) (*entity.User, error)
+	ListUsers(ctx context.Context, filter entity.UserFilter) ([]entity.User, error)
+	CountUsers(ctx context.Context, filter entity.UserFilter) (int, error)
+	LockActiveUsers(ctx context.Context, roles []string) ([]uuid.UUID, error)
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role string, actor entity.Actor) error
+	SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool, actor entity.Actor) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, password string, reset bool, actor entity.Actor) error
+	DeleteUser(ctx context.Context, id uuid.UUID, actor entity.Actor) error
 }
 
 type UsersPostgresStorageImpl struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewUsersStorage(db *sql.DB) *UsersPostgresStorageImpl {
+func NewUsersStorage(db DBTX) *UsersPostgresStorageImpl {
 	return &UsersPostgresStorageImpl{db: db}
 }
 
-func (u *UsersPostgresStorageImpl) GetUserByEmail(email string) (*entity.User, bool, error) {
+func (u *UsersPostgresStorageImpl) GetUserByEmail(ctx context.Context, email string) (*entity.User, bool, error) {
 	var user entity.User
-	query := "SELECT * FROM users WHERE email = $1"
-	err := u.db.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Password, &user.Role)
+	query := "SELECT user_id, email, password_hash, role_name, disabled FROM users WHERE email = $1"
+	err := u.db.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.Disabled)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, false, err
 	} else if err == sql.ErrNoRows {
@@ -33,12 +47,190 @@ func (u *UsersPostgresStorageImpl) GetUserByEmail(email string) (*entity.User, b
 	return &user, true, nil
 }
 
-func (u *UsersPostgresStorageImpl) CreateUser(email, password, role string) (*entity.User, error) {
-	id := uuid.Must(uuid.NewV4())
+func (u *UsersPostgresStorageImpl) GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	var user entity.User
+	query := "SELECT user_id, email, password_hash, role_name, disabled FROM users WHERE user_id = $1"
+	err := u.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.Disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// CreateUser без автора в actor считает, что пользователь зарегистрировался сам.
+func (u *UsersPostgresStorageImpl) CreateUser(ctx context.Context, email, password, role string, actor entity.Actor) (*entity.User, error) {
+	user := &entity.User{ID: uuid.Must(uuid.NewV4()), Email: email, Password: string(password), Role: role}
+	if actor.UserID.IsNil() {
+		actor.UserID = user.ID
+	}
 	query := "INSERT INTO users (user_id, email, password_hash, role_name) VALUES ($1, $2, $3, $4)"
-	_, err := u.db.Exec(query, id, email, password, role)
+
+	err := atomic(ctx, u.db, func(tx DBTX) error {
+		if _, err := tx.ExecContext(ctx, query, user.ID, email, password, role); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actor, AuditUserRegister, AuditEntityUser, user.ID, nil, user)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &entity.User{ID: id, Email: email, Password: string(password), Role: role}, nil
+	return user, nil
+}
+
+func (u *UsersPostgresStorageImpl) ListUsers(ctx context.Context, filter entity.UserFilter) ([]entity.User, error) {
+	query := `
+		SELECT user_id, email, password_hash, role_name, disabled
+		FROM users
+		WHERE ($1 = '' OR role_name::text = $1)
+		ORDER BY email
+		LIMIT $2 OFFSET $3
+	`
+
+	offset := (filter.Page - 1) * filter.Limit
+
+	rows, err := u.db.QueryContext(ctx, query, filter.Role, filter.Limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []entity.User{}
+	for rows.Next() {
+		var user entity.User
+		if err := rows.Scan(&user.ID, &user.Email, &user.Password, &user.Role, &user.Disabled); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return users, nil
+}
+
+func (u *UsersPostgresStorageImpl) CountUsers(ctx context.Context, filter entity.UserFilter) (int, error) {
+	query := "SELECT COUNT(*) FROM users WHERE ($1 = '' OR role_name::text = $1)"
+
+	var count int
+	err := u.db.QueryRowContext(ctx, query, filter.Role).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count, nil
+}
+
+// LockActiveUsers возвращает включённых пользователей с ролями из roles и
+// блокирует их строки до конца транзакции: два параллельных понижения не
+// могут оба решить, что после них останется другой администратор.
+func (u *UsersPostgresStorageImpl) LockActiveUsers(ctx context.Context, roles []string) ([]uuid.UUID, error) {
+	query := "SELECT user_id FROM users WHERE NOT disabled AND role_name = ANY($1) ORDER BY user_id FOR UPDATE"
+
+	rows, err := u.db.QueryContext(ctx, query, pq.Array(roles))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (u *UsersPostgresStorageImpl) UpdateUserRole(ctx context.Context, id uuid.UUID, role string, actor entity.Actor) error {
+	return u.updateUser(ctx, id, actor, AuditUserRoleChange, "UPDATE users SET role_name = $2 WHERE user_id = $1", role, func(user *entity.User) {
+		user.Role = role
+	})
+}
+
+func (u *UsersPostgresStorageImpl) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool, actor entity.Actor) error {
+	action := AuditUserEnable
+	if disabled {
+		action = AuditUserDisable
+	}
+	return u.updateUser(ctx, id, actor, action, "UPDATE users SET disabled = $2 WHERE user_id = $1", disabled, func(user *entity.User) {
+		user.Disabled = disabled
+	})
+}
+
+// updateUser меняет одно поле пользователя и записывает состояние до и после изменения.
+func (u *UsersPostgresStorageImpl) updateUser(ctx context.Context, id uuid.UUID, actor entity.Actor, action, query string, value any, apply func(*entity.User)) error {
+	return atomic(ctx, u.db, func(tx DBTX) error {
+		var before entity.User
+		selectQuery := "SELECT user_id, email, password_hash, role_name, disabled FROM users WHERE user_id = $1"
+		err := tx.QueryRowContext(ctx, selectQuery, id).Scan(&before.ID, &before.Email, &before.Password, &before.Role, &before.Disabled)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, id, value); err != nil {
+			return err
+		}
+
+		after := before
+		apply(&after)
+		return recordAudit(ctx, tx, actor, action, AuditEntityUser, id, before, after)
+	})
+}
+
+// UpdatePassword записывает в аудит только сам факт смены: хэш пароля
+// в журнал не попадает ни до, ни после.
+func (u *UsersPostgresStorageImpl) UpdatePassword(ctx context.Context, id uuid.UUID, password string, reset bool, actor entity.Actor) error {
+	query := "UPDATE users SET password_hash = $2 WHERE user_id = $1"
+	action := AuditUserPasswordChange
+	if reset {
+		action = AuditUserPasswordReset
+	}
+
+	return atomic(ctx, u.db, func(tx DBTX) error {
+		res, err := tx.ExecContext(ctx, query, id, password)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(res); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actor, action, AuditEntityUser, id, nil, nil)
+	})
+}
+
+func (u *UsersPostgresStorageImpl) DeleteUser(ctx context.Context, id uuid.UUID, actor entity.Actor) error {
+	query := "DELETE FROM users WHERE user_id = $1 RETURNING user_id, email, role_name, disabled"
+
+	return atomic(ctx, u.db, func(tx DBTX) error {
+		var before entity.User
+		err := tx.QueryRowContext(ctx, query, id).Scan(&before.ID, &before.Email, &before.Role, &before.Disabled)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrUserInUse
+		} else if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actor, AuditUserDelete, AuditEntityUser, id, before, nil)
+	})
+}
+
+func checkAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
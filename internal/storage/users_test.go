@@ -1,11 +1,14 @@
 package storage_test
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
 
 	"github.com/stretchr/testify/assert"
 
@@ -33,9 +36,9 @@ func TestUsersStorage_GetUserByEmail(t *testing.T) {
 			name:  "success",
 			email: "test@example.com",
 			mock: func() {
-				rows := sqlmock.NewRows([]string{"user_id", "email", "password_hash", "role_name"}).
-					AddRow("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", "test@example.com", "hash123", "moderator")
-				mock.ExpectQuery("SELECT \\* FROM users WHERE email = \\$1").
+				rows := sqlmock.NewRows([]string{"user_id", "email", "password_hash", "role_name", "disabled"}).
+					AddRow("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", "test@example.com", "hash123", "moderator", false)
+				mock.ExpectQuery("SELECT user_id, email, password_hash, role_name, disabled FROM users WHERE email = \\$1").
 					WithArgs("test@example.com").
 					WillReturnRows(rows)
 			},
@@ -51,7 +54,7 @@ func TestUsersStorage_GetUserByEmail(t *testing.T) {
 			name:  "not found",
 			email: "test@example.com",
 			mock: func() {
-				mock.ExpectQuery("SELECT \\* FROM users WHERE email = \\$1").
+				mock.ExpectQuery("SELECT user_id, email, password_hash, role_name, disabled FROM users WHERE email = \\$1").
 					WithArgs("test@example.com").
 					WillReturnError(fmt.Errorf("user not exists"))
 			},
@@ -63,7 +66,7 @@ func TestUsersStorage_GetUserByEmail(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			user, _, err := storage.GetUserByEmail(tt.email)
+			user, _, err := storage.GetUserByEmail(context.Background(), tt.email)
 
 			assert.Equal(t, tt.expectedErr, err)
 			assert.Equal(t, tt.expected, user)
@@ -96,8 +99,13 @@ func TestUsersStorage_CreateUser(t *testing.T) {
 			password: "hash123",
 			role:     "moderator",
 			mock: func() {
+				mock.ExpectBegin()
 				mock.ExpectExec("INSERT INTO users").
 					WithArgs(sqlmock.AnyArg(), "test@example.com", "hash123", "moderator").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(sqlmock.AnyArg(), "user.register", "user", sqlmock.AnyArg(), nil, sqlmock.AnyArg(), "", "", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 
 			},
 			expectedErr: nil,
@@ -108,7 +116,7 @@ func TestUsersStorage_CreateUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			user, err := storage.CreateUser(tt.email, tt.password, tt.role)
+			user, err := storage.CreateUser(context.Background(), tt.email, tt.password, tt.role, entity.Actor{})
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -127,3 +135,184 @@ func TestUsersStorage_CreateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUsersStorage_ListUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewUsersStorage(db)
+	user_id := uuid.Must(uuid.NewV4())
+
+	filter := entity.UserFilter{Role: "employee", Page: 2, Limit: 10}
+
+	rows := sqlmock.NewRows([]string{"user_id", "email", "password_hash", "role_name", "disabled"}).
+		AddRow(user_id, "employee@example.com", "hash123", "employee", true)
+	mock.ExpectQuery("SELECT user_id, email, password_hash, role_name, disabled FROM users WHERE .* ORDER BY email LIMIT \\$2 OFFSET \\$3").
+		WithArgs("employee", 10, 10).
+		WillReturnRows(rows)
+
+	users, err := storage.ListUsers(context.Background(), filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.User{
+		{ID: user_id, Email: "employee@example.com", Password: "hash123", Role: "employee", Disabled: true},
+	}, users)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersStorage_LockActiveUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := storage.NewUsersStorage(db)
+	user_id := uuid.Must(uuid.NewV4())
+
+	rows := sqlmock.NewRows([]string{"user_id"}).AddRow(user_id)
+	mock.ExpectQuery("SELECT user_id FROM users WHERE NOT disabled AND role_name = ANY\\(\\$1\\) ORDER BY user_id FOR UPDATE").
+		WithArgs(pq.Array([]string{"admin"})).
+		WillReturnRows(rows)
+
+	ids, err := storage.LockActiveUsers(context.Background(), []string{"admin"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{user_id}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersStorage_DeleteUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	usersStorage := storage.NewUsersStorage(db)
+	user_id := uuid.Must(uuid.NewV4())
+	moderator_id := uuid.Must(uuid.NewV4())
+
+	tests := []struct {
+		name        string
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name: "success",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM users WHERE user_id = \\$1 RETURNING").
+					WithArgs(user_id).WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "role_name", "disabled"}).
+					AddRow(user_id, "employee@example.com", "employee", false))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(moderator_id, storage.AuditUserDelete, storage.AuditEntityUser, user_id, sqlmock.AnyArg(), nil, "", "", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "not found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM users WHERE user_id = \\$1 RETURNING").
+					WithArgs(user_id).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+		{
+			name: "user created pvz",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM users WHERE user_id = \\$1 RETURNING").
+					WithArgs(user_id).WillReturnError(&pq.Error{Code: "23503"})
+				mock.ExpectRollback()
+			},
+			expectedErr: storage.ErrUserInUse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := usersStorage.DeleteUser(context.Background(), user_id, entity.Actor{UserID: moderator_id})
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUsersStorage_UpdatePassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	usersStorage := storage.NewUsersStorage(db)
+	user_id := uuid.Must(uuid.NewV4())
+
+	tests := []struct {
+		name        string
+		reset       bool
+		actor       entity.Actor
+		mock        func()
+		expectedErr error
+	}{
+		{
+			name:  "change",
+			actor: entity.Actor{UserID: user_id, RequestID: "request", IP: "10.0.0.1"},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET password_hash = \\$2 WHERE user_id = \\$1").
+					WithArgs(user_id, "hash").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(user_id, storage.AuditUserPasswordChange, storage.AuditEntityUser, user_id, nil, nil, "request", "10.0.0.1", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "reset by anonymous request",
+			reset: true,
+			actor: entity.Actor{RequestID: "request", IP: "10.0.0.1"},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET password_hash").
+					WithArgs(user_id, "hash").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO audit_events").
+					WithArgs(nil, storage.AuditUserPasswordReset, storage.AuditEntityUser, user_id, nil, nil, "request", "10.0.0.1", nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "not found",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users SET password_hash").
+					WithArgs(user_id, "hash").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedErr: sql.ErrNoRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := usersStorage.UpdatePassword(context.Background(), user_id, "hash", tt.reset, tt.actor)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
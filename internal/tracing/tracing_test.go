@@ -0,0 +1,67 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"pvz/internal/config"
+	"pvz/internal/tracing"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestQueryHook(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	parentCtx, parent := provider.Tracer("test").Start(context.Background(), "PVZUsecase.GetPVZsWithFilter")
+
+	ctx, done := tracing.QueryHook(parentCtx, "select pvz", "SELECT * FROM pvz")
+	assert.True(t, trace.SpanFromContext(ctx).SpanContext().IsValid())
+	done(nil)
+	_, done = tracing.QueryHook(parentCtx, "select reception", "SELECT * FROM reception")
+	done(errors.New("canceling statement due to user request"))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, "select pvz", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("db.query.text", "SELECT * FROM pvz"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "select reception", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	cfg := config.Default().Tracing
+
+	shutdown, err := tracing.Setup(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	cfg.Exporter = "stdout"
+	shutdown, err = tracing.Setup(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	cfg.Exporter = "jaeger"
+	_, err = tracing.Setup(context.Background(), cfg)
+	assert.Error(t, err)
+}
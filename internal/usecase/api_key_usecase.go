@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"pvz/internal/apperr"
+	"pvz/internal/logging"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const apiKeyPrefix = "pvz_"
+
+var (
+	ErrInvalidAPIKey  = apperr.Unauthorized("invalid_api_key", "invalid api key")
+	ErrAPIKeyNotFound = apperr.NotFound("api_key_not_found", "api key not found")
+	// ErrAPIKeyNotAllowed: ключами управляют только пользователи, иначе ключ
+	// с apikey:manage мог бы выпускать новые ключи.
+	ErrAPIKeyNotAllowed  = apperr.Forbidden("api_key_not_allowed", "API key cannot manage API keys")
+	ErrAPIKeyOwnerDenied = apperr.Forbidden("api_key_owner_denied", "cannot issue a key for a user with permissions you do not have")
+)
+
+type APIKeyInput struct {
+	Name        string
+	UserID      uuid.UUID
+	Permissions []Permission
+	PVZIDs      []uuid.UUID
+	ExpiresAt   *time.Time
+}
+
+// CreatedAPIKey содержит ключ целиком. Он возвращается только при создании,
+// в базе хранится лишь его хэш.
+type CreatedAPIKey struct {
+	entity.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyPrincipal описывает, от чьего имени и с какими ограничениями
+// выполняется запрос по API ключу.
+type APIKeyPrincipal struct {
+	KeyID       uuid.UUID
+	UserID      uuid.UUID
+	CreatedBy   uuid.UUID
+	Role        string
+	Permissions []Permission
+	PVZIDs      []uuid.UUID
+}
+
+type APIKeyUsecase interface {
+	CreateKey(ctx context.Context, actor entity.Actor, input APIKeyInput) (*CreatedAPIKey, error)
+	ListKeys(ctx context.Context) ([]entity.APIKey, error)
+	RevokeKey(ctx context.Context, actor entity.Actor, id uuid.UUID) error
+	Authenticate(ctx context.Context, rawKey string) (*APIKeyPrincipal, error)
+}
+
+type APIKeyUsecaseImpl struct {
+	apiKeyStorage storage.APIKeysPostgresStorage
+	userStorage   storage.UsersPostgresStorage
+	pvzStorage    storage.PVZPostgresStorage
+	policy        *Policy
+}
+
+func NewAPIKeyUsecase(apiKeyStorage storage.APIKeysPostgresStorage, userStorage storage.UsersPostgresStorage, pvzStorage storage.PVZPostgresStorage, policy *Policy) *APIKeyUsecaseImpl {
+	return &APIKeyUsecaseImpl{apiKeyStorage: apiKeyStorage, userStorage: userStorage, pvzStorage: pvzStorage, policy: policy}
+}
+
+// CreateKey выпускает ключ от имени пользователя input.UserID. Ключ не может
+// получить разрешений больше, чем есть у роли владельца и у роли создателя,
+// а владельцем не может быть пользователь с правами, которых нет у создателя.
+func (a *APIKeyUsecaseImpl) CreateKey(ctx context.Context, actor entity.Actor, input APIKeyInput) (*CreatedAPIKey, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyUsecase.CreateKey")
+	defer span.End()
+
+	if !actor.APIKeyID.IsNil() {
+		return nil, ErrAPIKeyNotAllowed
+	}
+
+	var verr ValidationError
+
+	if strings.TrimSpace(input.Name) == "" {
+		verr.Add("name", "is required")
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		verr.Add("expiresAt", "must be in the future")
+	}
+
+	user, err := a.userStorage.GetUserByID(ctx, input.UserID)
+	if err == sql.ErrNoRows {
+		verr.Add("userId", "user not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	} else if user.Disabled {
+		verr.Add("userId", "user is disabled")
+	} else if !a.policy.Covers(actor.Role, user.Role) {
+		return nil, ErrAPIKeyOwnerDenied
+	}
+
+	if len(input.Permissions) == 0 {
+		verr.Add("permissions", "is required")
+	}
+	// Роль создателя покрывает роль владельца, поэтому разрешения владельца
+	// есть и у создателя.
+	for _, permission := range input.Permissions {
+		if user != nil && !a.policy.Allowed(user.Role, permission) {
+			verr.Add("permissions", fmt.Sprintf("%s is not granted to role %s", permission, user.Role))
+		}
+	}
+
+	for _, pvz_id := range input.PVZIDs {
+		_, err := a.pvzStorage.GetPVZById(ctx, pvz_id)
+		if err == sql.ErrNoRows {
+			verr.Add("pvzIds", fmt.Sprintf("pvz %s not found", pvz_id))
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get pvz: %w", err)
+		}
+	}
+
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	prefix, secret, err := newAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	rawKey := prefix + secret
+
+	permissions := make([]string, 0, len(input.Permissions))
+	for _, permission := range input.Permissions {
+		permissions = append(permissions, string(permission))
+	}
+
+	key := entity.APIKey{
+		ID:          uuid.Must(uuid.NewV4()),
+		Name:        strings.TrimSpace(input.Name),
+		Prefix:      prefix,
+		Hash:        hashToken(rawKey),
+		UserID:      user.ID,
+		CreatedBy:   actor.UserID,
+		Permissions: permissions,
+		PVZIDs:      input.PVZIDs,
+		ExpiresAt:   input.ExpiresAt,
+		CreatedAt:   time.Now(),
+	}
+	if err := a.apiKeyStorage.CreateAPIKey(ctx, key, actor); err != nil {
+		return nil, fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	return &CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+func (a *APIKeyUsecaseImpl) ListKeys(ctx context.Context) ([]entity.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyUsecase.ListKeys")
+	defer span.End()
+
+	keys, err := a.apiKeyStorage.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (a *APIKeyUsecaseImpl) RevokeKey(ctx context.Context, actor entity.Actor, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "APIKeyUsecase.RevokeKey")
+	defer span.End()
+
+	if !actor.APIKeyID.IsNil() {
+		return ErrAPIKeyNotAllowed
+	}
+
+	err := a.apiKeyStorage.RevokeAPIKey(ctx, id, actor)
+	if err == sql.ErrNoRows {
+		return ErrAPIKeyNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// Authenticate проверяет ключ и роль владельца на момент запроса,
+// поэтому отключение или понижение пользователя сразу действует и на его ключи.
+func (a *APIKeyUsecaseImpl) Authenticate(ctx context.Context, rawKey string) (*APIKeyPrincipal, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyUsecase.Authenticate")
+	defer span.End()
+
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := a.apiKeyStorage.GetAPIKeyByHash(ctx, hashToken(rawKey))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if key.Revoked || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := a.userStorage.GetUserByID(ctx, key.UserID)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	permissions := make([]Permission, 0, len(key.Permissions))
+	for _, permission := range key.Permissions {
+		permissions = append(permissions, Permission(permission))
+	}
+
+	if err := a.apiKeyStorage.TouchAPIKey(ctx, key.ID, time.Now()); err != nil {
+		logging.FromContext(ctx).Warn("failed to update api key usage", slog.Any("error", err))
+	}
+
+	return &APIKeyPrincipal{
+		KeyID:       key.ID,
+		UserID:      user.ID,
+		CreatedBy:   key.CreatedBy,
+		Role:        user.Role,
+		Permissions: permissions,
+		PVZIDs:      key.PVZIDs,
+	}, nil
+}
+
+// newAPIKey возвращает видимый префикс вида pvz_xxxxxxxx_ и секретную часть.
+func newAPIKey() (string, string, error) {
+	id := make([]byte, 5)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	return apiKeyPrefix + strings.ToLower(totpEncoding.EncodeToString(id)) + "_", secret, nil
+}
@@ -0,0 +1,259 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAPIKeysStorage struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]entity.APIKey
+}
+
+func newFakeAPIKeysStorage() *fakeAPIKeysStorage {
+	return &fakeAPIKeysStorage{keys: map[uuid.UUID]entity.APIKey{}}
+}
+
+func (f *fakeAPIKeysStorage) CreateAPIKey(ctx context.Context, key entity.APIKey, actor entity.Actor) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[key.ID] = key
+	return nil
+}
+
+func (f *fakeAPIKeysStorage) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, key := range f.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeAPIKeysStorage) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]entity.APIKey, 0, len(f.keys))
+	for _, key := range f.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (f *fakeAPIKeysStorage) RevokeAPIKey(ctx context.Context, id uuid.UUID, actor entity.Actor) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, ok := f.keys[id]
+	if !ok || key.Revoked {
+		return sql.ErrNoRows
+	}
+	key.Revoked = true
+	f.keys[id] = key
+	return nil
+}
+
+func (f *fakeAPIKeysStorage) TouchAPIKey(ctx context.Context, id uuid.UUID, used_at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := f.keys[id]
+	key.LastUsedAt = &used_at
+	f.keys[id] = key
+	return nil
+}
+
+func TestAPIKeyUsecase_CreateKey(t *testing.T) {
+	creator_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
+	admin_id := uuid.Must(uuid.NewV4())
+	pvz_id := uuid.Must(uuid.NewV4())
+	past := time.Now().Add(-time.Hour)
+
+	employee := &entity.User{ID: user_id, Email: "bot@example.com", Role: "employee"}
+	admin := entity.Actor{UserID: creator_id, Role: "admin"}
+	moderator := entity.Actor{UserID: creator_id, Role: "moderator"}
+
+	tests := []struct {
+		name          string
+		actor         *entity.Actor
+		input         usecase.APIKeyInput
+		mock          func(*MockUsersStorage, *MockPVZStorage)
+		expectedError string
+	}{
+		{
+			name: "success",
+			input: usecase.APIKeyInput{
+				Name:        "scanner",
+				UserID:      user_id,
+				Permissions: []usecase.Permission{usecase.PermReceptionOpen, usecase.PermProductCreate},
+				PVZIDs:      []uuid.UUID{pvz_id},
+			},
+			mock: func(mus *MockUsersStorage, mps *MockPVZStorage) {
+				mus.On("GetUserByID", anyCtx, user_id).Return(employee, nil)
+				mps.On("GetPVZById", anyCtx, pvz_id).Return(&entity.PVZ{ID: pvz_id}, nil)
+			},
+		},
+		{
+			name: "permission not granted to role",
+			input: usecase.APIKeyInput{
+				Name:        "scanner",
+				UserID:      user_id,
+				Permissions: []usecase.Permission{usecase.PermPVZCreate},
+			},
+			mock: func(mus *MockUsersStorage, mps *MockPVZStorage) {
+				mus.On("GetUserByID", anyCtx, user_id).Return(employee, nil)
+			},
+			expectedError: "validation failed: permissions: pvz:create is not granted to role employee",
+		},
+		{
+			name: "unknown pvz and past expiry",
+			input: usecase.APIKeyInput{
+				Name:        "scanner",
+				UserID:      user_id,
+				Permissions: []usecase.Permission{usecase.PermReceptionOpen},
+				PVZIDs:      []uuid.UUID{pvz_id},
+				ExpiresAt:   &past,
+			},
+			mock: func(mus *MockUsersStorage, mps *MockPVZStorage) {
+				mus.On("GetUserByID", anyCtx, user_id).Return(employee, nil)
+				mps.On("GetPVZById", anyCtx, pvz_id).Return((*entity.PVZ)(nil), sql.ErrNoRows)
+			},
+			expectedError: "pvz " + pvz_id.String() + " not found",
+		},
+		{
+			name:  "owner with permissions creator lacks",
+			actor: &moderator,
+			input: usecase.APIKeyInput{
+				Name:        "impersonation",
+				UserID:      admin_id,
+				Permissions: []usecase.Permission{usecase.PermPVZRead},
+			},
+			mock: func(mus *MockUsersStorage, mps *MockPVZStorage) {
+				mus.On("GetUserByID", anyCtx, admin_id).Return(&entity.User{ID: admin_id, Role: "admin"}, nil)
+			},
+			expectedError: usecase.ErrAPIKeyOwnerDenied.Error(),
+		},
+		{
+			name:  "permission the creator lacks for own key",
+			actor: &moderator,
+			input: usecase.APIKeyInput{
+				Name:        "scanner",
+				UserID:      creator_id,
+				Permissions: []usecase.Permission{usecase.PermReceptionOpen},
+			},
+			mock: func(mus *MockUsersStorage, mps *MockPVZStorage) {
+				mus.On("GetUserByID", anyCtx, creator_id).Return(&entity.User{ID: creator_id, Role: "moderator"}, nil)
+			},
+			expectedError: "reception:open is not granted to role moderator",
+		},
+		{
+			name:  "request made with api key",
+			actor: &entity.Actor{UserID: creator_id, Role: "admin", APIKeyID: uuid.Must(uuid.NewV4())},
+			input: usecase.APIKeyInput{
+				Name:        "scanner",
+				UserID:      user_id,
+				Permissions: []usecase.Permission{usecase.PermReceptionOpen},
+			},
+			mock:          func(mus *MockUsersStorage, mps *MockPVZStorage) {},
+			expectedError: usecase.ErrAPIKeyNotAllowed.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userStorage := new(MockUsersStorage)
+			pvzStorage := new(MockPVZStorage)
+			tt.mock(userStorage, pvzStorage)
+
+			keys := newFakeAPIKeysStorage()
+			usecase := usecase.NewAPIKeyUsecase(keys, userStorage, pvzStorage, usecase.DefaultPolicy())
+
+			actor := admin
+			if tt.actor != nil {
+				actor = *tt.actor
+			}
+
+			created, err := usecase.CreateKey(context.Background(), actor, tt.input)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Empty(t, keys.keys)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, creator_id, created.CreatedBy)
+				assert.NotContains(t, created.Hash, created.Key)
+				assert.True(t, len(created.Key) > len(created.Prefix))
+				assert.Equal(t, created.Prefix, created.Key[:len(created.Prefix)])
+			}
+
+			userStorage.AssertExpectations(t)
+			pvzStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyUsecase_Authenticate(t *testing.T) {
+	creator_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
+	pvz_id := uuid.Must(uuid.NewV4())
+
+	userStorage := new(MockUsersStorage)
+	userStorage.On("GetUserByID", anyCtx, user_id).Return(&entity.User{ID: user_id, Role: "employee"}, nil)
+	pvzStorage := new(MockPVZStorage)
+	pvzStorage.On("GetPVZById", anyCtx, pvz_id).Return(&entity.PVZ{ID: pvz_id}, nil)
+
+	keys := newFakeAPIKeysStorage()
+	apiKeys := usecase.NewAPIKeyUsecase(keys, userStorage, pvzStorage, usecase.DefaultPolicy())
+
+	creator := entity.Actor{UserID: creator_id, Role: "admin"}
+	created, err := apiKeys.CreateKey(context.Background(), creator, usecase.APIKeyInput{
+		Name:        "scanner",
+		UserID:      user_id,
+		Permissions: []usecase.Permission{usecase.PermReceptionOpen},
+		PVZIDs:      []uuid.UUID{pvz_id},
+	})
+	require.NoError(t, err)
+
+	principal, err := apiKeys.Authenticate(context.Background(), created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, user_id, principal.UserID)
+	assert.Equal(t, creator_id, principal.CreatedBy)
+	assert.Equal(t, "employee", principal.Role)
+	assert.Equal(t, []usecase.Permission{usecase.PermReceptionOpen}, principal.Permissions)
+	assert.Equal(t, []uuid.UUID{pvz_id}, principal.PVZIDs)
+	assert.NotNil(t, keys.keys[created.ID].LastUsedAt)
+
+	_, err = apiKeys.Authenticate(context.Background(), created.Key+"x")
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+
+	_, err = apiKeys.Authenticate(context.Background(), "not-a-key")
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+
+	expired := keys.keys[created.ID]
+	past := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &past
+	keys.keys[created.ID] = expired
+	_, err = apiKeys.Authenticate(context.Background(), created.Key)
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+
+	expired.ExpiresAt = nil
+	keys.keys[created.ID] = expired
+	byKey := entity.Actor{UserID: user_id, Role: "employee", APIKeyID: created.ID}
+	assert.Equal(t, usecase.ErrAPIKeyNotAllowed, apiKeys.RevokeKey(context.Background(), byKey, created.ID))
+
+	require.NoError(t, apiKeys.RevokeKey(context.Background(), creator, created.ID))
+	_, err = apiKeys.Authenticate(context.Background(), created.Key)
+	assert.Equal(t, usecase.ErrInvalidAPIKey, err)
+
+	assert.Equal(t, usecase.ErrAPIKeyNotFound, apiKeys.RevokeKey(context.Background(), creator, uuid.Must(uuid.NewV4())))
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"pvz/internal/apperr"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+var (
+	ErrNotAssigned        = apperr.Forbidden("not_assigned", "employee is not assigned to pvz")
+	ErrAssignmentNotFound = apperr.NotFound("assignment_not_found", "assignment not found")
+	ErrPVZNotFound        = apperr.NotFound("pvz_not_found", "pvz not found")
+	ErrUserNotFound       = apperr.NotFound("user_not_found", "user not found")
+	ErrNotEmployee        = apperr.Validation("not_employee", "user role is not assigned to PVZ")
+)
+
+type AssignmentUsecase interface {
+	AssignEmployee(ctx context.Context, pvz_id, user_id uuid.UUID) error
+	UnassignEmployee(ctx context.Context, pvz_id, user_id uuid.UUID) error
+}
+
+type AssignmentUsecaseImpl struct {
+	assignmentStorage storage.AssignmentsPostgresStorage
+	pvzStorage        storage.PVZPostgresStorage
+	userStorage       storage.UsersPostgresStorage
+	policy            *Policy
+}
+
+func NewAssignmentUsecase(assignmentStorage storage.AssignmentsPostgresStorage, pvzStorage storage.PVZPostgresStorage, userStorage storage.UsersPostgresStorage, policy *Policy) *AssignmentUsecaseImpl {
+	return &AssignmentUsecaseImpl{assignmentStorage: assignmentStorage, pvzStorage: pvzStorage, userStorage: userStorage, policy: policy}
+}
+
+func (a *AssignmentUsecaseImpl) AssignEmployee(ctx context.Context, pvz_id, user_id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AssignmentUsecase.AssignEmployee")
+	defer span.End()
+
+	_, err := a.pvzStorage.GetPVZById(ctx, pvz_id)
+	if err == sql.ErrNoRows {
+		return ErrPVZNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to get pvz: %w", err)
+	}
+
+	user, err := a.userStorage.GetUserByID(ctx, user_id)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !a.policy.AssignmentRequired(user.Role) {
+		return ErrNotEmployee
+	}
+
+	if err := a.assignmentStorage.AssignEmployee(ctx, user_id, pvz_id); err != nil {
+		return fmt.Errorf("failed to assign employee: %w", err)
+	}
+	return nil
+}
+
+func (a *AssignmentUsecaseImpl) UnassignEmployee(ctx context.Context, pvz_id, user_id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AssignmentUsecase.UnassignEmployee")
+	defer span.End()
+
+	ok, err := a.assignmentStorage.UnassignEmployee(ctx, user_id, pvz_id)
+	if err != nil {
+		return fmt.Errorf("failed to unassign employee: %w", err)
+	}
+	if !ok {
+		return ErrAssignmentNotFound
+	}
+	return nil
+}
+
+// checkAssignment пропускает роли, которым политика разрешает работать
+// без назначения, остальных — только на назначенных ПВЗ.
+func checkAssignment(ctx context.Context, assignmentStorage storage.AssignmentsPostgresStorage, policy *Policy, actor entity.Actor, pvz_id uuid.UUID) error {
+	if !policy.AssignmentRequired(actor.Role) {
+		return nil
+	}
+	assigned, err := assignmentStorage.IsAssigned(ctx, actor.UserID, pvz_id)
+	if err != nil {
+		return fmt.Errorf("failed to check assignment: %w", err)
+	}
+	if !assigned {
+		return ErrNotAssigned
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignmentUsecase_AssignEmployee(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
+
+	tests := []struct {
+		name          string
+		getPVZError   error
+		getUserResult *entity.User
+		getUserError  error
+		policy        *usecase.Policy
+		expectedError error
+	}{
+		{
+			name:          "success",
+			getUserResult: &entity.User{ID: user_id, Role: "employee"},
+		},
+		{
+			name:          "pvz not found",
+			getPVZError:   sql.ErrNoRows,
+			expectedError: usecase.ErrPVZNotFound,
+		},
+		{
+			name:          "user not found",
+			getUserResult: &entity.User{},
+			getUserError:  sql.ErrNoRows,
+			expectedError: usecase.ErrUserNotFound,
+		},
+		{
+			name:          "user is moderator",
+			getUserResult: &entity.User{ID: user_id, Role: "moderator"},
+			expectedError: usecase.ErrNotEmployee,
+		},
+		{
+			name:          "role exempt from assignment",
+			getUserResult: &entity.User{ID: user_id, Role: "admin"},
+			expectedError: usecase.ErrNotEmployee,
+		},
+		{
+			name:          "custom role requiring assignment",
+			getUserResult: &entity.User{ID: user_id, Role: "courier"},
+			policy:        usecase.NewPolicy(map[string][]usecase.Permission{"courier": {usecase.PermProductCreate}}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AssignmentStorage := new(MockAssignmentStorage)
+			PVZStorage := new(MockPVZStorage)
+			UserStorage := new(MockUsersStorage)
+			policy := tt.policy
+			if policy == nil {
+				policy = usecase.DefaultPolicy()
+			}
+			usecase := usecase.NewAssignmentUsecase(AssignmentStorage, PVZStorage, UserStorage, policy)
+
+			PVZStorage.On("GetPVZById", anyCtx, pvz_id).Return(&entity.PVZ{ID: pvz_id}, tt.getPVZError)
+			if tt.getPVZError == nil {
+				UserStorage.On("GetUserByID", anyCtx, user_id).Return(tt.getUserResult, tt.getUserError)
+			}
+			if tt.expectedError == nil {
+				AssignmentStorage.On("AssignEmployee", anyCtx, user_id, pvz_id).Return(nil)
+			}
+
+			err := usecase.AssignEmployee(context.Background(), pvz_id, user_id)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			AssignmentStorage.AssertExpectations(t)
+			PVZStorage.AssertExpectations(t)
+			UserStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAssignmentUsecase_UnassignEmployee(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
+
+	tests := []struct {
+		name          string
+		unassigned    bool
+		unassignError error
+		expectedError string
+	}{
+		{
+			name:       "success",
+			unassigned: true,
+		},
+		{
+			name:          "assignment not found",
+			unassigned:    false,
+			expectedError: "assignment not found",
+		},
+		{
+			name:          "db error",
+			unassignError: errors.New("db error"),
+			expectedError: "failed to unassign employee: db error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AssignmentStorage := new(MockAssignmentStorage)
+			usecase := usecase.NewAssignmentUsecase(AssignmentStorage, new(MockPVZStorage), new(MockUsersStorage), usecase.DefaultPolicy())
+
+			AssignmentStorage.On("UnassignEmployee", anyCtx, user_id, pvz_id).Return(tt.unassigned, tt.unassignError)
+
+			err := usecase.UnassignEmployee(context.Background(), pvz_id, user_id)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			AssignmentStorage.AssertExpectations(t)
+		})
+	}
+}
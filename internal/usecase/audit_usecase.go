@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"pvz/internal/apperr"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+)
+
+var ErrInvalidAuditRange = apperr.Validation("invalid_range", "from must be before to")
+
+type AuditUsecase interface {
+	ListEvents(ctx context.Context, filter entity.AuditFilter) (*AuditListResponse, error)
+}
+
+type AuditUsecaseImpl struct {
+	auditStorage storage.AuditPostgresStorage
+}
+
+type AuditListResponse struct {
+	Events []entity.AuditEvent `json:"events"`
+	Total  int                 `json:"total"`
+	Page   int                 `json:"page"`
+	Limit  int                 `json:"limit"`
+}
+
+func NewAuditUsecase(auditStorage storage.AuditPostgresStorage) *AuditUsecaseImpl {
+	return &AuditUsecaseImpl{auditStorage: auditStorage}
+}
+
+func (a *AuditUsecaseImpl) ListEvents(ctx context.Context, filter entity.AuditFilter) (*AuditListResponse, error) {
+	ctx, span := tracer.Start(ctx, "AuditUsecase.ListEvents")
+	defer span.End()
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidAuditRange
+	}
+
+	events, err := a.auditStorage.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	total, err := a.auditStorage.CountEvents(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	return &AuditListResponse{
+		Events: events,
+		Total:  total,
+		Page:   filter.Page,
+		Limit:  filter.Limit,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditStorage struct {
+	mock.Mock
+}
+
+func (m *MockAuditStorage) ListEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entity.AuditEvent), args.Error(1)
+}
+
+func (m *MockAuditStorage) CountEvents(ctx context.Context, filter entity.AuditFilter) (int, error) {
+	args := m.Called(ctx, filter)
+	return args.Int(0), args.Error(1)
+}
+
+func TestAuditUsecase_ListEvents(t *testing.T) {
+	actor_id := uuid.Must(uuid.NewV4())
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	events := []entity.AuditEvent{{ID: 1, ActorID: &actor_id, Action: "pvz.create", EntityType: "pvz"}}
+
+	tests := []struct {
+		name          string
+		filter        entity.AuditFilter
+		mock          func(*MockAuditStorage, entity.AuditFilter)
+		expected      *usecase.AuditListResponse
+		expectedError error
+	}{
+		{
+			name:   "success",
+			filter: entity.AuditFilter{ActorID: &actor_id, From: &from, To: &to, Page: 1, Limit: 50},
+			mock: func(m *MockAuditStorage, filter entity.AuditFilter) {
+				m.On("ListEvents", anyCtx, filter).Return(events, nil)
+				m.On("CountEvents", anyCtx, filter).Return(1, nil)
+			},
+			expected: &usecase.AuditListResponse{Events: events, Total: 1, Page: 1, Limit: 50},
+		},
+		{
+			name:          "inverted range",
+			filter:        entity.AuditFilter{From: &to, To: &from, Page: 1, Limit: 50},
+			mock:          func(m *MockAuditStorage, filter entity.AuditFilter) {},
+			expectedError: usecase.ErrInvalidAuditRange,
+		},
+		{
+			name:   "storage error",
+			filter: entity.AuditFilter{Page: 1, Limit: 50},
+			mock: func(m *MockAuditStorage, filter entity.AuditFilter) {
+				m.On("ListEvents", anyCtx, filter).Return([]entity.AuditEvent(nil), errors.New("db down"))
+			},
+			expectedError: errors.New("failed to list audit events: db down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditStorage := new(MockAuditStorage)
+			tt.mock(auditStorage, tt.filter)
+			usecase := usecase.NewAuditUsecase(auditStorage)
+
+			result, err := usecase.ListEvents(context.Background(), tt.filter)
+
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+			auditStorage.AssertExpectations(t)
+		})
+	}
+}
@@ -1,45 +1,282 @@
 package usecase
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"pvz/internal/apperr"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/golang-jwt/jwt"
 )
 
+const (
+	// challengeTokenType помечает токен второго шага входа, чтобы его
+	// нельзя было использовать как access токен и наоборот.
+	challengeTokenType = "mfa_challenge"
+)
+
+var (
+	ErrInvalidRefreshToken   = apperr.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrInvalidChallengeToken = apperr.Unauthorized("invalid_challenge_token", "invalid challenge token")
+)
+
+// TokenConfig задаёт время жизни выпускаемых токенов.
+type TokenConfig struct {
+	AccessTTL    time.Duration
+	RefreshTTL   time.Duration
+	ChallengeTTL time.Duration
+}
+
+func DefaultTokenConfig() TokenConfig {
+	return TokenConfig{
+		AccessTTL:    15 * time.Minute,
+		RefreshTTL:   30 * 24 * time.Hour,
+		ChallengeTTL: 5 * time.Minute,
+	}
+}
+
 type AuthUsecase interface {
 	ValidateToken(tokenString string) (*jwt.Token, error)
-	GenerateToken(userID uuid.UUID, role string) (string, error)
+	GenerateToken(userID uuid.UUID, role string, mfa bool) (string, error)
+	GenerateChallengeToken(userID uuid.UUID) (string, error)
+	ValidateChallengeToken(tokenString string) (uuid.UUID, error)
+	GenerateRefreshToken(ctx context.Context, userID uuid.UUID) (string, error)
+	RotateRefreshToken(ctx context.Context, refreshToken string) (uuid.UUID, string, error)
+	RevokeRefreshToken(ctx context.Context, userID uuid.UUID, refreshToken string) error
+	RevokeToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error)
+	ActiveUser(ctx context.Context, userID uuid.UUID) (*entity.User, error)
 }
 
 type AuthService struct {
-	secretKey []byte
+	keyring      *Keyring
+	tokenStorage storage.TokensPostgresStorage
+	userStorage  storage.UsersPostgresStorage
+	config       TokenConfig
 }
 
-func NewAuthService(secret string) *AuthService {
-	return &AuthService{secretKey: []byte(secret)}
+func NewAuthService(keyring *Keyring, tokenStorage storage.TokensPostgresStorage, userStorage storage.UsersPostgresStorage, config TokenConfig) *AuthService {
+	return &AuthService{keyring: keyring, tokenStorage: tokenStorage, userStorage: userStorage, config: config}
 }
 
-func (a *AuthService) GenerateToken(userID uuid.UUID, role string) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+// GenerateToken выпускает access токен. mfa отмечает, что при входе
+// был предъявлен второй фактор.
+func (a *AuthService) GenerateToken(userID uuid.UUID, role string, mfa bool) (string, error) {
+	key, err := a.keyring.Active()
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.New(key.Method)
+	token.Header["kid"] = key.ID
 	claims := token.Claims.(jwt.MapClaims)
 	claims["id"] = userID
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
+	claims["jti"] = jti
+	claims["exp"] = time.Now().Add(a.config.AccessTTL).Unix()
 	claims["role"] = role
+	claims["mfa"] = mfa
+
+	return token.SignedString(key.Private)
+}
+
+func (a *AuthService) GenerateChallengeToken(userID uuid.UUID) (string, error) {
+	key, err := a.keyring.Active()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.New(key.Method)
+	token.Header["kid"] = key.ID
+	claims := token.Claims.(jwt.MapClaims)
+	claims["id"] = userID
+	claims["typ"] = challengeTokenType
+	claims["exp"] = time.Now().Add(a.config.ChallengeTTL).Unix()
 
-	return token.SignedString(a.secretKey)
+	return token.SignedString(key.Private)
 }
 
+func (a *AuthService) ValidateChallengeToken(tokenString string) (uuid.UUID, error) {
+	token, err := a.parse(tokenString)
+	if err != nil || !token.Valid {
+		return uuid.Nil, ErrInvalidChallengeToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != challengeTokenType {
+		return uuid.Nil, ErrInvalidChallengeToken
+	}
+
+	id, _ := claims["id"].(string)
+	userID, err := uuid.FromString(id)
+	if err != nil {
+		return uuid.Nil, ErrInvalidChallengeToken
+	}
+	return userID, nil
+}
+
+// ValidateToken принимает только access токены.
 func (a *AuthService) ValidateToken(tokenString string) (*jwt.Token, error) {
+	token, err := a.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["typ"] != nil {
+		return nil, fmt.Errorf("unexpected token type")
+	}
+	return token, nil
+}
+
+func (a *AuthService) parse(tokenString string) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := a.keyring.Verifier(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method")
 		}
-		return a.secretKey, nil
+		return key.Public, nil
 	})
 	if err != nil {
 		return nil, err
 	}
 	return token, nil
 }
+
+func (a *AuthService) GenerateRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.GenerateRefreshToken")
+	defer span.End()
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	err = a.tokenStorage.CreateRefreshToken(ctx, uuid.Must(uuid.NewV4()), userID, hashToken(refreshToken), time.Now().Add(a.config.RefreshTTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return refreshToken, nil
+}
+
+// RotateRefreshToken отзывает предъявленный refresh токен и выпускает новый.
+// Повторное предъявление уже отозванного токена считается утечкой,
+// поэтому в этом случае отзываются все сессии пользователя.
+func (a *AuthService) RotateRefreshToken(ctx context.Context, refreshToken string) (uuid.UUID, string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RotateRefreshToken")
+	defer span.End()
+
+	stored, err := a.tokenStorage.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err == sql.ErrNoRows {
+		return uuid.Nil, "", ErrInvalidRefreshToken
+	} else if err != nil {
+		return uuid.Nil, "", fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if stored.Revoked {
+		if err := a.tokenStorage.RevokeUserRefreshTokens(ctx, stored.UserID); err != nil {
+			return uuid.Nil, "", fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+		return uuid.Nil, "", ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return uuid.Nil, "", ErrInvalidRefreshToken
+	}
+
+	ok, err := a.tokenStorage.RevokeRefreshToken(ctx, stored.ID)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	if !ok {
+		return uuid.Nil, "", ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := a.GenerateRefreshToken(ctx, stored.UserID)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return stored.UserID, newRefreshToken, nil
+}
+
+// RevokeRefreshToken отзывает только токен пользователя userID: чужой
+// токен неотличим от несуществующего.
+func (a *AuthService) RevokeRefreshToken(ctx context.Context, userID uuid.UUID, refreshToken string) error {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeRefreshToken")
+	defer span.End()
+
+	stored, err := a.tokenStorage.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err == sql.ErrNoRows {
+		return ErrInvalidRefreshToken
+	} else if err != nil {
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if stored.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+
+	if _, err := a.tokenStorage.RevokeRefreshToken(ctx, stored.ID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+func (a *AuthService) RevokeToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeToken")
+	defer span.End()
+
+	return a.tokenStorage.RevokeAccessToken(ctx, jti, expiresAt)
+}
+
+func (a *AuthService) IsTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.IsTokenRevoked")
+	defer span.End()
+
+	return a.tokenStorage.IsAccessTokenRevoked(ctx, jti)
+}
+
+// ActiveUser возвращает пользователя из базы; удалённый или отключённый
+// пользователь даёт ErrUserDisabled.
+func (a *AuthService) ActiveUser(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ActiveUser")
+	defer span.End()
+
+	user, err := a.userStorage.GetUserByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserDisabled
+	} else if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	return user, nil
+}
+
+func randomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,65 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthService_RevokeRefreshToken(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	token_id := uuid.Must(uuid.NewV4())
+	stored := &entity.RefreshToken{ID: token_id, UserID: user_id, ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name          string
+		userID        uuid.UUID
+		mock          func(*MockTokensStorage)
+		expectedError error
+	}{
+		{
+			name:   "own token",
+			userID: user_id,
+			mock: func(mts *MockTokensStorage) {
+				mts.On("GetRefreshTokenByHash", anyCtx, mock.Anything).Return(stored, nil)
+				mts.On("RevokeRefreshToken", anyCtx, token_id).Return(true, nil)
+			},
+		},
+		{
+			name:   "token of another user",
+			userID: uuid.Must(uuid.NewV4()),
+			mock: func(mts *MockTokensStorage) {
+				mts.On("GetRefreshTokenByHash", anyCtx, mock.Anything).Return(stored, nil)
+			},
+			expectedError: usecase.ErrInvalidRefreshToken,
+		},
+		{
+			name:   "unknown token",
+			userID: user_id,
+			mock: func(mts *MockTokensStorage) {
+				mts.On("GetRefreshTokenByHash", anyCtx, mock.Anything).Return((*entity.RefreshToken)(nil), sql.ErrNoRows)
+			},
+			expectedError: usecase.ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := new(MockTokensStorage)
+			tt.mock(tokens)
+			auth := usecase.NewAuthService(nil, tokens, nil, usecase.DefaultTokenConfig())
+
+			err := auth.RevokeRefreshToken(context.Background(), tt.userID, "refresh_token")
+
+			assert.Equal(t, tt.expectedError, err)
+			tokens.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"pvz/internal/apperr"
+	"pvz/internal/storage/migrations/entity"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+var (
+	ErrInvalidCursor        = apperr.Validation("invalid_cursor", "invalid cursor")
+	ErrCursorFilterMismatch = apperr.Validation("cursor_filter_mismatch", "cursor was issued for other filters")
+)
+
+const (
+	cursorFilterSize  = 8
+	cursorPayloadSize = 8 + 8 + uuid.Size + cursorFilterSize
+	cursorMACSize     = 16
+)
+
+// CursorCodec превращает позицию в списке в непрозрачную строку для
+// параметра cursor и обратно. Строка подписана HMAC, поэтому клиент не
+// может подставить произвольную позицию, а scope не даёт использовать
+// курсор одного списка в другом. В курсор входит и хэш фильтра: позиция
+// из одной выборки ничего не значит для выборки с другими фильтрами.
+type CursorCodec struct {
+	key []byte
+}
+
+func NewCursorCodec(key []byte) *CursorCodec {
+	return &CursorCodec{key: key}
+}
+
+// filter — фильтры списка в нормализованном виде, одинаковом для
+// одинаковых выборок.
+func (c *CursorCodec) Encode(scope, filter string, keyset entity.Keyset) string {
+	payload := make([]byte, cursorPayloadSize, cursorPayloadSize+cursorMACSize)
+	// Нулевое время (ключ — Count) кодируется нулём: UnixNano для него
+	// не определён.
+	if !keyset.Time.IsZero() {
+		binary.BigEndian.PutUint64(payload, uint64(keyset.Time.UnixNano()))
+	}
+	binary.BigEndian.PutUint64(payload[8:], uint64(keyset.Count))
+	copy(payload[16:], keyset.ID.Bytes())
+	copy(payload[16+uuid.Size:], filterHash(filter))
+	return base64.RawURLEncoding.EncodeToString(append(payload, c.sign(scope, payload)...))
+}
+
+func (c *CursorCodec) Decode(scope, filter, cursor string) (*entity.Keyset, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(raw) != cursorPayloadSize+cursorMACSize {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, mac := raw[:cursorPayloadSize], raw[cursorPayloadSize:]
+	if !hmac.Equal(mac, c.sign(scope, payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	if !hmac.Equal(payload[16+uuid.Size:], filterHash(filter)) {
+		return nil, ErrCursorFilterMismatch
+	}
+
+	id, err := uuid.FromBytes(payload[16 : 16+uuid.Size])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	keyset := &entity.Keyset{Count: int(binary.BigEndian.Uint64(payload[8:])), ID: id}
+	if nanos := int64(binary.BigEndian.Uint64(payload)); nanos != 0 {
+		keyset.Time = time.Unix(0, nanos).UTC()
+	}
+	return keyset, nil
+}
+
+func (c *CursorCodec) sign(scope string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(scope))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)[:cursorMACSize]
+}
+
+func filterHash(filter string) []byte {
+	sum := sha256.Sum256([]byte(filter))
+	return sum[:cursorFilterSize]
+}
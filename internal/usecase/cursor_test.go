@@ -0,0 +1,58 @@
+package usecase_test
+
+import (
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cursors = usecase.NewCursorCodec([]byte("0123456789abcdef0123456789abcdef"))
+
+func TestCursorCodec(t *testing.T) {
+	keyset := entity.Keyset{
+		Time: time.Date(2025, 4, 16, 12, 30, 0, 123456000, time.UTC),
+		ID:   uuid.Must(uuid.NewV4()),
+	}
+	cursor := cursors.Encode("pvz", "city=Москва", keyset)
+
+	decoded, err := cursors.Decode("pvz", "city=Москва", cursor)
+	require.NoError(t, err)
+	assert.Equal(t, keyset, *decoded)
+
+	counted := entity.Keyset{Count: 42, ID: keyset.ID}
+	decoded, err = cursors.Decode("pvz", "", cursors.Encode("pvz", "", counted))
+	require.NoError(t, err)
+	assert.Equal(t, counted, *decoded)
+
+	tampered := []byte(cursor)
+	tampered[3] ^= 1
+
+	tests := []struct {
+		name   string
+		scope  string
+		cursor string
+		codec  *usecase.CursorCodec
+	}{
+		{name: "tampered", scope: "pvz", cursor: string(tampered), codec: cursors},
+		{name: "other scope", scope: "pvz_receptions:" + keyset.ID.String(), cursor: cursor, codec: cursors},
+		{name: "other key", scope: "pvz", cursor: cursor, codec: usecase.NewCursorCodec([]byte("another key"))},
+		{name: "truncated", scope: "pvz", cursor: cursor[:len(cursor)-4], codec: cursors},
+		{name: "not base64", scope: "pvz", cursor: strings.Repeat("!", len(cursor)), codec: cursors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.codec.Decode(tt.scope, "city=Москва", tt.cursor)
+			assert.Equal(t, usecase.ErrInvalidCursor, err)
+		})
+	}
+
+	_, err = cursors.Decode("pvz", "city=Казань", cursor)
+	assert.Equal(t, usecase.ErrCursorFilterMismatch, err)
+}
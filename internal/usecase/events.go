@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"pvz/internal/storage"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// EventRecorder учитывает бизнес-события. Методы вызываются только после
+// фиксации транзакции, чтобы откаченные изменения не попадали в счётчики.
+type EventRecorder interface {
+	PVZCreated(city string)
+	ReceptionOpened(city string)
+	ReceptionClosed(city string)
+	ProductAdded(city, productType string)
+	ProductDeleted(city, productType string)
+}
+
+// NopEventRecorder ничего не учитывает.
+type NopEventRecorder struct{}
+
+func (NopEventRecorder) PVZCreated(city string)                  {}
+func (NopEventRecorder) ReceptionOpened(city string)             {}
+func (NopEventRecorder) ReceptionClosed(city string)             {}
+func (NopEventRecorder) ProductAdded(city, productType string)   {}
+func (NopEventRecorder) ProductDeleted(city, productType string) {}
+
+// pvzCity возвращает город ПВЗ для меток событий.
+func pvzCity(ctx context.Context, tx storage.Tx, pvz_id uuid.UUID) (string, error) {
+	pvz, err := tx.PVZ().GetPVZById(ctx, pvz_id)
+	if err == sql.ErrNoRows {
+		return "", ErrPVZNotFound
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get pvz: %w", err)
+	}
+	return pvz.City, nil
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"pvz/internal/apperr"
+	"pvz/internal/storage"
+	"sync/atomic"
+)
+
+var (
+	ErrShuttingDown        = apperr.Unavailable("shutting_down", "service is shutting down")
+	ErrDatabaseUnavailable = apperr.Unavailable("database_unavailable", "database is unavailable")
+	ErrSchemaOutdated      = apperr.Unavailable("schema_outdated", "database schema is behind the expected version")
+)
+
+type HealthUsecase interface {
+	Ready(ctx context.Context) error
+	Drain()
+}
+
+type HealthUsecaseImpl struct {
+	healthStorage   storage.HealthPostgresStorage
+	expectedVersion int64
+	draining        atomic.Bool
+}
+
+func NewHealthUsecase(healthStorage storage.HealthPostgresStorage, expectedVersion int64) *HealthUsecaseImpl {
+	return &HealthUsecaseImpl{healthStorage: healthStorage, expectedVersion: expectedVersion}
+}
+
+// Ready проверяет, что сервис может обслуживать запросы: база доступна
+// и схема не старше той, под которую собран сервис. Более новая схема
+// допустима, чтобы старые экземпляры работали во время выкатки.
+func (h *HealthUsecaseImpl) Ready(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "HealthUsecase.Ready")
+	defer span.End()
+
+	if h.draining.Load() {
+		return ErrShuttingDown
+	}
+
+	if err := h.healthStorage.Ping(ctx); err != nil {
+		return ErrDatabaseUnavailable
+	}
+
+	version, err := h.healthStorage.SchemaVersion(ctx)
+	if err != nil {
+		return ErrDatabaseUnavailable
+	}
+	if version < h.expectedVersion {
+		return ErrSchemaOutdated
+	}
+	return nil
+}
+
+// Drain переводит сервис в состояние остановки: после вызова Ready
+// всегда возвращает ErrShuttingDown, и балансировщик перестаёт слать запросы.
+func (h *HealthUsecaseImpl) Drain() {
+	h.draining.Store(true)
+}
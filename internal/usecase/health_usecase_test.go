@@ -0,0 +1,88 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockHealthStorage struct {
+	mock.Mock
+}
+
+func (m *MockHealthStorage) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockHealthStorage) SchemaVersion(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestHealthUsecase_Ready(t *testing.T) {
+	const expectedVersion = int64(20250509090000)
+
+	tests := []struct {
+		name          string
+		mock          func(*MockHealthStorage)
+		expectedError error
+	}{
+		{
+			name: "ready",
+			mock: func(m *MockHealthStorage) {
+				m.On("Ping", anyCtx).Return(nil)
+				m.On("SchemaVersion", anyCtx).Return(expectedVersion, nil)
+			},
+		},
+		{
+			name: "newer schema",
+			mock: func(m *MockHealthStorage) {
+				m.On("Ping", anyCtx).Return(nil)
+				m.On("SchemaVersion", anyCtx).Return(expectedVersion+1, nil)
+			},
+		},
+		{
+			name: "database unreachable",
+			mock: func(m *MockHealthStorage) {
+				m.On("Ping", anyCtx).Return(errors.New("connection refused"))
+			},
+			expectedError: usecase.ErrDatabaseUnavailable,
+		},
+		{
+			name: "schema behind",
+			mock: func(m *MockHealthStorage) {
+				m.On("Ping", anyCtx).Return(nil)
+				m.On("SchemaVersion", anyCtx).Return(expectedVersion-1, nil)
+			},
+			expectedError: usecase.ErrSchemaOutdated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := &MockHealthStorage{}
+			tt.mock(mockStorage)
+
+			health := usecase.NewHealthUsecase(mockStorage, expectedVersion)
+			err := health.Ready(context.Background())
+
+			assert.Equal(t, tt.expectedError, err)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHealthUsecase_Drain(t *testing.T) {
+	mockStorage := &MockHealthStorage{}
+	health := usecase.NewHealthUsecase(mockStorage, 0)
+
+	health.Drain()
+
+	assert.Equal(t, usecase.ErrShuttingDown, health.Ready(context.Background()))
+	mockStorage.AssertNotCalled(t, "Ping", mock.Anything)
+}
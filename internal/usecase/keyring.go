@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private interface{}
+	Public  interface{}
+	Retired bool
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWKSProvider interface {
+	JWKS() JWKSet
+}
+
+// Keyring хранит ключи подписи токенов. Новые токены подписываются активным
+// ключом, проверка принимает любой ключ, не помеченный как retired.
+type Keyring struct {
+	activeID string
+	keys     map[string]*SigningKey
+	order    []string
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]*SigningKey)}
+}
+
+func (k *Keyring) Add(key *SigningKey) error {
+	if key.ID == "" {
+		return errors.New("signing key id is required")
+	}
+	if _, ok := k.keys[key.ID]; ok {
+		return fmt.Errorf("duplicate signing key %q", key.ID)
+	}
+	k.keys[key.ID] = key
+	k.order = append(k.order, key.ID)
+	return nil
+}
+
+func (k *Keyring) SetActive(id string) error {
+	key, ok := k.keys[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownSigningKey, id)
+	}
+	if key.Retired {
+		return fmt.Errorf("signing key %q is retired", id)
+	}
+	k.activeID = id
+	return nil
+}
+
+func (k *Keyring) Active() (*SigningKey, error) {
+	key, ok := k.keys[k.activeID]
+	if !ok {
+		return nil, errors.New("no active signing key")
+	}
+	return key, nil
+}
+
+func (k *Keyring) Verifier(id string) (*SigningKey, error) {
+	key, ok := k.keys[id]
+	if !ok || key.Retired {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSigningKey, id)
+	}
+	return key, nil
+}
+
+// JWKS возвращает публичные ключи всех действующих асимметричных ключей.
+// HMAC-секреты не публикуются.
+func (k *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, id := range k.order {
+		key := k.keys[id]
+		if key.Retired {
+			continue
+		}
+
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
+
+type keyringFile struct {
+	Active string `json:"active"`
+	Keys   []struct {
+		ID      string `json:"kid"`
+		Alg     string `json:"alg"`
+		File    string `json:"file"`
+		Retired bool   `json:"retired"`
+	} `json:"keys"`
+}
+
+// LoadKeyring читает описание ключей из JSON-файла. Пути к файлам ключей
+// считаются относительно каталога, в котором лежит сам файл.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse keyring: %w", err)
+	}
+
+	keyring := NewKeyring()
+	dir := filepath.Dir(path)
+	for _, entry := range file.Keys {
+		keyPath := entry.File
+		if !filepath.IsAbs(keyPath) {
+			keyPath = filepath.Join(dir, keyPath)
+		}
+
+		key, err := LoadSigningKey(entry.ID, entry.Alg, keyPath)
+		if err != nil {
+			return nil, err
+		}
+		key.Retired = entry.Retired
+
+		if err := keyring.Add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := keyring.SetActive(file.Active); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+func LoadSigningKey(id, alg, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %q: %w", id, err)
+	}
+
+	switch alg {
+	case "HS256":
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("key %q is empty", id)
+		}
+		return NewHMACKey(id, secret), nil
+	case "RS256":
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %q: %w", id, err)
+		}
+		return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, Private: private, Public: &private.PublicKey}, nil
+	case "EdDSA":
+		private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %q: %w", id, err)
+		}
+		edPrivate := private.(ed25519.PrivateKey)
+		return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, Private: edPrivate, Public: edPrivate.Public()}, nil
+	default:
+		return nil, fmt.Errorf("key %q: unsupported algorithm %q", id, alg)
+	}
+}
+
+func NewHMACKey(id string, secret []byte) *SigningKey {
+	return &SigningKey{ID: id, Method: jwt.SigningMethodHS256, Private: secret, Public: secret}
+}
@@ -0,0 +1,152 @@
+package usecase_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeyringFiles(t *testing.T) string {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rsa.pem"), rsaPEM, 0o600))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ed.pem"), edPEM, 0o600))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hmac.key"), []byte("old-secret\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "retired.key"), []byte("retired-secret"), 0o600))
+
+	manifest := `{
+		"active": "ed-2025",
+		"keys": [
+			{"kid": "ed-2025", "alg": "EdDSA", "file": "ed.pem"},
+			{"kid": "rsa-2024", "alg": "RS256", "file": "rsa.pem"},
+			{"kid": "hmac-legacy", "alg": "HS256", "file": "hmac.key"},
+			{"kid": "hmac-retired", "alg": "HS256", "file": "retired.key", "retired": true}
+		]
+	}`
+	path := filepath.Join(dir, "keyring.json")
+	require.NoError(t, os.WriteFile(path, []byte(manifest), 0o600))
+	return path
+}
+
+func TestKeyring_LoadAndJWKS(t *testing.T) {
+	keyring, err := usecase.LoadKeyring(writeKeyringFiles(t))
+	require.NoError(t, err)
+
+	active, err := keyring.Active()
+	require.NoError(t, err)
+	assert.Equal(t, "ed-2025", active.ID)
+
+	jwks := keyring.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "ed-2025", jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+	assert.Equal(t, "rsa-2024", jwks.Keys[1].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+}
+
+func TestAuthService_ValidateTokenWithKeyring(t *testing.T) {
+	keyring, err := usecase.LoadKeyring(writeKeyringFiles(t))
+	require.NoError(t, err)
+	auth := usecase.NewAuthService(keyring, nil, nil, usecase.DefaultTokenConfig())
+	userID := uuid.Must(uuid.NewV4())
+
+	t.Run("token signed by active key", func(t *testing.T) {
+		tokenString, err := auth.GenerateToken(userID, "moderator", true)
+		require.NoError(t, err)
+
+		token, err := auth.ValidateToken(tokenString)
+		require.NoError(t, err)
+		assert.True(t, token.Valid)
+		assert.Equal(t, "ed-2025", token.Header["kid"])
+		assert.Equal(t, "EdDSA", token.Method.Alg())
+	})
+
+	signWith := func(kid string) string {
+		key, err := keyring.Verifier(kid)
+		require.NoError(t, err)
+		token := jwt.NewWithClaims(key.Method, jwt.MapClaims{"id": userID.String(), "role": "employee"})
+		token.Header["kid"] = kid
+		tokenString, err := token.SignedString(key.Private)
+		require.NoError(t, err)
+		return tokenString
+	}
+
+	t.Run("token signed by previous key", func(t *testing.T) {
+		for _, kid := range []string{"rsa-2024", "hmac-legacy"} {
+			token, err := auth.ValidateToken(signWith(kid))
+			require.NoError(t, err, kid)
+			assert.True(t, token.Valid, kid)
+		}
+	})
+
+	t.Run("token signed by retired key", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": userID.String()})
+		token.Header["kid"] = "hmac-retired"
+		tokenString, err := token.SignedString([]byte("retired-secret"))
+		require.NoError(t, err)
+
+		_, err = auth.ValidateToken(tokenString)
+		assert.Error(t, err)
+	})
+
+	t.Run("algorithm mismatch", func(t *testing.T) {
+		rsaKey, err := keyring.Verifier("rsa-2024")
+		require.NoError(t, err)
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"id": userID.String()})
+		token.Header["kid"] = "hmac-legacy"
+		tokenString, err := token.SignedString(rsaKey.Private)
+		require.NoError(t, err)
+
+		_, err = auth.ValidateToken(tokenString)
+		assert.Error(t, err)
+	})
+
+	t.Run("challenge token is not an access token", func(t *testing.T) {
+		challenge, err := auth.GenerateChallengeToken(userID)
+		require.NoError(t, err)
+
+		_, err = auth.ValidateToken(challenge)
+		assert.Error(t, err)
+
+		subject, err := auth.ValidateChallengeToken(challenge)
+		require.NoError(t, err)
+		assert.Equal(t, userID, subject)
+
+		accessToken, err := auth.GenerateToken(userID, "moderator", false)
+		require.NoError(t, err)
+		_, err = auth.ValidateChallengeToken(accessToken)
+		assert.ErrorIs(t, err, usecase.ErrInvalidChallengeToken)
+	})
+
+	t.Run("token without kid", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": userID.String()})
+		tokenString, err := token.SignedString([]byte("old-secret"))
+		require.NoError(t, err)
+
+		_, err = auth.ValidateToken(tokenString)
+		assert.Error(t, err)
+	})
+}
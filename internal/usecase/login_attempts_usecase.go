@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"pvz/internal/apperr"
+	"pvz/internal/logging"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+var ErrTooManyAttempts = apperr.TooManyRequests("too_many_attempts", "too many login attempts")
+
+type LoginThrottleConfig struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	Window             time.Duration
+	BaseLockout        time.Duration
+	MaxLockout         time.Duration
+}
+
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      20,
+		Window:             15 * time.Minute,
+		BaseLockout:        30 * time.Second,
+		MaxLockout:         15 * time.Minute,
+	}
+}
+
+type LoginAttemptsUsecase interface {
+	CheckLogin(ctx context.Context, email, ip string) (time.Duration, error)
+	RecordFailure(ctx context.Context, email, ip string) error
+	RecordSuccess(ctx context.Context, email, ip string) error
+	ListAttempts(ctx context.Context, filter entity.LoginAttemptFilter) ([]entity.LoginAttempt, error)
+}
+
+// LoginAttemptsUsecaseImpl считает неудачные входы отдельно по учётной записи
+// и по IP. Если база недоступна, счётчики ведутся в памяти процесса.
+type LoginAttemptsUsecaseImpl struct {
+	attemptsStorage storage.LoginAttemptsPostgresStorage
+	fallback        storage.LoginThrottleStorage
+	config          LoginThrottleConfig
+}
+
+func NewLoginAttemptsUsecase(attemptsStorage storage.LoginAttemptsPostgresStorage, fallback storage.LoginThrottleStorage, config LoginThrottleConfig) *LoginAttemptsUsecaseImpl {
+	return &LoginAttemptsUsecaseImpl{attemptsStorage: attemptsStorage, fallback: fallback, config: config}
+}
+
+// CheckLogin возвращает ErrTooManyAttempts и время до снятия блокировки,
+// если вход для учётной записи или IP временно запрещён.
+func (l *LoginAttemptsUsecaseImpl) CheckLogin(ctx context.Context, email, ip string) (time.Duration, error) {
+	ctx, span := tracer.Start(ctx, "LoginAttemptsUsecase.CheckLogin")
+	defer span.End()
+
+	now := time.Now()
+	var retryAfter time.Duration
+
+	for _, key := range l.keys(email, ip) {
+		var throttle *entity.LoginThrottle
+		err := l.withFallback(ctx, func(s storage.LoginThrottleStorage) error {
+			var err error
+			throttle, err = s.GetThrottle(ctx, key)
+			if err == sql.ErrNoRows {
+				throttle = nil
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to get login throttle: %w", err)
+		}
+
+		if throttle != nil && throttle.LockedUntil.After(now) {
+			if wait := throttle.LockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return retryAfter, ErrTooManyAttempts
+	}
+	return 0, nil
+}
+
+func (l *LoginAttemptsUsecaseImpl) RecordFailure(ctx context.Context, email, ip string) error {
+	ctx, span := tracer.Start(ctx, "LoginAttemptsUsecase.RecordFailure")
+	defer span.End()
+
+	now := time.Now()
+	l.recordAttempt(ctx, email, ip, false, now)
+
+	limits := []int{l.config.MaxAccountFailures, l.config.MaxIPFailures}
+	for i, key := range l.keys(email, ip) {
+		err := l.withFallback(ctx, func(s storage.LoginThrottleStorage) error {
+			failures, err := s.IncrementFailures(ctx, key, now, l.config.Window)
+			if err != nil {
+				return err
+			}
+			if failures < limits[i] {
+				return nil
+			}
+			return s.LockUntil(ctx, key, now.Add(l.lockout(failures-limits[i])))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record login failure: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordSuccess сбрасывает счётчик учётной записи. Счётчик IP не сбрасывается,
+// чтобы успешный вход в свой аккаунт не открывал перебор чужих.
+func (l *LoginAttemptsUsecaseImpl) RecordSuccess(ctx context.Context, email, ip string) error {
+	ctx, span := tracer.Start(ctx, "LoginAttemptsUsecase.RecordSuccess")
+	defer span.End()
+
+	l.recordAttempt(ctx, email, ip, true, time.Now())
+
+	key := l.keys(email, ip)[0]
+	err := l.withFallback(ctx, func(s storage.LoginThrottleStorage) error {
+		return s.ResetThrottle(ctx, key)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+	return nil
+}
+
+func (l *LoginAttemptsUsecaseImpl) ListAttempts(ctx context.Context, filter entity.LoginAttemptFilter) ([]entity.LoginAttempt, error) {
+	ctx, span := tracer.Start(ctx, "LoginAttemptsUsecase.ListAttempts")
+	defer span.End()
+
+	filter.Email = normalizeEmail(filter.Email)
+	attempts, err := l.attemptsStorage.ListAttempts(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// lockout удваивает время блокировки за каждую неудачу сверх лимита.
+func (l *LoginAttemptsUsecaseImpl) lockout(excess int) time.Duration {
+	lockout := float64(l.config.BaseLockout) * math.Pow(2, float64(excess))
+	if lockout > float64(l.config.MaxLockout) {
+		return l.config.MaxLockout
+	}
+	return time.Duration(lockout)
+}
+
+func (l *LoginAttemptsUsecaseImpl) keys(email, ip string) []string {
+	return []string{"account:" + normalizeEmail(email), "ip:" + ip}
+}
+
+// recordAttempt пишет попытку в журнал. Журнал не должен мешать входу,
+// поэтому ошибка только логируется.
+func (l *LoginAttemptsUsecaseImpl) recordAttempt(ctx context.Context, email, ip string, success bool, now time.Time) {
+	err := l.attemptsStorage.RecordAttempt(ctx, entity.LoginAttempt{
+		ID:          uuid.Must(uuid.NewV4()),
+		Email:       normalizeEmail(email),
+		IP:          ip,
+		Success:     success,
+		AttemptedAt: now,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to record login attempt", slog.Any("error", err))
+	}
+}
+
+func (l *LoginAttemptsUsecaseImpl) withFallback(ctx context.Context, fn func(storage.LoginThrottleStorage) error) error {
+	err := fn(l.attemptsStorage)
+	if err == nil || l.fallback == nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Warn("login throttle storage unavailable, using in-memory fallback", slog.Any("error", err))
+	return fn(l.fallback)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
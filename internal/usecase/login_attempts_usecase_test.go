@@ -0,0 +1,141 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLoginAttemptsStorage хранит счётчики в памяти и умеет имитировать
+// недоступность базы.
+type fakeLoginAttemptsStorage struct {
+	*storage.MemoryLoginThrottleStorage
+	attempts []entity.LoginAttempt
+	down     bool
+}
+
+func newFakeLoginAttemptsStorage() *fakeLoginAttemptsStorage {
+	return &fakeLoginAttemptsStorage{MemoryLoginThrottleStorage: storage.NewMemoryLoginThrottleStorage()}
+}
+
+var errDatabaseDown = errors.New("database is down")
+
+func (f *fakeLoginAttemptsStorage) GetThrottle(ctx context.Context, key string) (*entity.LoginThrottle, error) {
+	if f.down {
+		return nil, errDatabaseDown
+	}
+	return f.MemoryLoginThrottleStorage.GetThrottle(context.Background(), key)
+}
+
+func (f *fakeLoginAttemptsStorage) IncrementFailures(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	if f.down {
+		return 0, errDatabaseDown
+	}
+	return f.MemoryLoginThrottleStorage.IncrementFailures(context.Background(), key, now, window)
+}
+
+func (f *fakeLoginAttemptsStorage) RecordAttempt(ctx context.Context, attempt entity.LoginAttempt) error {
+	if f.down {
+		return errDatabaseDown
+	}
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func (f *fakeLoginAttemptsStorage) ListAttempts(ctx context.Context, filter entity.LoginAttemptFilter) ([]entity.LoginAttempt, error) {
+	return f.attempts, nil
+}
+
+func testThrottleConfig() usecase.LoginThrottleConfig {
+	return usecase.LoginThrottleConfig{
+		MaxAccountFailures: 3,
+		MaxIPFailures:      5,
+		Window:             time.Minute,
+		BaseLockout:        10 * time.Second,
+		MaxLockout:         time.Minute,
+	}
+}
+
+func TestLoginAttemptsUsecase_AccountLockout(t *testing.T) {
+	attemptsStorage := newFakeLoginAttemptsStorage()
+	tracker := usecase.NewLoginAttemptsUsecase(attemptsStorage, nil, testThrottleConfig())
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, tracker.RecordFailure(context.Background(), "User@Example.com", "10.0.0.1"))
+	}
+	_, err := tracker.CheckLogin(context.Background(), "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+
+	require.NoError(t, tracker.RecordFailure(context.Background(), "user@example.com", "10.0.0.1"))
+	retryAfter, err := tracker.CheckLogin(context.Background(), "user@example.com", "10.0.0.2")
+	assert.ErrorIs(t, err, usecase.ErrTooManyAttempts)
+	assert.InDelta(t, 10*time.Second, retryAfter, float64(time.Second))
+
+	// каждая следующая неудача удваивает блокировку
+	require.NoError(t, tracker.RecordFailure(context.Background(), "user@example.com", "10.0.0.1"))
+	retryAfter, _ = tracker.CheckLogin(context.Background(), "user@example.com", "10.0.0.2")
+	assert.InDelta(t, 20*time.Second, retryAfter, float64(time.Second))
+
+	_, err = tracker.CheckLogin(context.Background(), "other@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+
+	assert.Len(t, attemptsStorage.attempts, 4)
+	assert.Equal(t, "user@example.com", attemptsStorage.attempts[0].Email)
+	assert.False(t, attemptsStorage.attempts[0].Success)
+}
+
+func TestLoginAttemptsUsecase_IPLockout(t *testing.T) {
+	tracker := usecase.NewLoginAttemptsUsecase(newFakeLoginAttemptsStorage(), nil, testThrottleConfig())
+
+	emails := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"}
+	for _, email := range emails {
+		require.NoError(t, tracker.RecordFailure(context.Background(), email, "10.0.0.1"))
+	}
+
+	_, err := tracker.CheckLogin(context.Background(), "f@example.com", "10.0.0.1")
+	assert.ErrorIs(t, err, usecase.ErrTooManyAttempts)
+
+	_, err = tracker.CheckLogin(context.Background(), "f@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+}
+
+func TestLoginAttemptsUsecase_SuccessResetsAccount(t *testing.T) {
+	tracker := usecase.NewLoginAttemptsUsecase(newFakeLoginAttemptsStorage(), nil, testThrottleConfig())
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, tracker.RecordFailure(context.Background(), "user@example.com", "10.0.0.1"))
+	}
+	require.NoError(t, tracker.RecordSuccess(context.Background(), "user@example.com", "10.0.0.1"))
+	require.NoError(t, tracker.RecordFailure(context.Background(), "user@example.com", "10.0.0.1"))
+
+	_, err := tracker.CheckLogin(context.Background(), "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestLoginAttemptsUsecase_InMemoryFallback(t *testing.T) {
+	attemptsStorage := newFakeLoginAttemptsStorage()
+	attemptsStorage.down = true
+	tracker := usecase.NewLoginAttemptsUsecase(attemptsStorage, storage.NewMemoryLoginThrottleStorage(), testThrottleConfig())
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, tracker.RecordFailure(context.Background(), "user@example.com", "10.0.0.1"))
+	}
+
+	_, err := tracker.CheckLogin(context.Background(), "user@example.com", "10.0.0.1")
+	assert.ErrorIs(t, err, usecase.ErrTooManyAttempts)
+	assert.Empty(t, attemptsStorage.attempts)
+
+	t.Run("without fallback", func(t *testing.T) {
+		tracker := usecase.NewLoginAttemptsUsecase(attemptsStorage, nil, testThrottleConfig())
+
+		_, err := tracker.CheckLogin(context.Background(), "user@example.com", "10.0.0.1")
+		assert.ErrorIs(t, err, errDatabaseDown)
+	})
+}
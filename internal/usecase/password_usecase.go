@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"pvz/internal/apperr"
+	"pvz/internal/logging"
+	"pvz/internal/notifier"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passwordResetTTL = time.Hour
+
+var (
+	ErrInvalidPassword   = apperr.Validation("invalid_password", "invalid current password")
+	ErrInvalidResetToken = apperr.Validation("invalid_reset_token", "invalid reset token")
+)
+
+type PasswordUsecase interface {
+	ChangePassword(ctx context.Context, actor entity.Actor, oldPassword, newPassword string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ConfirmPasswordReset(ctx context.Context, actor entity.Actor, token, newPassword string) error
+}
+
+type PasswordUsecaseImpl struct {
+	userStorage  storage.UsersPostgresStorage
+	resetStorage storage.PasswordResetsPostgresStorage
+	txManager    storage.TxManager
+	attempts     LoginAttemptsUsecase
+	notifier     notifier.Notifier
+	validator    *CredentialsValidator
+}
+
+func NewPasswordUsecase(userStorage storage.UsersPostgresStorage, resetStorage storage.PasswordResetsPostgresStorage, txManager storage.TxManager, attempts LoginAttemptsUsecase, notifier notifier.Notifier, validator *CredentialsValidator) *PasswordUsecaseImpl {
+	return &PasswordUsecaseImpl{userStorage: userStorage, resetStorage: resetStorage, txManager: txManager, attempts: attempts, notifier: notifier, validator: validator}
+}
+
+// ChangePassword проверяет текущий пароль через те же счётчики, что и вход,
+// иначе украденный access токен позволял бы подбирать пароль без ограничений.
+func (p *PasswordUsecaseImpl) ChangePassword(ctx context.Context, actor entity.Actor, oldPassword, newPassword string) error {
+	ctx, span := tracer.Start(ctx, "PasswordUsecase.ChangePassword")
+	defer span.End()
+
+	if err := p.validator.ValidatePassword("newPassword", newPassword); err != nil {
+		return err
+	}
+
+	user, err := p.userStorage.GetUserByID(ctx, actor.UserID)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if _, err := p.attempts.CheckLogin(ctx, user.Email, actor.IP); err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword))
+	if err != nil {
+		if err := p.attempts.RecordFailure(ctx, user.Email, actor.IP); err != nil {
+			return err
+		}
+		return ErrInvalidPassword
+	}
+
+	if err := p.attempts.RecordSuccess(ctx, user.Email, actor.IP); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return p.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		return setPassword(ctx, tx, user.ID, hashedPassword, false, actor)
+	})
+}
+
+// RequestPasswordReset не сообщает, существует ли пользователь с таким email,
+// чтобы по ответу нельзя было перебирать зарегистрированные адреса. Токен
+// выпускается для любого адреса, а сохраняется и отправляется уже после
+// ответа, поэтому и время ответа от адреса не зависит.
+func (p *PasswordUsecaseImpl) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, span := tracer.Start(ctx, "PasswordUsecase.RequestPasswordReset")
+	defer span.End()
+
+	user, _, err := p.userStorage.GetUserByEmail(ctx, email)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+	tokenHash := hashToken(token)
+	expiresAt := time.Now().Add(passwordResetTTL)
+
+	if user == nil || user.Disabled {
+		return nil
+	}
+
+	go p.sendResetToken(context.WithoutCancel(ctx), user.ID, user.Email, token, tokenHash, expiresAt)
+	return nil
+}
+
+// sendResetToken работает после ответа клиенту, поэтому ошибки только логируются.
+func (p *PasswordUsecaseImpl) sendResetToken(ctx context.Context, userID uuid.UUID, email, token, tokenHash string, expiresAt time.Time) {
+	ctx, span := tracer.Start(ctx, "PasswordUsecase.sendResetToken")
+	defer span.End()
+
+	err := p.resetStorage.CreateResetToken(ctx, uuid.Must(uuid.NewV4()), userID, tokenHash, expiresAt)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to save reset token", slog.Any("error", err))
+		return
+	}
+
+	if err := p.notifier.SendPasswordReset(email, token, expiresAt); err != nil {
+		logging.FromContext(ctx).Error("failed to send reset token", slog.Any("error", err))
+	}
+}
+
+// ConfirmPasswordReset проверяет новый пароль до использования токена,
+// чтобы слабый пароль не сжигал токен. Запрос анонимный, поэтому в аудите
+// смена пароля записывается без автора.
+func (p *PasswordUsecaseImpl) ConfirmPasswordReset(ctx context.Context, actor entity.Actor, token, newPassword string) error {
+	ctx, span := tracer.Start(ctx, "PasswordUsecase.ConfirmPasswordReset")
+	defer span.End()
+
+	if err := p.validator.ValidatePassword("newPassword", newPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// Токен погашается вместе со сменой пароля: если смена не удалась,
+	// токен остаётся действительным.
+	return p.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		userID, err := tx.PasswordResets().UseResetToken(ctx, hashToken(token))
+		if err == sql.ErrNoRows {
+			return ErrInvalidResetToken
+		} else if err != nil {
+			return fmt.Errorf("failed to use reset token: %w", err)
+		}
+
+		err = setPassword(ctx, tx, userID, hashedPassword, true, actor)
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	})
+}
+
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashedPassword), nil
+}
+
+// setPassword после смены пароля завершает все сессии пользователя
+// и аннулирует оставшиеся токены сброса.
+func setPassword(ctx context.Context, tx storage.Tx, userID uuid.UUID, hashedPassword string, reset bool, actor entity.Actor) error {
+	err := tx.Users().UpdatePassword(ctx, userID, hashedPassword, reset, actor)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := tx.Tokens().RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := tx.PasswordResets().InvalidateUserResetTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+	}
+	return nil
+}
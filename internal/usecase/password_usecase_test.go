@@ -0,0 +1,295 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockPasswordResetsStorage struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetsStorage) CreateResetToken(ctx context.Context, id, user_id uuid.UUID, hash string, expires_at time.Time) error {
+	args := m.Called(ctx, id, user_id, hash, expires_at)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetsStorage) UseResetToken(ctx context.Context, hash string) (uuid.UUID, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockPasswordResetsStorage) InvalidateUserResetTokens(ctx context.Context, user_id uuid.UUID) error {
+	args := m.Called(ctx, user_id)
+	return args.Error(0)
+}
+
+type MockTokensStorage struct {
+	mock.Mock
+}
+
+func (m *MockTokensStorage) CreateRefreshToken(ctx context.Context, id, user_id uuid.UUID, hash string, expires_at time.Time) error {
+	args := m.Called(ctx, id, user_id, hash, expires_at)
+	return args.Error(0)
+}
+
+func (m *MockTokensStorage) GetRefreshTokenByHash(ctx context.Context, hash string) (*entity.RefreshToken, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(*entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockTokensStorage) RevokeRefreshToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokensStorage) RevokeUserRefreshTokens(ctx context.Context, user_id uuid.UUID) error {
+	args := m.Called(ctx, user_id)
+	return args.Error(0)
+}
+
+func (m *MockTokensStorage) RevokeAccessToken(ctx context.Context, jti uuid.UUID, expires_at time.Time) error {
+	args := m.Called(ctx, jti, expires_at)
+	return args.Error(0)
+}
+
+func (m *MockTokensStorage) IsAccessTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) SendPasswordReset(email, token string, expiresAt time.Time) error {
+	args := m.Called(email, token, expiresAt)
+	return args.Error(0)
+}
+
+type MockLoginAttemptsUsecase struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptsUsecase) CheckLogin(ctx context.Context, email, ip string) (time.Duration, error) {
+	args := m.Called(ctx, email, ip)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginAttemptsUsecase) RecordFailure(ctx context.Context, email, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptsUsecase) RecordSuccess(ctx context.Context, email, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptsUsecase) ListAttempts(ctx context.Context, filter entity.LoginAttemptFilter) ([]entity.LoginAttempt, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entity.LoginAttempt), args.Error(1)
+}
+
+func TestPasswordUsecase_ChangePassword(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	email := "test@example.com"
+	ip := "10.0.0.1"
+	actor := entity.Actor{UserID: user_id, IP: ip}
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.DefaultCost)
+
+	tests := []struct {
+		name          string
+		oldPassword   string
+		newPassword   string
+		attempts      func(*MockLoginAttemptsUsecase)
+		expectedError error
+	}{
+		{
+			name:        "success",
+			oldPassword: "old-password",
+			newPassword: "N3w-Password",
+			attempts: func(mla *MockLoginAttemptsUsecase) {
+				mla.On("CheckLogin", anyCtx, email, ip).Return(time.Duration(0), nil)
+				mla.On("RecordSuccess", anyCtx, email, ip).Return(nil)
+			},
+		},
+		{
+			name:        "wrong current password",
+			oldPassword: "wrong-password",
+			newPassword: "N3w-Password",
+			attempts: func(mla *MockLoginAttemptsUsecase) {
+				mla.On("CheckLogin", anyCtx, email, ip).Return(time.Duration(0), nil)
+				mla.On("RecordFailure", anyCtx, email, ip).Return(nil)
+			},
+			expectedError: usecase.ErrInvalidPassword,
+		},
+		{
+			name:        "throttled",
+			oldPassword: "old-password",
+			newPassword: "N3w-Password",
+			attempts: func(mla *MockLoginAttemptsUsecase) {
+				mla.On("CheckLogin", anyCtx, email, ip).Return(time.Minute, usecase.ErrTooManyAttempts)
+			},
+			expectedError: usecase.ErrTooManyAttempts,
+		},
+		{
+			name:        "weak new password",
+			oldPassword: "old-password",
+			newPassword: "weak",
+			attempts:    func(mla *MockLoginAttemptsUsecase) {},
+			expectedError: &usecase.ValidationError{Fields: []usecase.FieldError{
+				{Field: "newPassword", Message: "must be at least 8 characters long"},
+				{Field: "newPassword", Message: "must contain an uppercase letter"},
+				{Field: "newPassword", Message: "must contain a digit"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			UserStorage := new(MockUsersStorage)
+			ResetStorage := new(MockPasswordResetsStorage)
+			TokenStorage := new(MockTokensStorage)
+			Attempts := new(MockLoginAttemptsUsecase)
+			tt.attempts(Attempts)
+			usecase := usecase.NewPasswordUsecase(UserStorage, ResetStorage, &fakeTx{users: UserStorage, resets: ResetStorage, tokens: TokenStorage}, Attempts, new(MockNotifier), testValidator())
+
+			if tt.newPassword != "weak" {
+				UserStorage.On("GetUserByID", anyCtx, user_id).Return(&entity.User{ID: user_id, Email: email, Password: string(hashedPassword)}, nil)
+			}
+			if tt.expectedError == nil {
+				UserStorage.On("UpdatePassword", anyCtx, user_id, mock.AnythingOfType("string"), false, actor).Return(nil)
+				TokenStorage.On("RevokeUserRefreshTokens", anyCtx, user_id).Return(nil)
+				ResetStorage.On("InvalidateUserResetTokens", anyCtx, user_id).Return(nil)
+			}
+
+			err := usecase.ChangePassword(context.Background(), actor, tt.oldPassword, tt.newPassword)
+
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			UserStorage.AssertExpectations(t)
+			ResetStorage.AssertExpectations(t)
+			TokenStorage.AssertExpectations(t)
+			Attempts.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPasswordUsecase_RequestPasswordReset(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	email := "test@example.com"
+
+	t.Run("token is stored hashed and sent in plain", func(t *testing.T) {
+		UserStorage := new(MockUsersStorage)
+		ResetStorage := new(MockPasswordResetsStorage)
+		Notifier := new(MockNotifier)
+		usecase := usecase.NewPasswordUsecase(UserStorage, ResetStorage, &fakeTx{users: UserStorage, resets: ResetStorage}, new(MockLoginAttemptsUsecase), Notifier, testValidator())
+
+		var storedHash, sentToken string
+		sent := make(chan struct{})
+		UserStorage.On("GetUserByEmail", anyCtx, email).Return(&entity.User{ID: user_id, Email: email}, true, nil)
+		ResetStorage.On("CreateResetToken", anyCtx, mock.Anything, user_id, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+			Run(func(args mock.Arguments) { storedHash = args.String(3) }).Return(nil)
+		Notifier.On("SendPasswordReset", email, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+			Run(func(args mock.Arguments) { sentToken = args.String(1); close(sent) }).Return(nil)
+
+		require.NoError(t, usecase.RequestPasswordReset(context.Background(), email))
+
+		select {
+		case <-sent:
+		case <-time.After(time.Second):
+			t.Fatal("reset token was not sent")
+		}
+		assert.NotEmpty(t, sentToken)
+		assert.Len(t, storedHash, 64)
+		assert.NotEqual(t, sentToken, storedHash)
+		UserStorage.AssertExpectations(t)
+		ResetStorage.AssertExpectations(t)
+		Notifier.AssertExpectations(t)
+	})
+
+	for _, tt := range []struct {
+		name   string
+		user   *entity.User
+		getErr error
+	}{
+		{name: "unknown email", user: nil, getErr: sql.ErrNoRows},
+		{name: "disabled user", user: &entity.User{ID: user_id, Email: email, Disabled: true}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			UserStorage := new(MockUsersStorage)
+			ResetStorage := new(MockPasswordResetsStorage)
+			Notifier := new(MockNotifier)
+			usecase := usecase.NewPasswordUsecase(UserStorage, ResetStorage, &fakeTx{users: UserStorage}, new(MockLoginAttemptsUsecase), Notifier, testValidator())
+
+			UserStorage.On("GetUserByEmail", anyCtx, email).Return(tt.user, tt.user != nil, tt.getErr)
+
+			assert.NoError(t, usecase.RequestPasswordReset(context.Background(), email))
+			ResetStorage.AssertNotCalled(t, "CreateResetToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			Notifier.AssertNotCalled(t, "SendPasswordReset", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPasswordUsecase_ConfirmPasswordReset(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{RequestID: "request", IP: "10.0.0.1"}
+
+	tests := []struct {
+		name          string
+		useError      error
+		expectedError error
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:          "used or expired token",
+			useError:      sql.ErrNoRows,
+			expectedError: usecase.ErrInvalidResetToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			UserStorage := new(MockUsersStorage)
+			ResetStorage := new(MockPasswordResetsStorage)
+			TokenStorage := new(MockTokensStorage)
+			usecase := usecase.NewPasswordUsecase(UserStorage, ResetStorage, &fakeTx{users: UserStorage, resets: ResetStorage, tokens: TokenStorage}, new(MockLoginAttemptsUsecase), new(MockNotifier), testValidator())
+
+			ResetStorage.On("UseResetToken", anyCtx, mock.AnythingOfType("string")).Return(user_id, tt.useError)
+			if tt.expectedError == nil {
+				UserStorage.On("UpdatePassword", anyCtx, user_id, mock.AnythingOfType("string"), true, actor).Return(nil)
+				TokenStorage.On("RevokeUserRefreshTokens", anyCtx, user_id).Return(nil)
+				ResetStorage.On("InvalidateUserResetTokens", anyCtx, user_id).Return(nil)
+			}
+
+			err := usecase.ConfirmPasswordReset(context.Background(), actor, "token", "N3w-Password")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			UserStorage.AssertExpectations(t)
+			ResetStorage.AssertExpectations(t)
+			TokenStorage.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type Permission string
+
+const (
+	PermPVZCreate      Permission = "pvz:create"
+	PermPVZRead        Permission = "pvz:read"
+	PermPVZAssign      Permission = "pvz:assign"
+	PermReceptionOpen  Permission = "reception:open"
+	PermReceptionClose Permission = "reception:close"
+	PermProductCreate  Permission = "product:create"
+	PermProductDelete  Permission = "product:delete"
+	PermUserRead       Permission = "user:read"
+	PermUserManage     Permission = "user:manage"
+	PermAPIKeyManage   Permission = "apikey:manage"
+	PermAuditRead      Permission = "audit:read"
+)
+
+// Policy сопоставляет ролям набор разрешённых действий. Кроме того, она
+// отмечает роли, которым для любых действий нужен второй фактор (по
+// умолчанию таких нет), и роли, которые работают с приёмками любого ПВЗ
+// без назначения.
+type Policy struct {
+	roles      map[string]map[Permission]struct{}
+	twoFactor  map[string]struct{}
+	unassigned map[string]struct{}
+}
+
+func NewPolicy(rules map[string][]Permission) *Policy {
+	policy := &Policy{
+		roles:      make(map[string]map[Permission]struct{}),
+		twoFactor:  make(map[string]struct{}),
+		unassigned: make(map[string]struct{}),
+	}
+	for role, permissions := range rules {
+		policy.Grant(role, permissions...)
+	}
+	return policy
+}
+
+func DefaultPolicy() *Policy {
+	policy := NewPolicy(map[string][]Permission{
+		"moderator": {
+			PermPVZCreate, PermPVZRead, PermPVZAssign,
+			PermUserRead, PermUserManage, PermAPIKeyManage,
+			PermAuditRead,
+		},
+		"employee": {
+			PermPVZRead,
+			PermReceptionOpen, PermReceptionClose,
+			PermProductCreate, PermProductDelete,
+		},
+		"admin": {
+			PermPVZCreate, PermPVZRead, PermPVZAssign,
+			PermReceptionOpen, PermReceptionClose,
+			PermProductCreate, PermProductDelete,
+			PermUserRead, PermUserManage, PermAPIKeyManage,
+			PermAuditRead,
+		},
+		"auditor": {PermPVZRead, PermUserRead, PermAuditRead},
+	})
+	policy.ExemptFromAssignment("admin")
+	return policy
+}
+
+// LoadPolicy дополняет политику по умолчанию ролями из JSON-файла вида
+// {"roles": {"auditor": ["pvz:read"]}, "twoFactorRoles": ["moderator"],
+// "unassignedRoles": ["admin"]}. Роли из файла заменяют одноимённые,
+// twoFactorRoles и unassignedRoles, если заданы, полностью заменяют
+// соответствующие списки.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var file struct {
+		Roles           map[string][]Permission `json:"roles"`
+		TwoFactorRoles  []string                `json:"twoFactorRoles"`
+		UnassignedRoles []string                `json:"unassignedRoles"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	policy := DefaultPolicy()
+	for role, permissions := range file.Roles {
+		delete(policy.roles, role)
+		policy.Grant(role, permissions...)
+	}
+	if file.TwoFactorRoles != nil {
+		policy.twoFactor = make(map[string]struct{})
+		policy.RequireTwoFactor(file.TwoFactorRoles...)
+	}
+	if file.UnassignedRoles != nil {
+		policy.unassigned = make(map[string]struct{})
+		policy.ExemptFromAssignment(file.UnassignedRoles...)
+	}
+	return policy, nil
+}
+
+func (p *Policy) Grant(role string, permissions ...Permission) {
+	granted, ok := p.roles[role]
+	if !ok {
+		granted = make(map[Permission]struct{})
+		p.roles[role] = granted
+	}
+	for _, permission := range permissions {
+		granted[permission] = struct{}{}
+	}
+}
+
+func (p *Policy) Allowed(role string, permission Permission) bool {
+	_, ok := p.roles[role][permission]
+	return ok
+}
+
+// Covers сообщает, есть ли у роли role все разрешения роли other.
+func (p *Policy) Covers(role, other string) bool {
+	for permission := range p.roles[other] {
+		if !p.Allowed(role, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// AdminRoles возвращает роли, у которых есть все разрешения любой другой
+// роли политики.
+func (p *Policy) AdminRoles() []string {
+	var admins []string
+	for _, role := range p.Roles() {
+		admin := true
+		for other := range p.roles {
+			if !p.Covers(role, other) {
+				admin = false
+				break
+			}
+		}
+		if admin {
+			admins = append(admins, role)
+		}
+	}
+	return admins
+}
+
+func (p *Policy) RequireTwoFactor(roles ...string) {
+	for _, role := range roles {
+		p.twoFactor[role] = struct{}{}
+	}
+}
+
+func (p *Policy) TwoFactorRequired(role string) bool {
+	_, ok := p.twoFactor[role]
+	return ok
+}
+
+// ExemptFromAssignment снимает с ролей требование быть назначенными на ПВЗ
+// для работы с его приёмками и товарами.
+func (p *Policy) ExemptFromAssignment(roles ...string) {
+	for _, role := range roles {
+		p.unassigned[role] = struct{}{}
+	}
+}
+
+// AssignmentRequired сообщает, нужно ли роли назначение на ПВЗ. Оно нужно
+// только ролям, которые работают с приёмками или товарами и не освобождены
+// от назначения.
+func (p *Policy) AssignmentRequired(role string) bool {
+	if _, ok := p.unassigned[role]; ok {
+		return false
+	}
+	for _, permission := range []Permission{PermReceptionOpen, PermReceptionClose, PermProductCreate, PermProductDelete} {
+		if p.Allowed(role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) HasRole(role string) bool {
+	_, ok := p.roles[role]
+	return ok
+}
+
+func (p *Policy) Roles() []string {
+	roles := make([]string, 0, len(p.roles))
+	for role := range p.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
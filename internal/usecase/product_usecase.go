@@ -1,62 +1,122 @@
 package usecase
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"pvz/internal/apperr"
 	"pvz/internal/storage"
 	"pvz/internal/storage/migrations/entity"
 
 	"github.com/gofrs/uuid/v5"
 )
 
+var (
+	ErrNoProducts             = apperr.Validation("no_products", "no products in reception")
+	ErrUnsupportedProductType = apperr.Validation("unsupported_product_type", "unsupported product type")
+)
+
 type ProductUsecase interface {
-	CreateProduct(id uuid.UUID, product_type string) (*entity.Products, error)
-	DeleteLastProduct(pvz_id uuid.UUID) error
+	CreateProduct(ctx context.Context, actor entity.Actor, id uuid.UUID, product_type string) (*entity.Products, error)
+	DeleteLastProduct(ctx context.Context, actor entity.Actor, pvz_id uuid.UUID) error
 }
 
 type ProductUsecaseImpl struct {
-	productStorage   storage.ProductPostgresStorage
-	receptionStorage storage.ReceptionPostgresStorage
+	txManager         storage.TxManager
+	assignmentStorage storage.AssignmentsPostgresStorage
+	policy            *Policy
+	events            EventRecorder
 }
 
-func NewProductUsecase(productStorage storage.ProductPostgresStorage, receptionStorage storage.ReceptionPostgresStorage) *ProductUsecaseImpl {
-	return &ProductUsecaseImpl{productStorage: productStorage, receptionStorage: receptionStorage}
+func NewProductUsecase(txManager storage.TxManager, assignmentStorage storage.AssignmentsPostgresStorage, policy *Policy, events EventRecorder) *ProductUsecaseImpl {
+	return &ProductUsecaseImpl{txManager: txManager, assignmentStorage: assignmentStorage, policy: policy, events: events}
 }
 
-func (p *ProductUsecaseImpl) CreateProduct(id uuid.UUID, product_type string) (*entity.Products, error) {
-	reception_id, status, err := p.receptionStorage.GetLastReceptionStatus(id)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to check reception status: %w", err)
-	}
-	if status == "close" {
-		return nil, fmt.Errorf("no available receptions")
+// CreateProduct держит блокировку приёмки до вставки товара, поэтому
+// приёмку нельзя закрыть между проверкой статуса и добавлением.
+func (p *ProductUsecaseImpl) CreateProduct(ctx context.Context, actor entity.Actor, id uuid.UUID, product_type string) (*entity.Products, error) {
+	ctx, span := tracer.Start(ctx, "ProductUsecase.CreateProduct")
+	defer span.End()
+
+	if err := checkAssignment(ctx, p.assignmentStorage, p.policy, actor, id); err != nil {
+		return nil, err
 	}
 
-	products, err := p.productStorage.CreateProduct(reception_id, product_type)
+	var products *entity.Products
+	var city string
+	err := p.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		var err error
+		city, err = pvzCity(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		reception_id, status, err := tx.Receptions().GetLastReceptionStatus(ctx, id)
+		if err == sql.ErrNoRows {
+			return ErrNoOpenReception
+		} else if err != nil {
+			return fmt.Errorf("failed to check reception status: %w", err)
+		}
+		if status == "close" {
+			return ErrNoOpenReception
+		}
+
+		products, err = tx.Products().CreateProduct(ctx, reception_id, product_type, actor)
+		if errors.Is(err, storage.ErrUnsupportedProductType) {
+			return ErrUnsupportedProductType
+		} else if err != nil {
+			return fmt.Errorf("failed to create new product: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new product: %w", err)
+		return nil, err
 	}
+	p.events.ProductAdded(city, product_type)
 	return products, nil
 }
 
-func (p *ProductUsecaseImpl) DeleteLastProduct(pvz_id uuid.UUID) error {
-	reception_id, status, err := p.receptionStorage.GetLastReceptionStatus(pvz_id)
-	if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("failed to check reception status: %w", err)
-	}
-	if status == "close" {
-		return fmt.Errorf("no available receptions")
-	}
+func (p *ProductUsecaseImpl) DeleteLastProduct(ctx context.Context, actor entity.Actor, pvz_id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "ProductUsecase.DeleteLastProduct")
+	defer span.End()
 
-	product_id, err := p.productStorage.GetLastProductID(reception_id)
-	if err != nil {
+	if err := checkAssignment(ctx, p.assignmentStorage, p.policy, actor, pvz_id); err != nil {
 		return err
 	}
 
-	err = p.productStorage.DeleteProduct(product_id)
+	var deleted *entity.Products
+	var city string
+	err := p.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		var err error
+		city, err = pvzCity(ctx, tx, pvz_id)
+		if err != nil {
+			return err
+		}
+
+		reception_id, status, err := tx.Receptions().GetLastReceptionStatus(ctx, pvz_id)
+		if err == sql.ErrNoRows {
+			return ErrNoOpenReception
+		} else if err != nil {
+			return fmt.Errorf("failed to check reception status: %w", err)
+		}
+		if status == "close" {
+			return ErrNoOpenReception
+		}
+
+		product_id, err := tx.Products().GetLastProductID(ctx, reception_id)
+		if err == sql.ErrNoRows {
+			return ErrNoProducts
+		} else if err != nil {
+			return fmt.Errorf("failed to get last product: %w", err)
+		}
+
+		deleted, err = tx.Products().DeleteProduct(ctx, product_id, actor)
+		return err
+	})
 	if err != nil {
 		return err
 	}
-
+	p.events.ProductDeleted(city, deleted.Type)
 	return nil
 }
@@ -0,0 +1,178 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductStorage struct {
+	mock.Mock
+}
+
+func (m *MockProductStorage) CreateProduct(ctx context.Context, id uuid.UUID, product_type string, actor entity.Actor) (*entity.Products, error) {
+	args := m.Called(ctx, id, product_type, actor)
+	return args.Get(0).(*entity.Products), args.Error(1)
+}
+
+func (m *MockProductStorage) DeleteProduct(ctx context.Context, product_id uuid.UUID, actor entity.Actor) (*entity.Products, error) {
+	args := m.Called(ctx, product_id, actor)
+	return args.Get(0).(*entity.Products), args.Error(1)
+}
+
+func (m *MockProductStorage) GetLastProductID(ctx context.Context, reception_id uuid.UUID) (uuid.UUID, error) {
+	args := m.Called(ctx, reception_id)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func TestProductUsecase_CreateProduct(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	reception_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "employee"}
+
+	tests := []struct {
+		name           string
+		status         string
+		receptionError error
+		productError   error
+		expected       *entity.Products
+		expectedError  error
+	}{
+		{
+			name:     "success",
+			status:   "in_progress",
+			expected: &entity.Products{ID: uuid.Must(uuid.NewV4()), Type: "обувь", ReceptionId: reception_id},
+		},
+		{
+			name:          "unsupported product type",
+			status:        "in_progress",
+			productError:  storage.ErrUnsupportedProductType,
+			expectedError: usecase.ErrUnsupportedProductType,
+		},
+		{
+			name:          "reception closed",
+			status:        "close",
+			expectedError: usecase.ErrNoOpenReception,
+		},
+		{
+			name:           "pvz without receptions",
+			receptionError: sql.ErrNoRows,
+			expectedError:  usecase.ErrNoOpenReception,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			PVZStorage := new(MockPVZStorage)
+			ReceptionStorage := new(MockReceptionStorage)
+			ProductStorage := new(MockProductStorage)
+			AssignmentStorage := new(MockAssignmentStorage)
+			events := new(MockEventRecorder)
+			usecase := usecase.NewProductUsecase(&fakeTx{pvz: PVZStorage, receptions: ReceptionStorage, products: ProductStorage}, AssignmentStorage, usecase.DefaultPolicy(), events)
+
+			AssignmentStorage.On("IsAssigned", anyCtx, actor.UserID, pvz_id).Return(true, nil)
+			PVZStorage.On("GetPVZById", anyCtx, pvz_id).Return(&entity.PVZ{ID: pvz_id, City: "Москва"}, nil)
+			if tt.receptionError != nil {
+				ReceptionStorage.On("GetLastReceptionStatus", anyCtx, pvz_id).Return(uuid.UUID{}, "", tt.receptionError)
+			} else {
+				ReceptionStorage.On("GetLastReceptionStatus", anyCtx, pvz_id).Return(reception_id, tt.status, nil)
+			}
+			if tt.productError != nil {
+				ProductStorage.On("CreateProduct", anyCtx, reception_id, "обувь", actor).Return((*entity.Products)(nil), tt.productError)
+			}
+			if tt.expectedError == nil {
+				ProductStorage.On("CreateProduct", anyCtx, reception_id, "обувь", actor).Return(tt.expected, nil)
+				events.On("ProductAdded", "Москва", "обувь").Return()
+			}
+
+			product, err := usecase.CreateProduct(context.Background(), actor, pvz_id, "обувь")
+			if tt.expectedError != nil {
+				assert.EqualError(t, err, tt.expectedError.Error())
+				assert.Nil(t, product)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, product)
+			}
+			ReceptionStorage.AssertExpectations(t)
+			ProductStorage.AssertExpectations(t)
+			events.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProductUsecase_DeleteLastProduct(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	reception_id := uuid.Must(uuid.NewV4())
+	product_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "employee"}
+
+	tests := []struct {
+		name             string
+		status           string
+		receptionError   error
+		lastProductError error
+		expectedError    error
+	}{
+		{
+			name:   "success",
+			status: "in_progress",
+		},
+		{
+			name:          "reception closed",
+			status:        "close",
+			expectedError: usecase.ErrNoOpenReception,
+		},
+		{
+			name:           "pvz without receptions",
+			receptionError: sql.ErrNoRows,
+			expectedError:  usecase.ErrNoOpenReception,
+		},
+		{
+			name:             "empty reception",
+			status:           "in_progress",
+			lastProductError: sql.ErrNoRows,
+			expectedError:    usecase.ErrNoProducts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			PVZStorage := new(MockPVZStorage)
+			ReceptionStorage := new(MockReceptionStorage)
+			ProductStorage := new(MockProductStorage)
+			AssignmentStorage := new(MockAssignmentStorage)
+			events := new(MockEventRecorder)
+			usecase := usecase.NewProductUsecase(&fakeTx{pvz: PVZStorage, receptions: ReceptionStorage, products: ProductStorage}, AssignmentStorage, usecase.DefaultPolicy(), events)
+
+			AssignmentStorage.On("IsAssigned", anyCtx, actor.UserID, pvz_id).Return(true, nil)
+			PVZStorage.On("GetPVZById", anyCtx, pvz_id).Return(&entity.PVZ{ID: pvz_id, City: "Казань"}, nil)
+			if tt.receptionError != nil {
+				ReceptionStorage.On("GetLastReceptionStatus", anyCtx, pvz_id).Return(uuid.UUID{}, "", tt.receptionError)
+			} else {
+				ReceptionStorage.On("GetLastReceptionStatus", anyCtx, pvz_id).Return(reception_id, tt.status, nil)
+			}
+			if tt.lastProductError != nil {
+				ProductStorage.On("GetLastProductID", anyCtx, reception_id).Return(uuid.UUID{}, tt.lastProductError)
+			}
+			if tt.expectedError == nil {
+				ProductStorage.On("GetLastProductID", anyCtx, reception_id).Return(product_id, nil)
+				ProductStorage.On("DeleteProduct", anyCtx, product_id, actor).
+					Return(&entity.Products{ID: product_id, Type: "электроника", ReceptionId: reception_id}, nil)
+				events.On("ProductDeleted", "Казань", "электроника").Return()
+			}
+
+			err := usecase.DeleteLastProduct(context.Background(), actor, pvz_id)
+
+			assert.Equal(t, tt.expectedError, err)
+			ProductStorage.AssertExpectations(t)
+			events.AssertExpectations(t)
+		})
+	}
+}
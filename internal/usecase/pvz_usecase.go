@@ -5,52 +5,133 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/url"
+	"pvz/internal/apperr"
 	"pvz/internal/storage"
 	"pvz/internal/storage/migrations/entity"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
 )
 
+var (
+	ErrPVZExists       = apperr.Conflict("pvz_exists", "pvz exists")
+	ErrUnsupportedCity = apperr.Validation("unsupported_city", "unsupported city")
+)
+
 type PVZUsecase interface {
-	CreatePVZ(id, user_id uuid.UUID, city string, date time.Time) (*entity.PVZ, error)
-	GetPVZsWithFilter(ctx context.Context, filter entity.Filter) (*PVZListResponse, error)
+	CreatePVZ(ctx context.Context, id uuid.UUID, actor entity.Actor, city string, date time.Time) (*entity.PVZ, error)
+	// cursor — значение next_cursor предыдущей страницы; пустой cursor
+	// означает постраничный режим по filter.Page.
+	GetPVZsWithFilter(ctx context.Context, filter entity.Filter, cursor string) (*PVZListResponse, error)
+	GetPVZ(ctx context.Context, id uuid.UUID, filter entity.ReceptionFilter, cursor string) (*PVZDetailResponse, error)
 }
 
 type PVZUsecaseImpl struct {
 	pvzStorage storage.PVZPostgresStorage
+	events     EventRecorder
+	cursors    *CursorCodec
 }
 
+// PVZListResponse: в режиме курсора Page не заполняется. NextCursor пуст,
+// если страница неполная и дальше ничего нет.
 type PVZListResponse struct {
-	PVZs  []entity.ListPVZ `json:"pvzs"`
-	Total int              `json:"total"`
-	Page  int              `json:"page"`
-	Limit int              `json:"limit"`
+	PVZs       []entity.ListPVZ `json:"pvzs"`
+	Total      int              `json:"total"`
+	Page       int              `json:"page,omitempty"`
+	Limit      int              `json:"limit"`
+	NextCursor string           `json:"next_cursor,omitempty"`
 }
 
-func NewPVZUsecase(pvzStorage storage.PVZPostgresStorage) *PVZUsecaseImpl {
-	return &PVZUsecaseImpl{pvzStorage: pvzStorage}
+// PVZDetailResponse: Receptions — страница приёмок, счётчики и открытая
+// приёмка считаются по всем приёмкам ПВЗ.
+type PVZDetailResponse struct {
+	Pvz             entity.PVZ          `json:"pvz"`
+	Receptions      []entity.Receptions `json:"receptions"`
+	TotalReceptions int                 `json:"totalReceptions"`
+	TotalProducts   int                 `json:"totalProducts"`
+	OpenReceptionID *uuid.UUID          `json:"openReceptionId"`
+	Page            int                 `json:"page,omitempty"`
+	Limit           int                 `json:"limit"`
+	NextCursor      string              `json:"next_cursor,omitempty"`
 }
 
-func (p *PVZUsecaseImpl) CreatePVZ(id, user_id uuid.UUID, city string, date time.Time) (*entity.PVZ, error) {
+func NewPVZUsecase(pvzStorage storage.PVZPostgresStorage, events EventRecorder, cursors *CursorCodec) *PVZUsecaseImpl {
+	return &PVZUsecaseImpl{pvzStorage: pvzStorage, events: events, cursors: cursors}
+}
 
-	pvz, err := p.pvzStorage.GetPVZById(id)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to check PVZ existence: %w", err)
-	}
+// Курсор одного списка не подходит для другого: в scope списка ПВЗ входит
+// сортировка, у списка приёмок — идентификатор ПВЗ.
+func pvzListCursorScope(sort string) string {
+	return "pvz:" + sort
+}
 
-	if !pvz.ID.IsNil() {
-		return nil, errors.New("pvz exists")
+func receptionsCursorScope(pvz_id uuid.UUID) string {
+	return "pvz_receptions:" + pvz_id.String()
+}
+
+// pvzListCursorFilter нормализует фильтры списка ПВЗ для курсора. Page,
+// Limit и After на выборку не влияют и в него не входят.
+func pvzListCursorFilter(filter entity.Filter) string {
+	values := url.Values{}
+	if filter.StartDate != nil {
+		values.Set("start", filter.StartDate.UTC().Format(time.RFC3339Nano))
+	}
+	if filter.EndDate != nil {
+		values.Set("end", filter.EndDate.UTC().Format(time.RFC3339Nano))
+	}
+	values.Set("status", filter.Status)
+	values.Set("product_type", filter.ProductType)
+	values.Set("city", filter.City)
+	if filter.UserID != nil {
+		values.Set("user", filter.UserID.String())
+	}
+	// nil и пустой PVZIDs различаются: пустой список не пускает ни к одному ПВЗ
+	if filter.PVZIDs != nil {
+		ids := make([]string, 0, len(filter.PVZIDs))
+		for _, id := range filter.PVZIDs {
+			ids = append(ids, id.String())
+		}
+		sort.Strings(ids)
+		values.Set("pvz", strings.Join(ids, ","))
 	}
+	values.Set("include_empty", strconv.FormatBool(filter.IncludeEmpty))
+	return values.Encode()
+}
 
-	pvz, err = p.pvzStorage.CreatePVZ(id, user_id, city, date)
-	if err != nil {
+func (p *PVZUsecaseImpl) CreatePVZ(ctx context.Context, id uuid.UUID, actor entity.Actor, city string, date time.Time) (*entity.PVZ, error) {
+	ctx, span := tracer.Start(ctx, "PVZUsecase.CreatePVZ")
+	defer span.End()
+
+	// Занятый id определяет первичный ключ: предварительная проверка
+	// пропустила бы два одновременных запроса с одним id.
+	pvz, err := p.pvzStorage.CreatePVZ(ctx, id, actor, city, date)
+	if errors.Is(err, storage.ErrPVZExists) {
+		return nil, ErrPVZExists
+	} else if errors.Is(err, storage.ErrUnsupportedCity) {
+		return nil, ErrUnsupportedCity
+	} else if err != nil {
 		return nil, err
 	}
+	p.events.PVZCreated(city)
 	return pvz, nil
 }
 
-func (p *PVZUsecaseImpl) GetPVZsWithFilter(ctx context.Context, filter entity.Filter) (*PVZListResponse, error) {
+func (p *PVZUsecaseImpl) GetPVZsWithFilter(ctx context.Context, filter entity.Filter, cursor string) (*PVZListResponse, error) {
+	ctx, span := tracer.Start(ctx, "PVZUsecase.GetPVZsWithFilter")
+	defer span.End()
+
+	if cursor != "" {
+		after, err := p.cursors.Decode(pvzListCursorScope(filter.Sort), pvzListCursorFilter(filter), cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+	}
+
 	pvzs, err := p.pvzStorage.GetPVZsWithFilter(ctx, filter)
 	if err != nil {
 		return nil, err
@@ -61,10 +142,80 @@ func (p *PVZUsecaseImpl) GetPVZsWithFilter(ctx context.Context, filter entity.Fi
 		return nil, err
 	}
 
-	return &PVZListResponse{
+	response := &PVZListResponse{
 		PVZs:  pvzs,
 		Total: total,
 		Page:  filter.Page,
 		Limit: filter.Limit,
-	}, nil
+	}
+	if len(pvzs) == filter.Limit {
+		response.NextCursor = p.cursors.Encode(pvzListCursorScope(filter.Sort), pvzListCursorFilter(filter), pvzKeyset(pvzs[len(pvzs)-1], filter.Sort))
+	}
+	return response, nil
+}
+
+// pvzKeyset повторяет ключи сортировки хранилища по тому, что попало в
+// ответ: приёмки и товары в нём отобраны тем же фильтром. Первая приёмка —
+// самая поздняя из подходящих.
+func pvzKeyset(pvz entity.ListPVZ, sort string) entity.Keyset {
+	keyset := entity.Keyset{ID: pvz.Pvz.ID}
+	switch sort {
+	case entity.PVZSortRegistrationDate:
+		keyset.Time = pvz.Pvz.RegistrationDate
+	case entity.PVZSortProductCount:
+		for _, reception := range pvz.Receptions {
+			keyset.Count += len(reception.Products)
+		}
+	default:
+		keyset.Time = pvz.Pvz.RegistrationDate
+		if len(pvz.Receptions) > 0 {
+			keyset.Time = pvz.Receptions[0].DateTime
+		}
+	}
+	return keyset
+}
+
+func (p *PVZUsecaseImpl) GetPVZ(ctx context.Context, id uuid.UUID, filter entity.ReceptionFilter, cursor string) (*PVZDetailResponse, error) {
+	ctx, span := tracer.Start(ctx, "PVZUsecase.GetPVZ")
+	defer span.End()
+
+	if cursor != "" {
+		after, err := p.cursors.Decode(receptionsCursorScope(id), "", cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+	}
+
+	pvz, err := p.pvzStorage.GetPVZById(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrPVZNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get pvz: %w", err)
+	}
+
+	summary, err := p.pvzStorage.GetPVZSummary(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	receptions, err := p.pvzStorage.GetPVZReceptions(ctx, id, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PVZDetailResponse{
+		Pvz:             *pvz,
+		Receptions:      receptions,
+		TotalReceptions: summary.Receptions,
+		TotalProducts:   summary.Products,
+		OpenReceptionID: summary.OpenReceptionID,
+		Page:            filter.Page,
+		Limit:           filter.Limit,
+	}
+	if len(receptions) == filter.Limit {
+		last := receptions[len(receptions)-1]
+		response.NextCursor = p.cursors.Encode(receptionsCursorScope(id), "", entity.Keyset{Time: last.DateTime, ID: last.ID})
+	}
+	return response, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"pvz/internal/storage"
 	"pvz/internal/storage/migrations/entity"
 	"pvz/internal/usecase"
 	"testing"
@@ -12,19 +13,20 @@ import (
 	"github.com/gofrs/uuid/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockPVZStorage struct {
 	mock.Mock
 }
 
-func (m *MockPVZStorage) CreatePVZ(id, user_id uuid.UUID, city string, date time.Time) (*entity.PVZ, error) {
-	args := m.Called(id, user_id, city, date)
+func (m *MockPVZStorage) CreatePVZ(ctx context.Context, id uuid.UUID, actor entity.Actor, city string, date time.Time) (*entity.PVZ, error) {
+	args := m.Called(ctx, id, actor, city, date)
 	return args.Get(0).(*entity.PVZ), args.Error(1)
 }
 
-func (m *MockPVZStorage) GetPVZById(id uuid.UUID) (*entity.PVZ, error) {
-	args := m.Called(id)
+func (m *MockPVZStorage) GetPVZById(ctx context.Context, id uuid.UUID) (*entity.PVZ, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*entity.PVZ), args.Error(1)
 }
 
@@ -38,6 +40,16 @@ func (m *MockPVZStorage) CountPVZsWithFilter(ctx context.Context, filter entity.
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockPVZStorage) GetPVZReceptions(ctx context.Context, pvz_id uuid.UUID, filter entity.ReceptionFilter) ([]entity.Receptions, error) {
+	args := m.Called(ctx, pvz_id, filter)
+	return args.Get(0).([]entity.Receptions), args.Error(1)
+}
+
+func (m *MockPVZStorage) GetPVZSummary(ctx context.Context, pvz_id uuid.UUID) (*entity.PVZSummary, error) {
+	args := m.Called(ctx, pvz_id)
+	return args.Get(0).(*entity.PVZSummary), args.Error(1)
+}
+
 func TestPVZUsecase_CreatePVZ(t *testing.T) {
 	pvz_id := uuid.Must(uuid.NewV4())
 	userID := uuid.Must(uuid.NewV4())
@@ -50,59 +62,67 @@ func TestPVZUsecase_CreatePVZ(t *testing.T) {
 		user_id       uuid.UUID
 		city          string
 		date          time.Time
+		createResult  *entity.PVZ
+		createError   error
 		expected      *entity.PVZ
-		getPVZresult  *entity.PVZ
 		expectedError error
-		getPVZError   error
 	}{
 		{
 			name:    "success",
 			pvz_id:  pvz_id,
 			user_id: userID,
 			city:    city,
+			createResult: &entity.PVZ{
+				ID:               pvz_id,
+				RegistrationDate: date,
+				City:             city,
+				UserID:           userID,
+			},
 			expected: &entity.PVZ{
 				ID:               pvz_id,
 				RegistrationDate: date,
 				City:             city,
 				UserID:           userID,
 			},
-			expectedError: nil,
-			getPVZError:   sql.ErrNoRows,
-			getPVZresult:  &entity.PVZ{},
 		},
 		{
 			name:          "pvz exists",
 			pvz_id:        pvz_id,
 			user_id:       userID,
 			city:          city,
-			expectedError: errors.New("pvz exists"),
-			getPVZresult: &entity.PVZ{
-				ID: pvz_id,
-			},
-			getPVZError: nil,
+			createError:   storage.ErrPVZExists,
+			expectedError: usecase.ErrPVZExists,
+		},
+		{
+			name:          "unsupported city",
+			pvz_id:        pvz_id,
+			user_id:       userID,
+			city:          "Тверь",
+			createError:   storage.ErrUnsupportedCity,
+			expectedError: usecase.ErrUnsupportedCity,
 		},
 		{
 			name:          "db error",
 			pvz_id:        pvz_id,
 			user_id:       userID,
 			city:          city,
-			expectedError: errors.New("failed to check PVZ existence: db error"),
-			getPVZError:   errors.New("db error"),
+			createError:   errors.New("db error"),
+			expectedError: errors.New("db error"),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			PVZStorage := new(MockPVZStorage)
-			usecase := usecase.NewPVZUsecase(PVZStorage)
-
-			PVZStorage.On("GetPVZById", tt.pvz_id).Return(tt.getPVZresult, tt.getPVZError)
+			events := new(MockEventRecorder)
+			usecase := usecase.NewPVZUsecase(PVZStorage, events, cursors)
 
-			if tt.getPVZError == sql.ErrNoRows && tt.expectedError == nil {
-				PVZStorage.On("CreatePVZ", tt.pvz_id, tt.user_id, tt.city, tt.date).Return(tt.expected, tt.expectedError)
+			PVZStorage.On("CreatePVZ", anyCtx, tt.pvz_id, entity.Actor{UserID: tt.user_id}, tt.city, tt.date).Return(tt.createResult, tt.createError)
+			if tt.expectedError == nil {
+				events.On("PVZCreated", tt.city).Return()
 			}
 
-			pvz, err := usecase.CreatePVZ(tt.pvz_id, tt.user_id, tt.city, tt.date)
+			pvz, err := usecase.CreatePVZ(context.Background(), tt.pvz_id, entity.Actor{UserID: tt.user_id}, tt.city, tt.date)
 
 			if tt.expectedError != nil {
 				assert.Error(t, err)
@@ -114,6 +134,7 @@ func TestPVZUsecase_CreatePVZ(t *testing.T) {
 			}
 
 			PVZStorage.AssertExpectations(t)
+			events.AssertExpectations(t)
 		})
 	}
 }
@@ -209,15 +230,15 @@ func TestPVZUsecase_GetPVZsWithFilter(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			PVZStorage := new(MockPVZStorage)
-			usecase := usecase.NewPVZUsecase(PVZStorage)
+			usecase := usecase.NewPVZUsecase(PVZStorage, usecase.NopEventRecorder{}, cursors)
 
-			PVZStorage.On("GetPVZsWithFilter", context.Background(), tt.filter).Return(tt.getPVZresult, tt.getPVZError)
+			PVZStorage.On("GetPVZsWithFilter", anyCtx, tt.filter).Return(tt.getPVZresult, tt.getPVZError)
 
 			if tt.getPVZError == nil && tt.expectedError == nil {
-				PVZStorage.On("CountPVZsWithFilter", context.Background(), tt.filter).Return(tt.countPVZresult, tt.countPVZerror)
+				PVZStorage.On("CountPVZsWithFilter", anyCtx, tt.filter).Return(tt.countPVZresult, tt.countPVZerror)
 			}
 
-			pvz_list, err := usecase.GetPVZsWithFilter(context.Background(), filter)
+			pvz_list, err := usecase.GetPVZsWithFilter(context.Background(), filter, "")
 
 			if tt.expectedError != nil {
 				assert.Error(t, err)
@@ -232,3 +253,157 @@ func TestPVZUsecase_GetPVZsWithFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestPVZUsecase_GetPVZ(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	reception_id := uuid.Must(uuid.NewV4())
+	date := time.Now()
+
+	pvz := &entity.PVZ{ID: pvz_id, City: "Москва", RegistrationDate: date}
+	receptions := []entity.Receptions{
+		{ID: reception_id, DateTime: date, PVZID: pvz_id, Status: "in_progress", Products: []entity.Products{}},
+	}
+
+	tests := []struct {
+		name          string
+		getPVZError   error
+		expected      *usecase.PVZDetailResponse
+		expectedError error
+	}{
+		{
+			name: "success",
+			expected: &usecase.PVZDetailResponse{
+				Pvz:             *pvz,
+				Receptions:      receptions,
+				TotalReceptions: 3,
+				TotalProducts:   12,
+				OpenReceptionID: &reception_id,
+				Page:            1,
+				Limit:           10,
+			},
+		},
+		{
+			name:          "not found",
+			getPVZError:   sql.ErrNoRows,
+			expectedError: usecase.ErrPVZNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			PVZStorage := new(MockPVZStorage)
+			usecase := usecase.NewPVZUsecase(PVZStorage, usecase.NopEventRecorder{}, cursors)
+
+			if tt.getPVZError != nil {
+				PVZStorage.On("GetPVZById", anyCtx, pvz_id).Return((*entity.PVZ)(nil), tt.getPVZError)
+			} else {
+				PVZStorage.On("GetPVZById", anyCtx, pvz_id).Return(pvz, nil)
+				PVZStorage.On("GetPVZSummary", anyCtx, pvz_id).Return(&entity.PVZSummary{Receptions: 3, Products: 12, OpenReceptionID: &reception_id}, nil)
+				PVZStorage.On("GetPVZReceptions", anyCtx, pvz_id, entity.ReceptionFilter{Page: 1, Limit: 10}).Return(receptions, nil)
+			}
+
+			response, err := usecase.GetPVZ(context.Background(), pvz_id, entity.ReceptionFilter{Page: 1, Limit: 10}, "")
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.Equal(t, tt.expected, response)
+			PVZStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPVZUsecase_GetPVZsWithFilter_Cursor(t *testing.T) {
+	first := uuid.Must(uuid.NewV4())
+	second := uuid.Must(uuid.NewV4())
+	date := time.Date(2025, 4, 16, 12, 0, 0, 0, time.UTC)
+
+	page := []entity.ListPVZ{
+		{Pvz: entity.PVZ{ID: first}, Receptions: []entity.Receptions{{DateTime: date}}},
+		{Pvz: entity.PVZ{ID: second}, Receptions: []entity.Receptions{{DateTime: date.Add(-time.Hour)}, {DateTime: date.Add(-2 * time.Hour)}}},
+	}
+
+	PVZStorage := new(MockPVZStorage)
+	pvzUsecase := usecase.NewPVZUsecase(PVZStorage, usecase.NopEventRecorder{}, cursors)
+
+	PVZStorage.On("GetPVZsWithFilter", anyCtx, entity.Filter{Page: 1, Limit: 2}).Return(page, nil).Once()
+	PVZStorage.On("CountPVZsWithFilter", anyCtx, mock.Anything).Return(3, nil)
+
+	response, err := pvzUsecase.GetPVZsWithFilter(context.Background(), entity.Filter{Page: 1, Limit: 2}, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, response.NextCursor)
+	cursor := response.NextCursor
+
+	after := &entity.Keyset{Time: date.Add(-time.Hour), ID: second}
+	PVZStorage.On("GetPVZsWithFilter", anyCtx, entity.Filter{Limit: 2, After: after}).Return(page[:1], nil).Once()
+
+	response, err = pvzUsecase.GetPVZsWithFilter(context.Background(), entity.Filter{Limit: 2}, cursor)
+	require.NoError(t, err)
+	assert.Empty(t, response.NextCursor)
+	assert.Equal(t, 3, response.Total)
+	PVZStorage.AssertExpectations(t)
+
+	_, err = pvzUsecase.GetPVZsWithFilter(context.Background(), entity.Filter{Limit: 2}, cursors.Encode("pvz_receptions:"+first.String(), "", *after))
+	assert.Equal(t, usecase.ErrInvalidCursor, err)
+
+	start := date.Add(-24 * time.Hour)
+	user_id := uuid.Must(uuid.NewV4())
+	for name, filter := range map[string]entity.Filter{
+		"start date":    {Limit: 2, StartDate: &start},
+		"status":        {Limit: 2, Status: "close"},
+		"product type":  {Limit: 2, ProductType: "обувь"},
+		"city":          {Limit: 2, City: "Казань"},
+		"user":          {Limit: 2, UserID: &user_id},
+		"pvz scope":     {Limit: 2, PVZIDs: []uuid.UUID{first}},
+		"include empty": {Limit: 2, IncludeEmpty: true},
+	} {
+		t.Run("other "+name, func(t *testing.T) {
+			_, err := pvzUsecase.GetPVZsWithFilter(context.Background(), filter, cursor)
+			assert.Equal(t, usecase.ErrCursorFilterMismatch, err)
+		})
+	}
+}
+
+func TestPVZUsecase_GetPVZsWithFilter_SortCursor(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	date := time.Date(2025, 4, 16, 12, 0, 0, 0, time.UTC)
+	empty := []entity.ListPVZ{{Pvz: entity.PVZ{ID: pvz_id, RegistrationDate: date}, Receptions: []entity.Receptions{}}}
+	counted := []entity.ListPVZ{{Pvz: entity.PVZ{ID: pvz_id, RegistrationDate: date}, Receptions: []entity.Receptions{
+		{DateTime: date, Products: []entity.Products{{}, {}}},
+		{DateTime: date.Add(-time.Hour), Products: []entity.Products{{}}},
+	}}}
+
+	tests := []struct {
+		name     string
+		sort     string
+		page     []entity.ListPVZ
+		expected entity.Keyset
+	}{
+		{name: "pvz without receptions by last reception", sort: entity.PVZSortLastReception, page: empty, expected: entity.Keyset{Time: date, ID: pvz_id}},
+		{name: "registration date", sort: entity.PVZSortRegistrationDate, page: counted, expected: entity.Keyset{Time: date, ID: pvz_id}},
+		{name: "product count", sort: entity.PVZSortProductCount, page: counted, expected: entity.Keyset{Count: 3, ID: pvz_id}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			PVZStorage := new(MockPVZStorage)
+			pvzUsecase := usecase.NewPVZUsecase(PVZStorage, usecase.NopEventRecorder{}, cursors)
+
+			filter := entity.Filter{IncludeEmpty: true, Sort: tt.sort, Page: 1, Limit: 1}
+			PVZStorage.On("GetPVZsWithFilter", anyCtx, filter).Return(tt.page, nil)
+			PVZStorage.On("CountPVZsWithFilter", anyCtx, filter).Return(2, nil)
+
+			response, err := pvzUsecase.GetPVZsWithFilter(context.Background(), filter, "")
+			require.NoError(t, err)
+
+			next := entity.Filter{IncludeEmpty: true, Sort: tt.sort, Limit: 1, After: &tt.expected}
+			PVZStorage.On("GetPVZsWithFilter", anyCtx, next).Return([]entity.ListPVZ{}, nil)
+			PVZStorage.On("CountPVZsWithFilter", anyCtx, next).Return(2, nil)
+
+			_, err = pvzUsecase.GetPVZsWithFilter(context.Background(), entity.Filter{IncludeEmpty: true, Sort: tt.sort, Limit: 1}, response.NextCursor)
+			require.NoError(t, err)
+			PVZStorage.AssertExpectations(t)
+
+			_, err = pvzUsecase.GetPVZsWithFilter(context.Background(), entity.Filter{Sort: "other", Limit: 1}, response.NextCursor)
+			assert.Equal(t, usecase.ErrInvalidCursor, err)
+		})
+	}
+}
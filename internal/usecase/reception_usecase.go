@@ -1,58 +1,121 @@
 package usecase
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"pvz/internal/apperr"
 	"pvz/internal/storage"
 	"pvz/internal/storage/migrations/entity"
 
 	"github.com/gofrs/uuid/v5"
 )
 
+var (
+	ErrReceptionInProgress = apperr.Conflict("reception_in_progress", "close previous receipt")
+	ErrNoOpenReception     = apperr.Validation("no_open_reception", "no available receptions")
+)
+
 type ReceptionUsecase interface {
-	CreateReception(uuid.UUID) (*entity.Receptions, error)
-	UpdateReceptionStatus(uuid.UUID) (*entity.Receptions, error)
+	CreateReception(ctx context.Context, actor entity.Actor, pvz_id uuid.UUID) (*entity.Receptions, error)
+	UpdateReceptionStatus(ctx context.Context, actor entity.Actor, pvz_id uuid.UUID) (*entity.Receptions, error)
 }
 
 type ReceptionUsecaseImpl struct {
-	receptionStorage storage.ReceptionPostgresStorage
+	txManager         storage.TxManager
+	assignmentStorage storage.AssignmentsPostgresStorage
+	policy            *Policy
+	events            EventRecorder
 }
 
-func NewReceptionUsecase(receptionStorage storage.ReceptionPostgresStorage) *ReceptionUsecaseImpl {
-	return &ReceptionUsecaseImpl{receptionStorage: receptionStorage}
+func NewReceptionUsecase(txManager storage.TxManager, assignmentStorage storage.AssignmentsPostgresStorage, policy *Policy, events EventRecorder) *ReceptionUsecaseImpl {
+	return &ReceptionUsecaseImpl{txManager: txManager, assignmentStorage: assignmentStorage, policy: policy, events: events}
 }
 
-func (r *ReceptionUsecaseImpl) CreateReception(id uuid.UUID) (*entity.Receptions, error) {
-	_, status, err := r.receptionStorage.GetLastReceptionStatus(id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check reception status: %w", err)
-	}
-	if status == "in_progress" {
-		return nil, fmt.Errorf("close previous receipt")
+func (r *ReceptionUsecaseImpl) CreateReception(ctx context.Context, actor entity.Actor, id uuid.UUID) (*entity.Receptions, error) {
+	ctx, span := tracer.Start(ctx, "ReceptionUsecase.CreateReception")
+	defer span.End()
+
+	if err := checkAssignment(ctx, r.assignmentStorage, r.policy, actor, id); err != nil {
+		return nil, err
 	}
-	reception, err := r.receptionStorage.CreateReception(id)
+
+	var reception *entity.Receptions
+	var city string
+	err := r.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		var err error
+		city, err = pvzCity(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		// у нового ПВЗ приёмок ещё нет, и первую можно открыть
+		_, status, err := tx.Receptions().GetLastReceptionStatus(ctx, id)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to check reception status: %w", err)
+		}
+		if status == "in_progress" {
+			return ErrReceptionInProgress
+		}
+		// Проверка выше лишь отсекает очевидный случай: одновременные запросы
+		// разводит уникальный индекс в базе.
+		reception, err = tx.Receptions().CreateReception(ctx, id, actor)
+		if errors.Is(err, storage.ErrReceptionInProgress) {
+			return ErrReceptionInProgress
+		} else if err != nil {
+			return fmt.Errorf("failed to create new reception: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new reception: %w", err)
+		return nil, err
 	}
+	r.events.ReceptionOpened(city)
 	return reception, nil
 }
 
-func (r *ReceptionUsecaseImpl) UpdateReceptionStatus(pvz_id uuid.UUID) (*entity.Receptions, error) {
-	reception_id, status, err := r.receptionStorage.GetLastReceptionStatus(pvz_id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check reception status: %w", err)
-	}
-	if status == "close" {
-		return nil, fmt.Errorf("no available receptions")
-	}
-	err = r.receptionStorage.UpdateReceptionStatus(reception_id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update reception status: %w", err)
+func (r *ReceptionUsecaseImpl) UpdateReceptionStatus(ctx context.Context, actor entity.Actor, pvz_id uuid.UUID) (*entity.Receptions, error) {
+	ctx, span := tracer.Start(ctx, "ReceptionUsecase.UpdateReceptionStatus")
+	defer span.End()
+
+	if err := checkAssignment(ctx, r.assignmentStorage, r.policy, actor, pvz_id); err != nil {
+		return nil, err
 	}
 
-	reception, err := r.receptionStorage.GetReceptionById(reception_id)
+	var reception *entity.Receptions
+	var city string
+	err := r.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		var err error
+		city, err = pvzCity(ctx, tx, pvz_id)
+		if err != nil {
+			return err
+		}
+
+		reception_id, status, err := tx.Receptions().GetLastReceptionStatus(ctx, pvz_id)
+		if err == sql.ErrNoRows {
+			return ErrNoOpenReception
+		} else if err != nil {
+			return fmt.Errorf("failed to check reception status: %w", err)
+		}
+		if status == "close" {
+			return ErrNoOpenReception
+		}
+		err = tx.Receptions().UpdateReceptionStatus(ctx, reception_id, actor)
+		if err != nil {
+			return fmt.Errorf("failed to update reception status: %w", err)
+		}
+
+		reception, err = tx.Receptions().GetReceptionById(ctx, reception_id)
+		if err != nil {
+			return fmt.Errorf("failed to get reception: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get reception: %w", err)
+		return nil, err
 	}
 
+	r.events.ReceptionClosed(city)
 	return reception, nil
 }
@@ -1,13 +1,18 @@
 package usecase_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"pvz/internal/storage"
 	"pvz/internal/storage/migrations/entity"
 	"pvz/internal/usecase"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gofrs/uuid/v5"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -16,34 +21,56 @@ type MockReceptionStorage struct {
 	mock.Mock
 }
 
-func (m *MockReceptionStorage) CreateReception(id uuid.UUID) (*entity.Receptions, error) {
-	args := m.Called(id)
+func (m *MockReceptionStorage) CreateReception(ctx context.Context, id uuid.UUID, actor entity.Actor) (*entity.Receptions, error) {
+	args := m.Called(ctx, id, actor)
 	return args.Get(0).(*entity.Receptions), args.Error(1)
 }
 
-func (m *MockReceptionStorage) GetLastReceptionStatus(id uuid.UUID) (uuid.UUID, string, error) {
-	args := m.Called(id)
+func (m *MockReceptionStorage) GetLastReceptionStatus(ctx context.Context, id uuid.UUID) (uuid.UUID, string, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(uuid.UUID), args.String(1), args.Error(2)
 }
 
-func (m *MockReceptionStorage) UpdateReceptionStatus(reception_id uuid.UUID) error {
-	args := m.Called(reception_id)
+func (m *MockReceptionStorage) UpdateReceptionStatus(ctx context.Context, reception_id uuid.UUID, actor entity.Actor) error {
+	args := m.Called(ctx, reception_id, actor)
 	return args.Error(0)
 }
 
-func (m *MockReceptionStorage) GetReceptionById(reception_id uuid.UUID) (*entity.Receptions, error) {
-	args := m.Called(reception_id)
+func (m *MockReceptionStorage) GetReceptionById(ctx context.Context, reception_id uuid.UUID) (*entity.Receptions, error) {
+	args := m.Called(ctx, reception_id)
 	return args.Get(0).(*entity.Receptions), args.Error(1)
 }
 
+type MockAssignmentStorage struct {
+	mock.Mock
+}
+
+func (m *MockAssignmentStorage) AssignEmployee(ctx context.Context, user_id, pvz_id uuid.UUID) error {
+	args := m.Called(ctx, user_id, pvz_id)
+	return args.Error(0)
+}
+
+func (m *MockAssignmentStorage) UnassignEmployee(ctx context.Context, user_id, pvz_id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, user_id, pvz_id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAssignmentStorage) IsAssigned(ctx context.Context, user_id, pvz_id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, user_id, pvz_id)
+	return args.Bool(0), args.Error(1)
+}
+
 func TestReceptionUsecase_CreateReception(t *testing.T) {
 	pvz_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
 
 	tests := []struct {
 		name               string
 		pvz_id             uuid.UUID
+		notAssigned        bool
 		getReceptionresult string
 		getReceptionError  error
+		createError        error
 		expected           *entity.Receptions
 		expectedError      error
 	}{
@@ -59,12 +86,20 @@ func TestReceptionUsecase_CreateReception(t *testing.T) {
 			expectedError: nil,
 		},
 		{
-			name:               "status check error",
-			pvz_id:             pvz_id,
-			getReceptionError:  sql.ErrNoRows,
-			getReceptionresult: "",
-			expected:           nil,
-			expectedError:      errors.New("failed to check reception status: sql: no rows in result set"),
+			name:              "first reception on pvz",
+			pvz_id:            pvz_id,
+			getReceptionError: sql.ErrNoRows,
+			expected: &entity.Receptions{
+				Status: "in_progress",
+				PVZID:  pvz_id,
+			},
+		},
+		{
+			name:              "status check error",
+			pvz_id:            pvz_id,
+			getReceptionError: sql.ErrConnDone,
+			expected:          nil,
+			expectedError:     errors.New("failed to check reception status: sql: connection is already closed"),
 		},
 		{
 			name:               "status in_progress",
@@ -74,20 +109,45 @@ func TestReceptionUsecase_CreateReception(t *testing.T) {
 			expected:           nil,
 			expectedError:      errors.New("close previous receipt"),
 		},
+		{
+			name:          "employee is not assigned",
+			pvz_id:        pvz_id,
+			notAssigned:   true,
+			expected:      nil,
+			expectedError: usecase.ErrNotAssigned,
+		},
+		{
+			name:               "concurrent reception wins the unique index",
+			pvz_id:             pvz_id,
+			getReceptionresult: "close",
+			createError:        storage.ErrReceptionInProgress,
+			expected:           nil,
+			expectedError:      usecase.ErrReceptionInProgress,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			PVZStorage := new(MockPVZStorage)
 			ReceptionStorage := new(MockReceptionStorage)
-			usecase := usecase.NewReceptionUsecase(ReceptionStorage)
+			AssignmentStorage := new(MockAssignmentStorage)
+			events := new(MockEventRecorder)
+			usecase := usecase.NewReceptionUsecase(&fakeTx{pvz: PVZStorage, receptions: ReceptionStorage}, AssignmentStorage, usecase.DefaultPolicy(), events)
 
-			ReceptionStorage.On("GetLastReceptionStatus", tt.pvz_id).Return(uuid.UUID{}, tt.getReceptionresult, tt.getReceptionError)
+			AssignmentStorage.On("IsAssigned", anyCtx, user_id, tt.pvz_id).Return(!tt.notAssigned, nil)
+			if !tt.notAssigned {
+				PVZStorage.On("GetPVZById", anyCtx, tt.pvz_id).Return(&entity.PVZ{ID: tt.pvz_id, City: "Москва"}, nil)
+				ReceptionStorage.On("GetLastReceptionStatus", anyCtx, tt.pvz_id).Return(uuid.UUID{}, tt.getReceptionresult, tt.getReceptionError)
+			}
 
-			if tt.getReceptionError == nil && tt.expectedError == nil && tt.getReceptionresult == "close" {
-				ReceptionStorage.On("CreateReception", tt.pvz_id).Return(tt.expected, tt.expectedError)
+			if tt.getReceptionError == sql.ErrNoRows || tt.getReceptionError == nil && tt.getReceptionresult == "close" {
+				ReceptionStorage.On("CreateReception", anyCtx, tt.pvz_id, entity.Actor{UserID: user_id, Role: "employee"}).Return(tt.expected, tt.createError)
+			}
+			if tt.expectedError == nil {
+				events.On("ReceptionOpened", "Москва").Return()
 			}
 
-			reception, err := usecase.CreateReception(tt.pvz_id)
+			reception, err := usecase.CreateReception(context.Background(), entity.Actor{UserID: user_id, Role: "employee"}, tt.pvz_id)
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.EqualError(t, err, tt.expectedError.Error())
@@ -97,12 +157,39 @@ func TestReceptionUsecase_CreateReception(t *testing.T) {
 				assert.Equal(t, tt.expected, reception)
 			}
 			ReceptionStorage.AssertExpectations(t)
+			events.AssertExpectations(t)
 		})
 	}
 }
 
+// Администратор не назначается на ПВЗ, но по политике работает с приёмками
+// любого ПВЗ.
+func TestReceptionUsecase_CreateReception_AdminWithoutAssignment(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "admin"}
+	expected := &entity.Receptions{Status: "in_progress", PVZID: pvz_id}
+
+	PVZStorage := new(MockPVZStorage)
+	ReceptionStorage := new(MockReceptionStorage)
+	AssignmentStorage := new(MockAssignmentStorage)
+	events := new(MockEventRecorder)
+	usecase := usecase.NewReceptionUsecase(&fakeTx{pvz: PVZStorage, receptions: ReceptionStorage}, AssignmentStorage, usecase.DefaultPolicy(), events)
+
+	PVZStorage.On("GetPVZById", anyCtx, pvz_id).Return(&entity.PVZ{ID: pvz_id, City: "Москва"}, nil)
+	ReceptionStorage.On("GetLastReceptionStatus", anyCtx, pvz_id).Return(uuid.UUID{}, "close", nil)
+	ReceptionStorage.On("CreateReception", anyCtx, pvz_id, actor).Return(expected, nil)
+	events.On("ReceptionOpened", "Москва").Return()
+
+	reception, err := usecase.CreateReception(context.Background(), actor, pvz_id)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, reception)
+	AssignmentStorage.AssertNotCalled(t, "IsAssigned", mock.Anything, mock.Anything, mock.Anything)
+	ReceptionStorage.AssertExpectations(t)
+}
+
 func TestReceptionUsecase_UpdateReceptionStatus(t *testing.T) {
 	pvz_id := uuid.Must(uuid.NewV4())
+	user_id := uuid.Must(uuid.NewV4())
 
 	tests := []struct {
 		name               string
@@ -135,24 +222,36 @@ func TestReceptionUsecase_UpdateReceptionStatus(t *testing.T) {
 			expectedError:        nil,
 			updateReceptionError: nil,
 		},
+		{
+			name:              "pvz without receptions",
+			pvz_id:            pvz_id,
+			getReceptionError: sql.ErrNoRows,
+			expectedError:     usecase.ErrNoOpenReception,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			PVZStorage := new(MockPVZStorage)
 			ReceptionStorage := new(MockReceptionStorage)
-			usecase := usecase.NewReceptionUsecase(ReceptionStorage)
+			AssignmentStorage := new(MockAssignmentStorage)
+			events := new(MockEventRecorder)
+			usecase := usecase.NewReceptionUsecase(&fakeTx{pvz: PVZStorage, receptions: ReceptionStorage}, AssignmentStorage, usecase.DefaultPolicy(), events)
 
-			ReceptionStorage.On("GetLastReceptionStatus", tt.pvz_id).Return(tt.getReceptionResult.reception_id, tt.getReceptionResult.status, tt.getReceptionError)
+			AssignmentStorage.On("IsAssigned", anyCtx, user_id, tt.pvz_id).Return(true, nil)
+			PVZStorage.On("GetPVZById", anyCtx, tt.pvz_id).Return(&entity.PVZ{ID: tt.pvz_id, City: "Москва"}, nil)
+			ReceptionStorage.On("GetLastReceptionStatus", anyCtx, tt.pvz_id).Return(tt.getReceptionResult.reception_id, tt.getReceptionResult.status, tt.getReceptionError)
 
 			if tt.getReceptionError == nil && tt.expectedError == nil && tt.getReceptionResult.status == "in_progress" {
-				ReceptionStorage.On("UpdateReceptionStatus", tt.getReceptionResult.reception_id).Return(tt.updateReceptionError)
+				ReceptionStorage.On("UpdateReceptionStatus", anyCtx, tt.getReceptionResult.reception_id, entity.Actor{UserID: user_id, Role: "employee"}).Return(tt.updateReceptionError)
 				if tt.updateReceptionError == nil {
-					ReceptionStorage.On("GetReceptionById", tt.getReceptionResult.reception_id).Return(tt.expected, tt.expectedError)
+					ReceptionStorage.On("GetReceptionById", anyCtx, tt.getReceptionResult.reception_id).Return(tt.expected, tt.expectedError)
+					events.On("ReceptionClosed", "Москва").Return()
 				}
 
 			}
 
-			reception, err := usecase.UpdateReceptionStatus(tt.pvz_id)
+			reception, err := usecase.UpdateReceptionStatus(context.Background(), entity.Actor{UserID: user_id, Role: "employee"}, tt.pvz_id)
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.EqualError(t, err, tt.expectedError.Error())
@@ -162,6 +261,40 @@ func TestReceptionUsecase_UpdateReceptionStatus(t *testing.T) {
 				assert.Equal(t, tt.expected, reception)
 			}
 			ReceptionStorage.AssertExpectations(t)
+			events.AssertExpectations(t)
 		})
 	}
 }
+
+// Нарушение индекса reception_one_in_progress_per_pvz при вставке — это
+// проигранная гонка за открытие приёмки, а не внутренняя ошибка.
+func TestReceptionUsecase_CreateReception_UniqueIndex(t *testing.T) {
+	pvz_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "employee"}
+
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	AssignmentStorage := new(MockAssignmentStorage)
+	AssignmentStorage.On("IsAssigned", anyCtx, actor.UserID, pvz_id).Return(true, nil)
+	receptions := usecase.NewReceptionUsecase(storage.NewTxManager(db), AssignmentStorage, usecase.DefaultPolicy(), new(MockEventRecorder))
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT \\* FROM pvz").WithArgs(pvz_id).
+		WillReturnRows(sqlmock.NewRows([]string{"pvz_id", "registration_date", "city_name", "user_id"}).
+			AddRow(pvz_id, time.Now(), "Москва", actor.UserID))
+	dbMock.ExpectQuery("SELECT reception_id, status_name FROM reception").WithArgs(pvz_id).
+		WillReturnRows(sqlmock.NewRows([]string{"reception_id", "status_name"}))
+	dbMock.ExpectExec("INSERT INTO reception").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pvz_id, "in_progress").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "reception_one_in_progress_per_pvz"})
+	dbMock.ExpectRollback()
+
+	reception, err := receptions.CreateReception(context.Background(), actor, pvz_id)
+	assert.Equal(t, usecase.ErrReceptionInProgress, err)
+	assert.Nil(t, reception)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
@@ -0,0 +1,7 @@
+package usecase
+
+import "go.opentelemetry.io/otel"
+
+// tracer создаёт спаны методов usecase. Пока tracing.Setup не вызван,
+// глобальный провайдер ничего не записывает.
+var tracer = otel.Tracer("pvz/internal/usecase")
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"pvz/internal/apperr"
+	"pvz/internal/storage"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const (
+	totpIssuer         = "PVZ"
+	recoveryCodesCount = 10
+	maxTOTPFailures    = 5
+	totpFailureWindow  = 15 * time.Minute
+)
+
+var (
+	ErrTwoFactorNotEnrolled = apperr.Conflict("two_factor_not_enrolled", "two-factor authentication is not enrolled")
+	ErrTwoFactorEnabled     = apperr.Conflict("two_factor_enabled", "two-factor authentication is already enabled")
+	ErrInvalidTwoFactorCode = apperr.Unauthorized("invalid_two_factor_code", "invalid two-factor code")
+)
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauthUri"`
+}
+
+type TwoFactorUsecase interface {
+	Enroll(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error)
+	Verify(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	Authenticate(ctx context.Context, userID uuid.UUID, code string) error
+	IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+type TwoFactorUsecaseImpl struct {
+	totpStorage  storage.TOTPPostgresStorage
+	userStorage  storage.UsersPostgresStorage
+	tokenStorage storage.TokensPostgresStorage
+}
+
+func NewTwoFactorUsecase(totpStorage storage.TOTPPostgresStorage, userStorage storage.UsersPostgresStorage, tokenStorage storage.TokensPostgresStorage) *TwoFactorUsecaseImpl {
+	return &TwoFactorUsecaseImpl{totpStorage: totpStorage, userStorage: userStorage, tokenStorage: tokenStorage}
+}
+
+// Enroll выдаёт новый секрет. Пока регистрация не подтверждена через Verify,
+// её можно начинать заново.
+func (t *TwoFactorUsecaseImpl) Enroll(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorUsecase.Enroll")
+	defer span.End()
+
+	user, err := t.userStorage.GetUserByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	err = t.totpStorage.SaveTOTP(ctx, user.ID, secret)
+	if err == sql.ErrNoRows {
+		return nil, ErrTwoFactorEnabled
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to save totp secret: %w", err)
+	}
+
+	return &TOTPEnrollment{Secret: secret, URI: totpURI(totpIssuer, user.Email, secret)}, nil
+}
+
+// Verify подтверждает регистрацию первым кодом из приложения и возвращает
+// коды восстановления. Они показываются один раз, в базе хранятся только хэши.
+// Все ранее выданные сессии завершаются.
+func (t *TwoFactorUsecaseImpl) Verify(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorUsecase.Verify")
+	defer span.End()
+
+	totp, err := t.totpStorage.GetTOTP(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrTwoFactorNotEnrolled
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get totp: %w", err)
+	}
+
+	if totp.Confirmed {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	if err := t.checkTOTP(ctx, totp.UserID, totp.Secret, code); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := t.totpStorage.ConfirmTOTP(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to confirm totp: %w", err)
+	}
+
+	if err := t.tokenStorage.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return codes, nil
+}
+
+// Authenticate принимает код из приложения или неиспользованный код восстановления.
+// После maxTOTPFailures неудач подряд проверка блокируется на totpFailureWindow.
+func (t *TwoFactorUsecaseImpl) Authenticate(ctx context.Context, userID uuid.UUID, code string) error {
+	ctx, span := tracer.Start(ctx, "TwoFactorUsecase.Authenticate")
+	defer span.End()
+
+	totp, err := t.totpStorage.GetTOTP(ctx, userID)
+	if err == sql.ErrNoRows {
+		return ErrTwoFactorNotEnrolled
+	} else if err != nil {
+		return fmt.Errorf("failed to get totp: %w", err)
+	}
+
+	if !totp.Confirmed {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	if totp.FailedAttempts >= maxTOTPFailures && time.Since(totp.LastFailureAt) < totpFailureWindow {
+		return ErrTooManyAttempts
+	}
+
+	if len(code) == totpDigits {
+		return t.checkTOTP(ctx, userID, totp.Secret, code)
+	}
+
+	ok, err := t.totpStorage.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if !ok {
+		return t.fail(ctx, userID)
+	}
+	return nil
+}
+
+func (t *TwoFactorUsecaseImpl) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	ctx, span := tracer.Start(ctx, "TwoFactorUsecase.IsEnabled")
+	defer span.End()
+
+	totp, err := t.totpStorage.GetTOTP(ctx, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get totp: %w", err)
+	}
+	return totp.Confirmed, nil
+}
+
+func (t *TwoFactorUsecaseImpl) checkTOTP(ctx context.Context, userID uuid.UUID, secret, code string) error {
+	step, ok, err := matchTOTP(secret, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return t.fail(ctx, userID)
+	}
+
+	fresh, err := t.totpStorage.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to save totp step: %w", err)
+	}
+	if !fresh {
+		return t.fail(ctx, userID)
+	}
+	return nil
+}
+
+func (t *TwoFactorUsecaseImpl) fail(ctx context.Context, userID uuid.UUID) error {
+	if err := t.totpStorage.RegisterTOTPFailure(ctx, userID, time.Now(), totpFailureWindow); err != nil {
+		return fmt.Errorf("failed to register totp failure: %w", err)
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(totpEncoding.EncodeToString(raw))
+	return code[:8] + "-" + code[8:16], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,202 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockTwoFactorUsecase struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorUsecase) Enroll(ctx context.Context, userID uuid.UUID) (*usecase.TOTPEnrollment, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*usecase.TOTPEnrollment), args.Error(1)
+}
+
+func (m *MockTwoFactorUsecase) Verify(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTwoFactorUsecase) Authenticate(ctx context.Context, userID uuid.UUID, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorUsecase) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+// fakeTOTPStorage повторяет семантику TOTPPostgresStorageImpl в памяти.
+type fakeTOTPStorage struct {
+	totp     *entity.TOTP
+	recovery map[string]bool
+}
+
+func (f *fakeTOTPStorage) GetTOTP(ctx context.Context, user_id uuid.UUID) (*entity.TOTP, error) {
+	if f.totp == nil {
+		return nil, sql.ErrNoRows
+	}
+	totp := *f.totp
+	return &totp, nil
+}
+
+func (f *fakeTOTPStorage) SaveTOTP(ctx context.Context, user_id uuid.UUID, secret string) error {
+	if f.totp != nil && f.totp.Confirmed {
+		return sql.ErrNoRows
+	}
+	f.totp = &entity.TOTP{UserID: user_id, Secret: secret}
+	return nil
+}
+
+func (f *fakeTOTPStorage) ConfirmTOTP(ctx context.Context, user_id uuid.UUID, recoveryHashes []string) error {
+	f.totp.Confirmed = true
+	f.recovery = make(map[string]bool)
+	for _, hash := range recoveryHashes {
+		f.recovery[hash] = false
+	}
+	return nil
+}
+
+func (f *fakeTOTPStorage) UseTOTPStep(ctx context.Context, user_id uuid.UUID, step int64) (bool, error) {
+	if f.totp.LastUsedStep >= step {
+		return false, nil
+	}
+	f.totp.LastUsedStep = step
+	f.totp.FailedAttempts = 0
+	return true, nil
+}
+
+func (f *fakeTOTPStorage) RegisterTOTPFailure(ctx context.Context, user_id uuid.UUID, now time.Time, window time.Duration) error {
+	f.totp.FailedAttempts++
+	f.totp.LastFailureAt = now
+	return nil
+}
+
+func (f *fakeTOTPStorage) UseRecoveryCode(ctx context.Context, user_id uuid.UUID, hash string) (bool, error) {
+	used, ok := f.recovery[hash]
+	if !ok || used {
+		return false, nil
+	}
+	f.recovery[hash] = true
+	return true, nil
+}
+
+func TestTwoFactorUsecase_EnrollVerifyAuthenticate(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	UserStorage := new(MockUsersStorage)
+	TokenStorage := new(MockTokensStorage)
+	totpStorage := &fakeTOTPStorage{}
+	twoFactor := usecase.NewTwoFactorUsecase(totpStorage, UserStorage, TokenStorage)
+
+	UserStorage.On("GetUserByID", anyCtx, user_id).Return(&entity.User{ID: user_id, Email: "moderator@example.com"}, nil)
+	TokenStorage.On("RevokeUserRefreshTokens", anyCtx, user_id).Return(nil)
+
+	enrollment, err := twoFactor.Enroll(context.Background(), user_id)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/PVZ:moderator@example.com?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	enabled, err := twoFactor.IsEnabled(context.Background(), user_id)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	_, err = twoFactor.Verify(context.Background(), user_id, "000000")
+	assert.ErrorIs(t, err, usecase.ErrInvalidTwoFactorCode)
+
+	code, err := usecase.TOTPCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+	recoveryCodes, err := twoFactor.Verify(context.Background(), user_id, code)
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, 10)
+	for hash := range totpStorage.recovery {
+		assert.NotContains(t, recoveryCodes, hash)
+	}
+
+	enabled, err = twoFactor.IsEnabled(context.Background(), user_id)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	t.Run("code cannot be reused", func(t *testing.T) {
+		assert.ErrorIs(t, twoFactor.Authenticate(context.Background(), user_id, code), usecase.ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("recovery code is single use", func(t *testing.T) {
+		assert.NoError(t, twoFactor.Authenticate(context.Background(), user_id, recoveryCodes[0]))
+		assert.ErrorIs(t, twoFactor.Authenticate(context.Background(), user_id, recoveryCodes[0]), usecase.ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("enroll again after confirmation", func(t *testing.T) {
+		_, err := twoFactor.Enroll(context.Background(), user_id)
+		assert.ErrorIs(t, err, usecase.ErrTwoFactorEnabled)
+	})
+
+	t.Run("locked after repeated failures", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			twoFactor.Authenticate(context.Background(), user_id, "000000")
+		}
+		assert.ErrorIs(t, twoFactor.Authenticate(context.Background(), user_id, recoveryCodes[1]), usecase.ErrTooManyAttempts)
+	})
+
+	TokenStorage.AssertExpectations(t)
+}
+
+func TestUserUsecase_LoginWithTwoFactor(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	user := &entity.User{ID: user_id, Email: "moderator@example.com", Password: string(hashedPassword), Role: "moderator"}
+
+	userStorage := new(MockUsersStorage)
+	authService := new(MockAuthService)
+	twoFactor := new(MockTwoFactorUsecase)
+	userUsecase := usecase.NewUserUsecase(userStorage, &fakeTx{users: userStorage}, authService, testValidator(), twoFactor)
+
+	userStorage.On("GetUserByEmail", anyCtx, user.Email).Return(user, true, nil)
+	userStorage.On("GetUserByID", anyCtx, user_id).Return(user, nil)
+	twoFactor.On("IsEnabled", anyCtx, user_id).Return(true, nil)
+	authService.On("GenerateChallengeToken", user_id).Return("challenge", nil)
+
+	tokens, err := userUsecase.Login(context.Background(), user.Email, "password")
+	require.NoError(t, err)
+	assert.Equal(t, &usecase.TokenPair{ChallengeToken: "challenge"}, tokens)
+
+	t.Run("wrong code", func(t *testing.T) {
+		authService.On("ValidateChallengeToken", "challenge").Return(user_id, nil)
+		twoFactor.On("Authenticate", anyCtx, user_id, "111111").Return(usecase.ErrInvalidTwoFactorCode).Once()
+
+		_, err := userUsecase.VerifyLogin(context.Background(), "challenge", "111111")
+		assert.ErrorIs(t, err, usecase.ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("correct code", func(t *testing.T) {
+		twoFactor.On("Authenticate", anyCtx, user_id, "123456").Return(nil).Once()
+		authService.On("GenerateToken", user_id, "moderator", true).Return("token", nil)
+		authService.On("GenerateRefreshToken", anyCtx, user_id).Return("refresh", nil)
+
+		tokens, err := userUsecase.VerifyLogin(context.Background(), "challenge", "123456")
+		require.NoError(t, err)
+		assert.Equal(t, &usecase.TokenPair{AccessToken: "token", RefreshToken: "refresh"}, tokens)
+	})
+
+	t.Run("invalid challenge", func(t *testing.T) {
+		authService.On("ValidateChallengeToken", "forged").Return(uuid.Nil, usecase.ErrInvalidChallengeToken)
+
+		_, err := userUsecase.VerifyLogin(context.Background(), "forged", "123456")
+		assert.ErrorIs(t, err, usecase.ErrInvalidChallengeToken)
+	})
+
+	authService.AssertExpectations(t)
+	twoFactor.AssertExpectations(t)
+}
@@ -0,0 +1,58 @@
+package usecase_test
+
+import (
+	"context"
+	"pvz/internal/storage"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeTx выполняет функцию сразу, подставляя вместо транзакционных
+// хранилищ переданные моки.
+type fakeTx struct {
+	pvz        storage.PVZPostgresStorage
+	receptions storage.ReceptionPostgresStorage
+	products   storage.ProductPostgresStorage
+	users      storage.UsersPostgresStorage
+	tokens     storage.TokensPostgresStorage
+	resets     storage.PasswordResetsPostgresStorage
+}
+
+func (f *fakeTx) WithTx(ctx context.Context, fn func(tx storage.Tx) error) error {
+	return fn(f)
+}
+
+func (f *fakeTx) PVZ() storage.PVZPostgresStorage                       { return f.pvz }
+func (f *fakeTx) Receptions() storage.ReceptionPostgresStorage          { return f.receptions }
+func (f *fakeTx) Products() storage.ProductPostgresStorage              { return f.products }
+func (f *fakeTx) Users() storage.UsersPostgresStorage                   { return f.users }
+func (f *fakeTx) Tokens() storage.TokensPostgresStorage                 { return f.tokens }
+func (f *fakeTx) PasswordResets() storage.PasswordResetsPostgresStorage { return f.resets }
+
+// anyCtx: usecase передаёт хранилищам контекст со своим спаном,
+// а не тот, с которым его вызвали.
+var anyCtx = mock.MatchedBy(func(context.Context) bool { return true })
+
+type MockEventRecorder struct {
+	mock.Mock
+}
+
+func (m *MockEventRecorder) PVZCreated(city string) {
+	m.Called(city)
+}
+
+func (m *MockEventRecorder) ReceptionOpened(city string) {
+	m.Called(city)
+}
+
+func (m *MockEventRecorder) ReceptionClosed(city string) {
+	m.Called(city)
+}
+
+func (m *MockEventRecorder) ProductAdded(city, productType string) {
+	m.Called(city, productType)
+}
+
+func (m *MockEventRecorder) ProductDeleted(city, productType string) {
+	m.Called(city, productType)
+}
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"pvz/internal/apperr"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+var (
+	ErrUnknownRole = apperr.Validation("unknown_role", "unknown role")
+	ErrUserInUse   = apperr.Conflict("user_in_use", "user has related records")
+	// ErrSelfManagement: свою роль, статус и учётную запись через управление
+	// пользователями менять нельзя, чтобы не лишить систему администратора.
+	ErrSelfManagement = apperr.Forbidden("self_management", "cannot manage own account")
+	ErrRoleNotCovered = apperr.Forbidden("role_not_covered", "role has permissions outside of your role")
+	ErrLastAdmin      = apperr.Conflict("last_admin", "cannot remove the last active administrator")
+)
+
+type UserManagementUsecase interface {
+	ListUsers(ctx context.Context, filter entity.UserFilter) (*UserListResponse, error)
+	GetUser(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	ChangeRole(ctx context.Context, actor entity.Actor, id uuid.UUID, role string) (*entity.User, error)
+	SetDisabled(ctx context.Context, actor entity.Actor, id uuid.UUID, disabled bool) (*entity.User, error)
+	DeleteUser(ctx context.Context, actor entity.Actor, id uuid.UUID) error
+}
+
+type UserManagementUsecaseImpl struct {
+	userStorage storage.UsersPostgresStorage
+	txManager   storage.TxManager
+	policy      *Policy
+}
+
+type UserListResponse struct {
+	Users []entity.User `json:"users"`
+	Total int           `json:"total"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+}
+
+func NewUserManagementUsecase(userStorage storage.UsersPostgresStorage, txManager storage.TxManager, policy *Policy) *UserManagementUsecaseImpl {
+	return &UserManagementUsecaseImpl{userStorage: userStorage, txManager: txManager, policy: policy}
+}
+
+func (u *UserManagementUsecaseImpl) ListUsers(ctx context.Context, filter entity.UserFilter) (*UserListResponse, error) {
+	ctx, span := tracer.Start(ctx, "UserManagementUsecase.ListUsers")
+	defer span.End()
+
+	if filter.Role != "" && !u.policy.HasRole(filter.Role) {
+		return nil, ErrUnknownRole
+	}
+
+	users, err := u.userStorage.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := u.userStorage.CountUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserListResponse{
+		Users: users,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	}, nil
+}
+
+func (u *UserManagementUsecaseImpl) GetUser(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	ctx, span := tracer.Start(ctx, "UserManagementUsecase.GetUser")
+	defer span.End()
+
+	return getUser(ctx, u.userStorage, id)
+}
+
+func getUser(ctx context.Context, userStorage storage.UsersPostgresStorage, id uuid.UUID) (*entity.User, error) {
+	user, err := userStorage.GetUserByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (u *UserManagementUsecaseImpl) ChangeRole(ctx context.Context, actor entity.Actor, id uuid.UUID, role string) (*entity.User, error) {
+	ctx, span := tracer.Start(ctx, "UserManagementUsecase.ChangeRole")
+	defer span.End()
+
+	if !u.policy.HasRole(role) {
+		return nil, ErrUnknownRole
+	}
+	if !u.policy.Covers(actor.Role, role) {
+		return nil, ErrRoleNotCovered
+	}
+
+	return u.updateUser(ctx, actor, id, !u.isAdmin(role), func(users storage.UsersPostgresStorage) error {
+		err := users.UpdateUserRole(ctx, id, role, actor)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		} else if err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		return nil
+	})
+}
+
+func (u *UserManagementUsecaseImpl) SetDisabled(ctx context.Context, actor entity.Actor, id uuid.UUID, disabled bool) (*entity.User, error) {
+	ctx, span := tracer.Start(ctx, "UserManagementUsecase.SetDisabled")
+	defer span.End()
+
+	return u.updateUser(ctx, actor, id, disabled, func(users storage.UsersPostgresStorage) error {
+		err := users.SetUserDisabled(ctx, id, disabled, actor)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		} else if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		return nil
+	})
+}
+
+// checkManageable запрещает менять себя и пользователей, у роли которых есть
+// разрешения вне роли actor. removes отмечает изменения, после которых
+// пользователь перестаёт быть активным администратором.
+func (u *UserManagementUsecaseImpl) checkManageable(ctx context.Context, users storage.UsersPostgresStorage, actor entity.Actor, id uuid.UUID, removes bool) error {
+	if actor.UserID == id {
+		return ErrSelfManagement
+	}
+
+	user, err := getUser(ctx, users, id)
+	if err != nil {
+		return err
+	}
+	if !u.policy.Covers(actor.Role, user.Role) {
+		return ErrRoleNotCovered
+	}
+
+	if !removes || user.Disabled || !u.isAdmin(user.Role) {
+		return nil
+	}
+	admins, err := users.LockActiveUsers(ctx, u.policy.AdminRoles())
+	if err != nil {
+		return fmt.Errorf("failed to lock administrators: %w", err)
+	}
+	if len(admins) <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+func (u *UserManagementUsecaseImpl) isAdmin(role string) bool {
+	for _, admin := range u.policy.AdminRoles() {
+		if admin == role {
+			return true
+		}
+	}
+	return false
+}
+
+// updateUser проверяет права actor, применяет изменение и читает результат
+// в одной транзакции.
+func (u *UserManagementUsecaseImpl) updateUser(ctx context.Context, actor entity.Actor, id uuid.UUID, removes bool, update func(users storage.UsersPostgresStorage) error) (*entity.User, error) {
+	var user *entity.User
+	err := u.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		if err := u.checkManageable(ctx, tx.Users(), actor, id, removes); err != nil {
+			return err
+		}
+		if err := update(tx.Users()); err != nil {
+			return err
+		}
+		var err error
+		user, err = getUser(ctx, tx.Users(), id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *UserManagementUsecaseImpl) DeleteUser(ctx context.Context, actor entity.Actor, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "UserManagementUsecase.DeleteUser")
+	defer span.End()
+
+	return u.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		if err := u.checkManageable(ctx, tx.Users(), actor, id, true); err != nil {
+			return err
+		}
+
+		err := tx.Users().DeleteUser(ctx, id, actor)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		} else if errors.Is(err, storage.ErrUserInUse) {
+			return ErrUserInUse
+		} else if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,344 @@
+package usecase_test
+
+import (
+	"context"
+	"database/sql"
+	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserManagementUsecase_ListUsers(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+
+	tests := []struct {
+		name          string
+		filter        entity.UserFilter
+		users         []entity.User
+		total         int
+		expected      *usecase.UserListResponse
+		expectedError error
+	}{
+		{
+			name:   "success",
+			filter: entity.UserFilter{Role: "employee", Page: 1, Limit: 10},
+			users:  []entity.User{{ID: user_id, Email: "employee@example.com", Role: "employee"}},
+			total:  1,
+			expected: &usecase.UserListResponse{
+				Users: []entity.User{{ID: user_id, Email: "employee@example.com", Role: "employee"}},
+				Total: 1,
+				Page:  1,
+				Limit: 10,
+			},
+		},
+		{
+			name:          "unknown role",
+			filter:        entity.UserFilter{Role: "courier", Page: 1, Limit: 10},
+			expectedError: usecase.ErrUnknownRole,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			UserStorage := new(MockUsersStorage)
+			usecase := usecase.NewUserManagementUsecase(UserStorage, &fakeTx{users: UserStorage}, usecase.DefaultPolicy())
+
+			if tt.expectedError == nil {
+				UserStorage.On("ListUsers", anyCtx, tt.filter).Return(tt.users, nil)
+				UserStorage.On("CountUsers", anyCtx, tt.filter).Return(tt.total, nil)
+			}
+
+			response, err := usecase.ListUsers(context.Background(), tt.filter)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, response)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, response)
+			}
+
+			UserStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserManagementUsecase_ChangeRole(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	admin_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "admin", RequestID: "req-1"}
+	moderator := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "moderator", RequestID: "req-1"}
+	employee := &entity.User{ID: user_id, Role: "employee"}
+	admin := &entity.User{ID: admin_id, Role: "admin"}
+
+	tests := []struct {
+		name          string
+		actor         entity.Actor
+		id            uuid.UUID
+		role          string
+		mock          func(*MockUsersStorage)
+		expected      *entity.User
+		expectedError error
+	}{
+		{
+			name:  "success",
+			actor: actor,
+			id:    user_id,
+			role:  "moderator",
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, user_id).Return(employee, nil).Once()
+				mus.On("UpdateUserRole", anyCtx, user_id, "moderator", actor).Return(nil)
+				mus.On("GetUserByID", anyCtx, user_id).Return(&entity.User{ID: user_id, Role: "moderator"}, nil).Once()
+			},
+			expected: &entity.User{ID: user_id, Role: "moderator"},
+		},
+		{
+			name:          "unknown role",
+			actor:         actor,
+			id:            user_id,
+			role:          "courier",
+			mock:          func(mus *MockUsersStorage) {},
+			expectedError: usecase.ErrUnknownRole,
+		},
+		{
+			name:  "user not found",
+			actor: actor,
+			id:    user_id,
+			role:  "moderator",
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, user_id).Return((*entity.User)(nil), sql.ErrNoRows)
+			},
+			expectedError: usecase.ErrUserNotFound,
+		},
+		{
+			name:          "own role",
+			actor:         moderator,
+			id:            moderator.UserID,
+			role:          "moderator",
+			mock:          func(mus *MockUsersStorage) {},
+			expectedError: usecase.ErrSelfManagement,
+		},
+		{
+			name:          "grant role with more permissions",
+			actor:         moderator,
+			id:            user_id,
+			role:          "admin",
+			mock:          func(mus *MockUsersStorage) {},
+			expectedError: usecase.ErrRoleNotCovered,
+		},
+		{
+			name:  "demote user with more permissions",
+			actor: moderator,
+			id:    admin_id,
+			role:  "auditor",
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, admin_id).Return(admin, nil)
+			},
+			expectedError: usecase.ErrRoleNotCovered,
+		},
+		{
+			name:  "demote last admin",
+			actor: actor,
+			id:    admin_id,
+			role:  "moderator",
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, admin_id).Return(admin, nil)
+				mus.On("LockActiveUsers", anyCtx, []string{"admin"}).Return([]uuid.UUID{admin_id}, nil)
+			},
+			expectedError: usecase.ErrLastAdmin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			UserStorage := new(MockUsersStorage)
+			tt.mock(UserStorage)
+			usecase := usecase.NewUserManagementUsecase(UserStorage, &fakeTx{users: UserStorage}, usecase.DefaultPolicy())
+
+			user, err := usecase.ChangeRole(context.Background(), tt.actor, tt.id, tt.role)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, user)
+			}
+
+			UserStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserManagementUsecase_SetDisabled(t *testing.T) {
+	admin_id := uuid.Must(uuid.NewV4())
+	other_admin_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "admin", RequestID: "req-1"}
+	moderator := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "moderator", RequestID: "req-1"}
+	admin := &entity.User{ID: admin_id, Role: "admin"}
+
+	tests := []struct {
+		name          string
+		actor         entity.Actor
+		id            uuid.UUID
+		disabled      bool
+		mock          func(*MockUsersStorage)
+		expectedError error
+	}{
+		{
+			name:     "disable admin while another is active",
+			actor:    actor,
+			id:       admin_id,
+			disabled: true,
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, admin_id).Return(admin, nil).Once()
+				mus.On("LockActiveUsers", anyCtx, []string{"admin"}).Return([]uuid.UUID{admin_id, other_admin_id}, nil)
+				mus.On("SetUserDisabled", anyCtx, admin_id, true, actor).Return(nil)
+				mus.On("GetUserByID", anyCtx, admin_id).Return(&entity.User{ID: admin_id, Role: "admin", Disabled: true}, nil).Once()
+			},
+		},
+		{
+			name:          "disable self",
+			actor:         actor,
+			id:            actor.UserID,
+			disabled:      true,
+			mock:          func(mus *MockUsersStorage) {},
+			expectedError: usecase.ErrSelfManagement,
+		},
+		{
+			name:     "moderator disables admin",
+			actor:    moderator,
+			id:       admin_id,
+			disabled: true,
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, admin_id).Return(admin, nil)
+			},
+			expectedError: usecase.ErrRoleNotCovered,
+		},
+		{
+			name:     "disable last admin",
+			actor:    actor,
+			id:       admin_id,
+			disabled: true,
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, admin_id).Return(admin, nil)
+				mus.On("LockActiveUsers", anyCtx, []string{"admin"}).Return([]uuid.UUID{admin_id}, nil)
+			},
+			expectedError: usecase.ErrLastAdmin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			UserStorage := new(MockUsersStorage)
+			tt.mock(UserStorage)
+			usecase := usecase.NewUserManagementUsecase(UserStorage, &fakeTx{users: UserStorage}, usecase.DefaultPolicy())
+
+			user, err := usecase.SetDisabled(context.Background(), tt.actor, tt.id, tt.disabled)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.disabled, user.Disabled)
+			}
+
+			UserStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserManagementUsecase_DeleteUser(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	admin_id := uuid.Must(uuid.NewV4())
+	actor := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "admin", RequestID: "req-1"}
+	moderator := entity.Actor{UserID: uuid.Must(uuid.NewV4()), Role: "moderator", RequestID: "req-1"}
+	employee := &entity.User{ID: user_id, Role: "employee"}
+	admin := &entity.User{ID: admin_id, Role: "admin"}
+
+	tests := []struct {
+		name          string
+		actor         entity.Actor
+		id            uuid.UUID
+		mock          func(*MockUsersStorage)
+		expectedError error
+	}{
+		{
+			name:  "success",
+			actor: actor,
+			id:    user_id,
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, user_id).Return(employee, nil)
+				mus.On("DeleteUser", anyCtx, user_id, actor).Return(nil)
+			},
+		},
+		{
+			name:  "user not found",
+			actor: actor,
+			id:    user_id,
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, user_id).Return((*entity.User)(nil), sql.ErrNoRows)
+			},
+			expectedError: usecase.ErrUserNotFound,
+		},
+		{
+			name:  "user has pvz",
+			actor: actor,
+			id:    user_id,
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, user_id).Return(employee, nil)
+				mus.On("DeleteUser", anyCtx, user_id, actor).Return(storage.ErrUserInUse)
+			},
+			expectedError: usecase.ErrUserInUse,
+		},
+		{
+			name:          "delete self",
+			actor:         moderator,
+			id:            moderator.UserID,
+			mock:          func(mus *MockUsersStorage) {},
+			expectedError: usecase.ErrSelfManagement,
+		},
+		{
+			name:  "moderator deletes admin",
+			actor: moderator,
+			id:    admin_id,
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, admin_id).Return(admin, nil)
+			},
+			expectedError: usecase.ErrRoleNotCovered,
+		},
+		{
+			name:  "delete last admin",
+			actor: actor,
+			id:    admin_id,
+			mock: func(mus *MockUsersStorage) {
+				mus.On("GetUserByID", anyCtx, admin_id).Return(admin, nil)
+				mus.On("LockActiveUsers", anyCtx, []string{"admin"}).Return([]uuid.UUID{admin_id}, nil)
+			},
+			expectedError: usecase.ErrLastAdmin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			UserStorage := new(MockUsersStorage)
+			tt.mock(UserStorage)
+			usecase := usecase.NewUserManagementUsecase(UserStorage, &fakeTx{users: UserStorage}, usecase.DefaultPolicy())
+
+			err := usecase.DeleteUser(context.Background(), tt.actor, tt.id)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			UserStorage.AssertExpectations(t)
+		})
+	}
+}
@@ -1,54 +1,200 @@
 package usecase
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"pvz/internal/apperr"
 	"pvz/internal/storage"
+	"pvz/internal/storage/migrations/entity"
+	"time"
 
+	"github.com/gofrs/uuid/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserUsecase interface {
-	Login(email, password string) (string, error)
-	Register(email, password, role string) (string, error)
+	Login(ctx context.Context, email, password string) (*TokenPair, error)
+	VerifyLogin(ctx context.Context, challengeToken, code string) (*TokenPair, error)
+	Register(ctx context.Context, actor entity.Actor, email, password, role string) (string, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, userID, jti uuid.UUID, expiresAt time.Time, refreshToken string) error
+}
+
+var (
+	ErrUserDisabled       = apperr.Unauthorized("account_disabled", "account disabled")
+	ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "invalid credentials")
+	ErrUserExists         = apperr.Conflict("user_exists", "user already exists")
+)
+
+// TokenPair при включённом втором факторе содержит только ChallengeToken,
+// который обменивается на токены через VerifyLogin.
+type TokenPair struct {
+	AccessToken    string `json:"token,omitempty"`
+	RefreshToken   string `json:"refreshToken,omitempty"`
+	ChallengeToken string `json:"challengeToken,omitempty"`
 }
 
 type UserUsecaseImpl struct {
 	userStorage storage.UsersPostgresStorage
+	txManager   storage.TxManager
 	authService AuthUsecase
+	validator   *CredentialsValidator
+	twoFactor   TwoFactorUsecase
 }
 
-func NewUserUsecase(userStorage storage.UsersPostgresStorage, authService AuthUsecase) *UserUsecaseImpl {
-	return &UserUsecaseImpl{userStorage: userStorage, authService: authService}
+func NewUserUsecase(userStorage storage.UsersPostgresStorage, txManager storage.TxManager, authService AuthUsecase, validator *CredentialsValidator, twoFactor TwoFactorUsecase) *UserUsecaseImpl {
+	return &UserUsecaseImpl{userStorage: userStorage, txManager: txManager, authService: authService, validator: validator, twoFactor: twoFactor}
 }
 
-func (u *UserUsecaseImpl) Login(email, password string) (string, error) {
-	user, _, err := u.userStorage.GetUserByEmail(email)
+func (u *UserUsecaseImpl) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "UserUsecase.Login")
+	defer span.End()
+
+	user, _, err := u.userStorage.GetUserByEmail(ctx, email)
 	if err == sql.ErrNoRows {
-		return "", fmt.Errorf("invalid credentials")
+		return nil, ErrInvalidCredentials
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return "", fmt.Errorf("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	enabled, err := u.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		challengeToken, err := u.authService.GenerateChallengeToken(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &TokenPair{ChallengeToken: challengeToken}, nil
+	}
+
+	return u.issueTokens(ctx, user, false)
+}
+
+func (u *UserUsecaseImpl) VerifyLogin(ctx context.Context, challengeToken, code string) (*TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "UserUsecase.VerifyLogin")
+	defer span.End()
+
+	userID, err := u.authService.ValidateChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.twoFactor.Authenticate(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	user, err := u.userStorage.GetUserByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidChallengeToken
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	return u.issueTokens(ctx, user, true)
+}
+
+func (u *UserUsecaseImpl) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "UserUsecase.Refresh")
+	defer span.End()
+
+	userID, newRefreshToken, err := u.authService.RotateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	return u.authService.GenerateToken(user.ID, user.Role)
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	// после подтверждения второго фактора все старые сессии отзываются,
+	// поэтому refresh токен такого пользователя мог быть выдан только после 2FA
+	mfa, err := u.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := u.authService.GenerateToken(user.ID, user.Role, mfa)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: accessToken, RefreshToken: newRefreshToken}, nil
 }
 
-func (u *UserUsecaseImpl) Register(email, password, role string) (string, error) {
-	user, ok, err := u.userStorage.GetUserByEmail(email)
-	if err != nil && err != sql.ErrNoRows {
-		return "", fmt.Errorf("error: %w", err)
+func (u *UserUsecaseImpl) Logout(ctx context.Context, userID, jti uuid.UUID, expiresAt time.Time, refreshToken string) error {
+	ctx, span := tracer.Start(ctx, "UserUsecase.Logout")
+	defer span.End()
+
+	if err := u.authService.RevokeToken(ctx, jti, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
 	}
 
-	if ok {
-		return "", errors.New("user exist")
+	if refreshToken == "" {
+		return nil
 	}
+	return u.authService.RevokeRefreshToken(ctx, userID, refreshToken)
+}
+
+func (u *UserUsecaseImpl) issueTokens(ctx context.Context, user *entity.User, mfa bool) (*TokenPair, error) {
+	accessToken, err := u.authService.GenerateToken(user.ID, user.Role, mfa)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := u.authService.GenerateRefreshToken(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (u *UserUsecaseImpl) Register(ctx context.Context, actor entity.Actor, email, password, role string) (string, error) {
+	ctx, span := tracer.Start(ctx, "UserUsecase.Register")
+	defer span.End()
+
+	if err := u.validator.ValidateRegistration(email, password, role); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	var user *entity.User
+	err = u.txManager.WithTx(ctx, func(tx storage.Tx) error {
+		_, ok, err := tx.Users().GetUserByEmail(ctx, email)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("error: %w", err)
+		}
+
+		if ok {
+			return ErrUserExists
+		}
 
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	user, err = u.userStorage.CreateUser(email, string(hashedPassword), role)
+		user, err = tx.Users().CreateUser(ctx, email, string(hashedPassword), role, actor)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -1,11 +1,13 @@
 package usecase_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"pvz/internal/storage/migrations/entity"
 	"pvz/internal/usecase"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/golang-jwt/jwt"
@@ -18,30 +20,113 @@ type MockUsersStorage struct {
 	mock.Mock
 }
 
-func (m *MockUsersStorage) GetUserByEmail(email string) (*entity.User, bool, error) {
-	args := m.Called(email)
+func (m *MockUsersStorage) GetUserByEmail(ctx context.Context, email string) (*entity.User, bool, error) {
+	args := m.Called(ctx, email)
 	return args.Get(0).(*entity.User), args.Bool(1), args.Error(2)
 }
 
-func (m *MockUsersStorage) CreateUser(email, password, role string) (*entity.User, error) {
-	args := m.Called(email, password, role)
+func (m *MockUsersStorage) GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockUsersStorage) CreateUser(ctx context.Context, email, password, role string, actor entity.Actor) (*entity.User, error) {
+	args := m.Called(ctx, email, password, role, actor)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUsersStorage) ListUsers(ctx context.Context, filter entity.UserFilter) ([]entity.User, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entity.User), args.Error(1)
+}
+
+func (m *MockUsersStorage) CountUsers(ctx context.Context, filter entity.UserFilter) (int, error) {
+	args := m.Called(ctx, filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUsersStorage) LockActiveUsers(ctx context.Context, roles []string) ([]uuid.UUID, error) {
+	args := m.Called(ctx, roles)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockUsersStorage) UpdateUserRole(ctx context.Context, id uuid.UUID, role string, actor entity.Actor) error {
+	args := m.Called(ctx, id, role, actor)
+	return args.Error(0)
+}
+
+func (m *MockUsersStorage) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool, actor entity.Actor) error {
+	args := m.Called(ctx, id, disabled, actor)
+	return args.Error(0)
+}
+
+func (m *MockUsersStorage) UpdatePassword(ctx context.Context, id uuid.UUID, password string, reset bool, actor entity.Actor) error {
+	args := m.Called(ctx, id, password, reset, actor)
+	return args.Error(0)
+}
+
+func (m *MockUsersStorage) DeleteUser(ctx context.Context, id uuid.UUID, actor entity.Actor) error {
+	args := m.Called(ctx, id, actor)
+	return args.Error(0)
+}
+
 type MockAuthService struct {
 	mock.Mock
 }
 
-func (m *MockAuthService) GenerateToken(userID uuid.UUID, role string) (string, error) {
-	args := m.Called(userID, role)
+func (m *MockAuthService) GenerateToken(userID uuid.UUID, role string, mfa bool) (string, error) {
+	args := m.Called(userID, role, mfa)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) GenerateChallengeToken(userID uuid.UUID) (string, error) {
+	args := m.Called(userID)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockAuthService) ValidateChallengeToken(tokenString string) (uuid.UUID, error) {
+	args := m.Called(tokenString)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
 func (m *MockAuthService) ValidateToken(tokenString string) (*jwt.Token, error) {
 	args := m.Called(tokenString)
 	return args.Get(0).(*jwt.Token), args.Error(1)
 }
 
+func (m *MockAuthService) GenerateRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) RotateRefreshToken(ctx context.Context, refreshToken string) (uuid.UUID, string, error) {
+	args := m.Called(ctx, refreshToken)
+	return args.Get(0).(uuid.UUID), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthService) RevokeRefreshToken(ctx context.Context, userID uuid.UUID, refreshToken string) error {
+	args := m.Called(ctx, userID, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) IsTokenRevoked(ctx context.Context, jti uuid.UUID) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthService) ActiveUser(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
+	args := m.Called(ctx, userID)
+	if user, ok := args.Get(0).(*entity.User); ok {
+		return user, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func TestUserUsecase_Login(t *testing.T) {
 	userID := uuid.Must(uuid.NewV4())
 	email := "test@example.com"
@@ -49,6 +134,7 @@ func TestUserUsecase_Login(t *testing.T) {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	role := "moderator"
 	token := "test_token"
+	refreshToken := "test_refresh_token"
 
 	tests := []struct {
 		name          string
@@ -58,7 +144,7 @@ func TestUserUsecase_Login(t *testing.T) {
 		mockUserErr   error
 		mockToken     string
 		mockTokenErr  error
-		expected      string
+		expected      *usecase.TokenPair
 		expectedError string
 	}{
 		{
@@ -72,7 +158,7 @@ func TestUserUsecase_Login(t *testing.T) {
 				Role:     role,
 			},
 			mockToken: token,
-			expected:  token,
+			expected:  &usecase.TokenPair{AccessToken: token, RefreshToken: refreshToken},
 		},
 		{
 			name:          "user not found",
@@ -106,29 +192,47 @@ func TestUserUsecase_Login(t *testing.T) {
 			mockTokenErr:  errors.New("token error"),
 			expectedError: "token error",
 		},
+		{
+			name:     "disabled account",
+			email:    email,
+			password: password,
+			mockUser: &entity.User{
+				ID:       userID,
+				Email:    email,
+				Password: string(hashedPassword),
+				Role:     role,
+				Disabled: true,
+			},
+			expectedError: "account disabled",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			userStorage := new(MockUsersStorage)
 			authService := new(MockAuthService)
-			usecase := usecase.NewUserUsecase(userStorage, authService)
+			twoFactor := new(MockTwoFactorUsecase)
+			usecase := usecase.NewUserUsecase(userStorage, &fakeTx{users: userStorage}, authService, testValidator(), twoFactor)
 
-			userStorage.On("GetUserByEmail", tt.email).Return(tt.mockUser, false, tt.mockUserErr)
+			userStorage.On("GetUserByEmail", anyCtx, tt.email).Return(tt.mockUser, false, tt.mockUserErr)
 
-			if tt.mockUser != nil && tt.mockUserErr == nil && tt.expectedError != "invalid credentials" {
-				authService.On("GenerateToken", tt.mockUser.ID, tt.mockUser.Role).Return(tt.mockToken, tt.mockTokenErr)
+			if tt.mockUser != nil && tt.mockUserErr == nil && !tt.mockUser.Disabled && tt.expectedError != "invalid credentials" {
+				twoFactor.On("IsEnabled", anyCtx, tt.mockUser.ID).Return(false, nil)
+				authService.On("GenerateToken", tt.mockUser.ID, tt.mockUser.Role, false).Return(tt.mockToken, tt.mockTokenErr)
+				if tt.mockTokenErr == nil {
+					authService.On("GenerateRefreshToken", anyCtx, tt.mockUser.ID).Return(refreshToken, nil)
+				}
 			}
 
-			token, err := usecase.Login(tt.email, tt.password)
+			tokens, err := usecase.Login(context.Background(), tt.email, tt.password)
 
 			if tt.expectedError != "" {
 				assert.Error(t, err)
 				assert.EqualError(t, err, tt.expectedError)
-				assert.Empty(t, token)
+				assert.Nil(t, tokens)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expected, token)
+				assert.Equal(t, tt.expected, tokens)
 			}
 
 			userStorage.AssertExpectations(t)
@@ -140,8 +244,8 @@ func TestUserUsecase_Login(t *testing.T) {
 func TestUserUsecase_Register(t *testing.T) {
 	userID := uuid.Must(uuid.NewV4())
 	email := "test@example.com"
-	password := "password123"
-	role := "user"
+	password := "Password123"
+	role := "employee"
 
 	tests := []struct {
 		name          string
@@ -172,7 +276,7 @@ func TestUserUsecase_Register(t *testing.T) {
 			role:          role,
 			empty:         true,
 			mockGetUser:   &entity.User{},
-			expectedError: "user exist",
+			expectedError: "user already exists",
 		},
 		{
 			name:          "get user error",
@@ -183,6 +287,13 @@ func TestUserUsecase_Register(t *testing.T) {
 			mockGetErr:    errors.New("db error"),
 			expectedError: "error: db error",
 		},
+		{
+			name:          "invalid input",
+			email:         "not an email",
+			password:      "short",
+			role:          "admin",
+			expectedError: "validation failed: email: is not a valid email address; password: must be at least 8 characters long; password: must contain an uppercase letter; password: must contain a digit; role: is not allowed",
+		},
 		{
 			name:          "create user error",
 			email:         email,
@@ -199,16 +310,19 @@ func TestUserUsecase_Register(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			userStorage := new(MockUsersStorage)
 			authService := new(MockAuthService)
-			usecase := usecase.NewUserUsecase(userStorage, authService)
+			twoFactor := new(MockTwoFactorUsecase)
+			usecase := usecase.NewUserUsecase(userStorage, &fakeTx{users: userStorage}, authService, testValidator(), twoFactor)
 
-			userStorage.On("GetUserByEmail", tt.email).Return(tt.mockGetUser, tt.empty, tt.mockGetErr)
+			if tt.name != "invalid input" {
+				userStorage.On("GetUserByEmail", anyCtx, tt.email).Return(tt.mockGetUser, tt.empty, tt.mockGetErr)
+			}
 
 			if tt.mockGetErr == sql.ErrNoRows && tt.expectedError != "user exists" {
-				userStorage.On("CreateUser", tt.email, mock.Anything, tt.role).
+				userStorage.On("CreateUser", anyCtx, tt.email, mock.Anything, tt.role, entity.Actor{IP: "10.0.0.1"}).
 					Return(&entity.User{ID: userID}, tt.mockCreateErr)
 			}
 
-			result, err := usecase.Register(tt.email, tt.password, tt.role)
+			result, err := usecase.Register(context.Background(), entity.Actor{IP: "10.0.0.1"}, tt.email, tt.password, tt.role)
 
 			if tt.expectedError != "" {
 				assert.Error(t, err)
@@ -223,3 +337,120 @@ func TestUserUsecase_Register(t *testing.T) {
 		})
 	}
 }
+
+func TestUserUsecase_Refresh(t *testing.T) {
+	userID := uuid.Must(uuid.NewV4())
+	role := "employee"
+
+	tests := []struct {
+		name          string
+		refreshToken  string
+		mockRotateErr error
+		mockUser      *entity.User
+		mockUserErr   error
+		expected      *usecase.TokenPair
+		expectedError string
+	}{
+		{
+			name:         "success",
+			refreshToken: "old_refresh_token",
+			mockUser:     &entity.User{ID: userID, Role: role},
+			expected:     &usecase.TokenPair{AccessToken: "new_token", RefreshToken: "new_refresh_token"},
+		},
+		{
+			name:          "invalid refresh token",
+			refreshToken:  "old_refresh_token",
+			mockRotateErr: usecase.ErrInvalidRefreshToken,
+			expectedError: "invalid refresh token",
+		},
+		{
+			name:          "user not found",
+			refreshToken:  "old_refresh_token",
+			mockUser:      &entity.User{},
+			mockUserErr:   sql.ErrNoRows,
+			expectedError: "failed to get user: sql: no rows in result set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userStorage := new(MockUsersStorage)
+			authService := new(MockAuthService)
+			twoFactor := new(MockTwoFactorUsecase)
+			usecase := usecase.NewUserUsecase(userStorage, &fakeTx{users: userStorage}, authService, testValidator(), twoFactor)
+
+			authService.On("RotateRefreshToken", anyCtx, tt.refreshToken).Return(userID, "new_refresh_token", tt.mockRotateErr)
+			if tt.mockRotateErr == nil {
+				userStorage.On("GetUserByID", anyCtx, userID).Return(tt.mockUser, tt.mockUserErr)
+			}
+			if tt.mockRotateErr == nil && tt.mockUserErr == nil {
+				twoFactor.On("IsEnabled", anyCtx, userID).Return(false, nil)
+				authService.On("GenerateToken", userID, role, false).Return("new_token", nil)
+			}
+
+			tokens, err := usecase.Refresh(context.Background(), tt.refreshToken)
+
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, tokens)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, tokens)
+			}
+
+			userStorage.AssertExpectations(t)
+			authService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserUsecase_Logout(t *testing.T) {
+	user_id := uuid.Must(uuid.NewV4())
+	jti := uuid.Must(uuid.NewV4())
+	exp := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name          string
+		refreshToken  string
+		mockRevokeErr error
+		expectedError string
+	}{
+		{
+			name:         "success",
+			refreshToken: "refresh_token",
+		},
+		{
+			name: "without refresh token",
+		},
+		{
+			name:          "revoke error",
+			mockRevokeErr: errors.New("db error"),
+			expectedError: "failed to revoke token: db error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userStorage := new(MockUsersStorage)
+			authService := new(MockAuthService)
+			twoFactor := new(MockTwoFactorUsecase)
+			usecase := usecase.NewUserUsecase(userStorage, &fakeTx{users: userStorage}, authService, testValidator(), twoFactor)
+
+			authService.On("RevokeToken", anyCtx, jti, exp).Return(tt.mockRevokeErr)
+			if tt.refreshToken != "" {
+				authService.On("RevokeRefreshToken", anyCtx, user_id, tt.refreshToken).Return(nil)
+			}
+
+			err := usecase.Logout(context.Background(), user_id, jti, exp, tt.refreshToken)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			authService.AssertExpectations(t)
+		})
+	}
+}
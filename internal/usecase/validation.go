@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"bufio"
+	"fmt"
+	"net/mail"
+	"os"
+	"pvz/internal/apperr"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError собирает все нарушения, а не только первое,
+// чтобы клиент мог показать их разом.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Is относит ошибку валидации к виду apperr.ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == apperr.ErrValidation
+}
+
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	Breached      map[string]struct{}
+}
+
+// DefaultPasswordPolicy ограничивает длину сверху 72 байтами:
+// bcrypt не принимает более длинные пароли.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:    8,
+		MaxLength:    72,
+		RequireLower: true,
+		RequireUpper: true,
+		RequireDigit: true,
+	}
+}
+
+// LoadBreachedPasswords читает список скомпрометированных паролей,
+// по одному на строку. Пустые строки и строки с # пропускаются.
+func LoadBreachedPasswords(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords: %w", err)
+	}
+	defer file.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached passwords: %w", err)
+	}
+	return breached, nil
+}
+
+func (p PasswordPolicy) Violations(password string) []string {
+	var violations []string
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes long", p.MaxLength))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if _, ok := p.Breached[strings.ToLower(password)]; ok {
+		violations = append(violations, "appears in a list of breached passwords")
+	}
+	return violations
+}
+
+type CredentialsValidator struct {
+	passwordPolicy PasswordPolicy
+	roles          map[string]struct{}
+}
+
+// NewCredentialsValidator принимает роли, которые можно выбрать при регистрации.
+func NewCredentialsValidator(passwordPolicy PasswordPolicy, roles ...string) *CredentialsValidator {
+	validator := &CredentialsValidator{passwordPolicy: passwordPolicy, roles: make(map[string]struct{})}
+	for _, role := range roles {
+		validator.roles[role] = struct{}{}
+	}
+	return validator
+}
+
+func (v *CredentialsValidator) ValidateRegistration(email, password, role string) error {
+	var verr ValidationError
+
+	if email == "" {
+		verr.Add("email", "is required")
+	} else if !validEmail(email) {
+		verr.Add("email", "is not a valid email address")
+	}
+
+	v.checkPassword(&verr, "password", password)
+
+	if role == "" {
+		verr.Add("role", "is required")
+	} else if _, ok := v.roles[role]; !ok {
+		verr.Add("role", "is not allowed")
+	}
+
+	return verr.OrNil()
+}
+
+func (v *CredentialsValidator) ValidatePassword(field, password string) error {
+	var verr ValidationError
+	v.checkPassword(&verr, field, password)
+	return verr.OrNil()
+}
+
+func (v *CredentialsValidator) checkPassword(verr *ValidationError, field, password string) {
+	if password == "" {
+		verr.Add(field, "is required")
+		return
+	}
+	for _, violation := range v.passwordPolicy.Violations(password) {
+		verr.Add(field, violation)
+	}
+}
+
+// validEmail принимает только адрес по RFC 5322 без отображаемого имени
+// и комментариев, то есть ровно то, что пригодно для отправки письма.
+func validEmail(email string) bool {
+	if len(email) > 254 {
+		return false
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	return at > 0 && at <= 64 && at < len(email)-1
+}
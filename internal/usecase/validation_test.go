@@ -0,0 +1,104 @@
+package usecase_test
+
+import (
+	"os"
+	"path/filepath"
+	"pvz/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testValidator() *usecase.CredentialsValidator {
+	return usecase.NewCredentialsValidator(usecase.DefaultPasswordPolicy(), "employee", "moderator")
+}
+
+func TestCredentialsValidator_ValidateRegistration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# top passwords\nPassword123\n\nQwerty123\n"), 0o600))
+	breached, err := usecase.LoadBreachedPasswords(path)
+	require.NoError(t, err)
+
+	policy := usecase.DefaultPasswordPolicy()
+	policy.Breached = breached
+	validator := usecase.NewCredentialsValidator(policy, "employee", "moderator")
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		role     string
+		expected []usecase.FieldError
+	}{
+		{
+			name:     "valid",
+			email:    "employee@example.com",
+			password: "Str0ngPassw",
+			role:     "employee",
+		},
+		{
+			name:     "all fields empty",
+			expected: []usecase.FieldError{{Field: "email", Message: "is required"}, {Field: "password", Message: "is required"}, {Field: "role", Message: "is required"}},
+		},
+		{
+			name:     "email with display name",
+			email:    "Employee <employee@example.com>",
+			password: "Str0ngPassw",
+			role:     "employee",
+			expected: []usecase.FieldError{{Field: "email", Message: "is not a valid email address"}},
+		},
+		{
+			name:     "email without domain",
+			email:    "employee@",
+			password: "Str0ngPassw",
+			role:     "moderator",
+			expected: []usecase.FieldError{{Field: "email", Message: "is not a valid email address"}},
+		},
+		{
+			name:     "breached password in other case",
+			email:    "employee@example.com",
+			password: "PASSWORD123",
+			role:     "employee",
+			expected: []usecase.FieldError{
+				{Field: "password", Message: "must contain a lowercase letter"},
+				{Field: "password", Message: "appears in a list of breached passwords"},
+			},
+		},
+		{
+			name:     "short password counted in characters",
+			email:    "employee@example.com",
+			password: "Пароль1",
+			role:     "employee",
+			expected: []usecase.FieldError{{Field: "password", Message: "must be at least 8 characters long"}},
+		},
+		{
+			name:     "password longer than bcrypt limit",
+			email:    "employee@example.com",
+			password: "Aa1" + string(make([]byte, 70)),
+			role:     "employee",
+			expected: []usecase.FieldError{{Field: "password", Message: "must be at most 72 bytes long"}},
+		},
+		{
+			name:     "role outside of registration roles",
+			email:    "employee@example.com",
+			password: "Str0ngPassw",
+			role:     "admin",
+			expected: []usecase.FieldError{{Field: "role", Message: "is not allowed"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateRegistration(tt.email, tt.password, tt.role)
+
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *usecase.ValidationError
+			require.ErrorAs(t, err, &verr)
+			assert.Equal(t, tt.expected, verr.Fields)
+		})
+	}
+}